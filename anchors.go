@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// dedupeAnchors renames headings whose anchor collides with a heading from an earlier file by
+// appending the file's base name, e.g. "Setup" -> "Setup (install)". The renames are recorded on
+// the files and applied when they are merged. It returns the number of headings renamed.
+func dedupeAnchors(cliArgs *CLIArgs, files []MarkdownFile) int {
+	_, titleFromFile := resolveDocumentTitle(cliArgs, files)
+
+	owners := make(map[string]int) // Anchor -> index of the first file using it
+	renamed := 0
+	for i := range files {
+		content, err := files[i].readText(cliArgs)
+		if err != nil {
+			continue
+		}
+
+		_, body, _ := mergeFileContent(cliArgs, files[i], content, i == 0 && titleFromFile)
+		for _, header := range extractHeaders(body, 6) {
+			anchor := headingAnchor(header.Text)
+			owner, taken := owners[anchor]
+			if !taken {
+				owners[anchor] = i
+				continue
+			}
+
+			// Repeats within one file are numbered as usual; only cross-file collisions are renamed
+			if owner == i {
+				continue
+			}
+			if _, done := files[i].HeadingRenames[header.Text]; done {
+				continue
+			}
+
+			text := fmt.Sprintf("%s (%s)", header.Text, strings.TrimSuffix(files[i].Name, filepath.Ext(files[i].Name)))
+			if files[i].HeadingRenames == nil {
+				files[i].HeadingRenames = make(map[string]string)
+			}
+			files[i].HeadingRenames[header.Text] = text
+			owners[headingAnchor(text)] = i
+			renamed++
+		}
+	}
+
+	return renamed
+}
+
+// headingAnchor returns the anchor of a heading, ignoring any other headings in the document
+func headingAnchor(text string) string {
+	return slugify(text, make(map[string]int))
+}
+
+// applyHeadingRenames replaces the text of renamed headings in content and updates links to their
+// anchors within the same file
+func applyHeadingRenames(content string, renames map[string]string) string {
+	if len(renames) == 0 {
+		return content
+	}
+
+	anchors := make(map[string]string, len(renames))
+	for old, text := range renames {
+		anchors["#"+headingAnchor(old)] = "#" + headingAnchor(text)
+	}
+
+	lines := strings.Split(content, "\n")
+	var fence codeFenceTracker
+	for n, line := range lines {
+		if fence.inCode(line) {
+			continue
+		}
+
+		if setextLevel(lines, n) > 0 {
+			if text, ok := renames[strings.TrimSpace(line)]; ok {
+				lines[n] = text
+			}
+		} else if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "#") {
+			marker := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "#"))]
+			if text, ok := renames[strings.TrimSpace(trimmed[len(marker):])]; ok {
+				lines[n] = marker + " " + text
+				continue
+			}
+		}
+
+		lines[n] = linkDestinationPattern.ReplaceAllStringFunc(lines[n], func(match string) string {
+			groups := linkDestinationPattern.FindStringSubmatch(match)
+			if anchor, ok := anchors[groups[2]]; ok {
+				return groups[1] + anchor
+			}
+			return match
+		})
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeAnchors(t *testing.T) {
+	tempDir := t.TempDir()
+	contents := map[string]string{
+		"install.md": "# Install\n\n## Setup\n\n## Usage\n",
+		"deploy.md":  "# Deploy\n\n## Setup\n\nSee [setup](#setup).\n\n## Setup\n",
+	}
+	var files []MarkdownFile
+	for _, name := range []string{"install.md", "deploy.md"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(contents[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, MarkdownFile{Path: path, Name: name})
+	}
+
+	cliArgs := &CLIArgs{MergeOutputFile: "merged.md", MergeBaseLevel: 2}
+	if renamed := dedupeAnchors(cliArgs, files); renamed != 1 {
+		t.Errorf("dedupeAnchors() renamed %d headings, want 1", renamed)
+	}
+	if files[0].HeadingRenames != nil {
+		t.Errorf("Expected the first file to keep its headings, got %v", files[0].HeadingRenames)
+	}
+	expected := map[string]string{"Setup": "Setup (deploy)"}
+	if !reflect.DeepEqual(files[1].HeadingRenames, expected) {
+		t.Errorf("HeadingRenames = %v, want %v", files[1].HeadingRenames, expected)
+	}
+}
+
+func TestApplyHeadingRenames(t *testing.T) {
+	renames := map[string]string{"Setup": "Setup (deploy)"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"ATX heading", "## Setup", "## Setup (deploy)"},
+		{"Setext heading", "Setup\n-----", "Setup (deploy)\n-----"},
+		{"Same-document link", "See [setup](#setup).", "See [setup](#setup-deploy)."},
+		{"Other anchors kept", "See [usage](#usage).", "See [usage](#usage)."},
+		{"Code fence", "```\n## Setup\n```", "```\n## Setup\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := applyHeadingRenames(tt.input, renames); result != tt.expected {
+				t.Errorf("applyHeadingRenames(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
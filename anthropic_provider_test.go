@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestAnthropicProvider(t *testing.T, handler http.HandlerFunc) *AnthropicProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	provider, err := NewAnthropicProvider(ProviderConfig{
+		ProviderType:    ProviderTypeAnthropic,
+		AnthropicAPIKey: "test-key",
+		AnthropicModel:  "claude-3-5-haiku-20241022",
+	})
+	if err != nil {
+		t.Fatalf("NewAnthropicProvider() error: %v", err)
+	}
+	provider.apiURL = server.URL
+	return provider
+}
+
+func TestAnthropicProviderTranslate(t *testing.T) {
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("x-api-key = %q, want %q", r.Header.Get("x-api-key"), "test-key")
+		}
+		if r.Header.Get("anthropic-version") != anthropicAPIVersion {
+			t.Errorf("anthropic-version = %q, want %q", r.Header.Get("anthropic-version"), anthropicAPIVersion)
+		}
+
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "claude-3-5-haiku-20241022" {
+			t.Errorf("Model = %q, want configured model", req.Model)
+		}
+		if req.System == "" || len(req.Messages) != 1 || !strings.Contains(req.Messages[0].Content, "Hello") {
+			t.Errorf("Unexpected request shape: %+v", req)
+		}
+
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"こんにちは"},{"type":"text","text":"世界"}],"stop_reason":"end_turn"}`))
+	})
+
+	response, err := provider.Translate(context.Background(), "Hello world", TranslationOptions{TargetLanguage: "ja"})
+	if err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+	if response.Content != "こんにちは世界" {
+		t.Errorf("Content = %q, want %q", response.Content, "こんにちは世界")
+	}
+	if response.Status != "success" {
+		t.Errorf("Status = %q, want success", response.Status)
+	}
+}
+
+func TestAnthropicProviderTranslateAPIError(t *testing.T) {
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	})
+
+	_, err := provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "ja"})
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	if !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Errorf("Error %q should include status code and API message", err)
+	}
+}
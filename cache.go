@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bigdra50/doc/internal/config"
+)
+
+// translationCache stores translations on disk so unchanged content is not sent to the provider again
+type translationCache struct {
+	Dir      string
+	Provider string
+	Model    string
+}
+
+// getCacheDir returns the translation cache directory under the config directory
+func getCacheDir() string {
+	configDir := config.GetConfigDir()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "cache")
+}
+
+// newTranslationCache returns a cache for translations made with the configured provider and model,
+// or nil if no cache directory is available
+func newTranslationCache(cfg ProviderConfig) *translationCache {
+	dir := getCacheDir()
+	if dir == "" {
+		return nil
+	}
+
+	model := configuredModel(cfg, cfg.ProviderType)
+	if model == "" {
+		model = GetDefaultModel(cfg.ProviderType)
+	}
+	return &translationCache{Dir: dir, Provider: cfg.ProviderType, Model: model}
+}
+
+// key returns the SHA-256 of everything that affects the translation of content
+func (c *translationCache) key(content string, options TranslationOptions) string {
+	strict := ""
+	if options.Strict {
+		strict = "strict"
+	}
+
+	glossary := glossaryInstruction(options.Glossary)
+	parts := []string{c.Provider, c.Model, options.TargetLanguage, options.CustomInstruction, glossary, strict, content}
+	// Only a temperature override is hashed, so translations at the default keep their keys
+	if options.Temperature != nil {
+		parts = append(parts, "temperature="+strconv.FormatFloat(*options.Temperature, 'g', -1, 64))
+	}
+
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0}) // Separator so adjacent fields cannot run together
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// path returns the cache file for a key
+func (c *translationCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".md")
+}
+
+// Get returns the cached translation of content, if any
+func (c *translationCache) Get(content string, options TranslationOptions) (string, bool) {
+	data, err := os.ReadFile(c.path(c.key(content, options)))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put stores the translation of content
+func (c *translationCache) Put(content string, options TranslationOptions, translation string) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial entry
+	path := c.path(c.key(content, options))
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.WriteString(translation); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// clearTranslationCache removes all cached translations and returns how many entries were removed
+func clearTranslationCache(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".md") {
+			removed++
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, fmt.Errorf("failed to remove cache directory: %w", err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTranslationCache(t *testing.T) {
+	cache := &translationCache{Dir: t.TempDir(), Provider: ProviderTypeOpenAI, Model: "gpt-4o-mini"}
+	options := TranslationOptions{TargetLanguage: "ja"}
+
+	if _, ok := cache.Get("Hello", options); ok {
+		t.Fatal("Expected a miss on an empty cache")
+	}
+	if err := cache.Put("Hello", options, "こんにちは"); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if cached, ok := cache.Get("Hello", options); !ok || cached != "こんにちは" {
+		t.Errorf("Get() = %q, %v; want cached translation", cached, ok)
+	}
+
+	// Every part of the key must change the entry
+	variants := []struct {
+		name    string
+		cache   *translationCache
+		options TranslationOptions
+		content string
+	}{
+		{"content", cache, options, "Hello!"},
+		{"language", cache, TranslationOptions{TargetLanguage: "fr"}, "Hello"},
+		{"instruction", cache, TranslationOptions{TargetLanguage: "ja", CustomInstruction: "formal"}, "Hello"},
+		{"strict", cache, TranslationOptions{TargetLanguage: "ja", Strict: true}, "Hello"},
+		{"model", &translationCache{Dir: cache.Dir, Provider: ProviderTypeOpenAI, Model: "gpt-4o"}, options, "Hello"},
+		{"provider", &translationCache{Dir: cache.Dir, Provider: ProviderTypeOllama, Model: "gpt-4o-mini"}, options, "Hello"},
+	}
+	for _, v := range variants {
+		if _, ok := v.cache.Get(v.content, v.options); ok {
+			t.Errorf("Changing the %s should miss the cache", v.name)
+		}
+	}
+
+	removed, err := clearTranslationCache(cache.Dir)
+	if err != nil || removed != 1 {
+		t.Errorf("clearTranslationCache() = %d, %v; want 1 entry removed", removed, err)
+	}
+	if _, err := os.Stat(cache.Dir); !os.IsNotExist(err) {
+		t.Errorf("Expected cache directory to be removed, got %v", err)
+	}
+}
+
+func TestPerformTranslationUsesCache(t *testing.T) {
+	originalStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = originalStderr }()
+
+	provider := &fakeProvider{responses: []string{"Bonjour"}}
+	options := TranslationOptions{
+		TargetLanguage: "fr",
+		Cache:          &translationCache{Dir: t.TempDir(), Provider: "fake", Model: "fake"},
+	}
+
+	for range 2 {
+		result, err := performTranslation(provider, "Hello", options)
+		if err != nil || result != "Bonjour" {
+			t.Fatalf("performTranslation() = %q, %v", result, err)
+		}
+	}
+	if len(provider.calls) != 1 {
+		t.Errorf("Expected the provider to be called once, got %d calls", len(provider.calls))
+	}
+}
+
+func TestTranslateWithRepairBypassesCache(t *testing.T) {
+	originalStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = originalStderr }()
+
+	source := "# Title\n\n```\ncode\n```\n"
+	options := TranslationOptions{
+		TargetLanguage: "fr",
+		Cache:          &translationCache{Dir: t.TempDir(), Provider: "fake", Model: "fake"},
+	}
+
+	// Every attempt returns a different broken translation, so every repair must reach the provider
+	const maxRepairs = 3
+	provider := &fakeProvider{responses: []string{"# Titre 1\n\n```\ncode\n", "# Titre 2\n\n```\ncode\n", "# Titre 3\n\n```\ncode\n", "# Titre 4\n\n```\ncode\n"}}
+	if _, err := translateWithRepair(provider, source, options, maxRepairs); err != nil {
+		t.Fatalf("translateWithRepair() error: %v", err)
+	}
+	if len(provider.calls) != 1+maxRepairs {
+		t.Errorf("Expected 1 translation and %d repairs, got %d provider calls", maxRepairs, len(provider.calls))
+	}
+	if cached, ok := options.Cache.Get(source, options); ok {
+		t.Errorf("Expected a broken translation not to be cached, got %q", cached)
+	}
+
+	// A successful repair is cached under the original options, so a rerun needs no provider call
+	fixed := "# Titre\n\n```\ncode\n```\n"
+	provider = &fakeProvider{responses: []string{"# Titre\n\n```\ncode\n", fixed}}
+	for range 2 {
+		result, err := translateWithRepair(provider, source, options, maxRepairs)
+		if err != nil || result != fixed {
+			t.Fatalf("translateWithRepair() = %q, %v; want %q", result, err, fixed)
+		}
+	}
+	if len(provider.calls) != 2 {
+		t.Errorf("Expected the rerun to use the cached repair, got %d provider calls", len(provider.calls))
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// promptOverheadTokens approximates the tokens used by the system and user prompt around a chunk
+const promptOverheadTokens = 500
+
+// textBlock is a range of non-blank lines [start, end) that must be translated together
+type textBlock struct {
+	start, end int
+}
+
+// defaultChunkTokens returns the largest chunk size for the model: the chunk plus the prompt
+// and output reserve must fit the context window, and its translation must fit the output reserve
+// and, at outputGrowthPercent of the chunk, the model's MaxOutputTokens.
+func defaultChunkTokens(provider, modelID string) int {
+	budget := defaultOutputTokenReserve
+	if contextWindow := contextWindowFor(provider, modelID); contextWindow > 0 {
+		budget = min(budget, contextWindow-defaultOutputTokenReserve-promptOverheadTokens)
+	}
+	if maxOutput := maxOutputTokensFor(provider, modelID); maxOutput > 0 {
+		budget = min(budget, maxOutput*100/outputGrowthPercent)
+	}
+	return max(budget, 1)
+}
+
+// splitMarkdownBlocks splits lines into blocks separated by blank lines.
+// Headers always start a new block and fenced code blocks are never split.
+func splitMarkdownBlocks(lines []string) []textBlock {
+	var blocks []textBlock
+	var fence codeFenceTracker
+	start := -1
+
+	for i, line := range lines {
+		wasInCode := fence.marker != ""
+		inCode := fence.inCode(line)
+
+		if !wasInCode && !inCode && strings.TrimSpace(line) == "" {
+			if start >= 0 {
+				blocks = append(blocks, textBlock{start, i})
+				start = -1
+			}
+			continue
+		}
+
+		if !wasInCode && start >= 0 && strings.HasPrefix(strings.TrimSpace(line), "#") {
+			blocks = append(blocks, textBlock{start, i})
+			start = -1
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		blocks = append(blocks, textBlock{start, len(lines)})
+	}
+
+	return blocks
+}
+
+// splitMarkdownChunks packs markdown blocks into chunks of at most maxTokens estimated tokens.
+// A single block larger than maxTokens becomes its own chunk. separators[i] holds the exact
+// text between chunks[i] and chunks[i+1], so joining them reproduces the original content.
+func splitMarkdownChunks(content string, maxTokens int) (chunks, separators []string) {
+	lines := strings.Split(content, "\n")
+	blocks := splitMarkdownBlocks(lines)
+	if len(blocks) == 0 {
+		return []string{content}, nil
+	}
+
+	// Group consecutive blocks into chunks
+	var groups []textBlock
+	current := blocks[0]
+	for _, block := range blocks[1:] {
+		candidate := textBlock{current.start, block.end}
+		if estimateTokens(strings.Join(lines[candidate.start:candidate.end], "\n")) <= maxTokens {
+			current = candidate
+			continue
+		}
+		groups = append(groups, current)
+		current = block
+	}
+	groups = append(groups, current)
+
+	// Leading and trailing blank lines stay attached to the first and last chunk
+	groups[0].start = 0
+	groups[len(groups)-1].end = len(lines)
+
+	for i, group := range groups {
+		chunks = append(chunks, strings.Join(lines[group.start:group.end], "\n"))
+		if i+1 < len(groups) {
+			var sep strings.Builder
+			sep.WriteString("\n")
+			for _, line := range lines[group.end:groups[i+1].start] {
+				sep.WriteString(line + "\n")
+			}
+			separators = append(separators, sep.String())
+		}
+	}
+
+	return chunks, separators
+}
+
+// translateChunked translates content in chunks of at most maxChunkTokens and reassembles them
+func translateChunked(provider LLMProvider, content string, options TranslationOptions, maxRepairs, maxChunkTokens int) (string, error) {
+	chunks, separators := splitMarkdownChunks(content, maxChunkTokens)
+	if len(chunks) == 1 {
+		return translateWithRepair(provider, content, options, maxRepairs)
+	}
+
+	progress("Document split into %d chunks of up to ~%d tokens", len(chunks), maxChunkTokens)
+
+	// The spinner of each chunk's request shows its position and the remaining time
+	options.Progress = &chunkProgress{total: len(chunks)}
+
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		started := time.Now()
+		options.Progress.current = i + 1
+		translated, err := translateWithRepair(provider, chunk, options, maxRepairs)
+		if err != nil {
+			return "", fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		options.Progress.elapsed += time.Since(started)
+
+		sb.WriteString(strings.Trim(translated, "\n"))
+		if i < len(separators) {
+			sb.WriteString(separators[i])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// chunkProgress tracks a chunked translation for the spinner: the chunk being translated and the
+// time spent on the chunks before it
+type chunkProgress struct {
+	current int // 1-based index of the chunk being translated
+	total   int
+	elapsed time.Duration // Time spent on chunks 1 to current-1
+}
+
+// status describes the current chunk, with an ETA from the average time of the finished chunks
+func (p *chunkProgress) status() string {
+	finished := p.current - 1
+	if finished == 0 {
+		return fmt.Sprintf("chunk %d/%d", p.current, p.total)
+	}
+	remaining := p.elapsed / time.Duration(finished) * time.Duration(p.total-finished)
+	return fmt.Sprintf("chunk %d/%d, ETA %s", p.current, p.total, formatDuration(remaining))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitMarkdownChunks(t *testing.T) {
+	paragraph := strings.Repeat("word ", 40) // ~50 tokens
+	code := "```sh\n# comment\n\n" + strings.Repeat("echo line\n", 30) + "```"
+	content := "# Title\n\n" + paragraph + "\n\n\n" + code + "\n## Next\n" + paragraph + "\n"
+
+	chunks, separators := splitMarkdownChunks(content, 60)
+
+	if len(chunks) < 3 {
+		t.Fatalf("Expected the document to be split into several chunks, got %d", len(chunks))
+	}
+	if len(separators) != len(chunks)-1 {
+		t.Fatalf("Expected %d separators, got %d", len(chunks)-1, len(separators))
+	}
+
+	// Joining chunks with their separators reproduces the original content
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		sb.WriteString(chunk)
+		if i < len(separators) {
+			sb.WriteString(separators[i])
+		}
+	}
+	if sb.String() != content {
+		t.Errorf("Reassembled content differs from the original:\n%q\nwant:\n%q", sb.String(), content)
+	}
+
+	// The fenced code block stays whole even though it exceeds the limit
+	found := false
+	for _, chunk := range chunks {
+		opens := strings.Count(chunk, "```")
+		if opens%2 != 0 {
+			t.Errorf("Chunk splits a code block: %q", chunk)
+		}
+		if strings.Contains(chunk, code) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the code block to appear intact in a single chunk")
+	}
+}
+
+func TestSplitMarkdownChunksSmallDocument(t *testing.T) {
+	content := "# Title\n\nShort paragraph."
+	chunks, separators := splitMarkdownChunks(content, 1000)
+	if len(chunks) != 1 || chunks[0] != content || len(separators) != 0 {
+		t.Errorf("splitMarkdownChunks() = %q, %q; want a single unchanged chunk", chunks, separators)
+	}
+}
+
+func TestChunkProgressStatus(t *testing.T) {
+	tests := []struct {
+		progress chunkProgress
+		expected string
+	}{
+		{chunkProgress{current: 1, total: 4}, "chunk 1/4"},
+		{chunkProgress{current: 2, total: 4, elapsed: 30 * time.Second}, "chunk 2/4, ETA 1.5m"},
+		{chunkProgress{current: 4, total: 4, elapsed: 90 * time.Second}, "chunk 4/4, ETA 30.0s"},
+	}
+
+	for _, tt := range tests {
+		if status := tt.progress.status(); status != tt.expected {
+			t.Errorf("status() = %q, want %q", status, tt.expected)
+		}
+	}
+}
+
+func TestDefaultChunkTokens(t *testing.T) {
+	// Large context windows are capped by the output reserve
+	if got := defaultChunkTokens(ProviderTypeOpenAI, "gpt-4o-mini"); got != defaultOutputTokenReserve {
+		t.Errorf("defaultChunkTokens(gpt-4o-mini) = %d, want %d", got, defaultOutputTokenReserve)
+	}
+
+	// Small context windows leave room for the prompt and output
+	model := FindModel(ProviderTypeOpenAI, "gpt-4")
+	if model == nil {
+		t.Skip("gpt-4 not in catalog")
+	}
+	want := min(defaultOutputTokenReserve, model.ContextWindow-defaultOutputTokenReserve-promptOverheadTokens)
+	if got := defaultChunkTokens(ProviderTypeOpenAI, "gpt-4"); got != want {
+		t.Errorf("defaultChunkTokens(gpt-4) = %d, want %d", got, want)
+	}
+
+	// A 4096-token output limit must hold the translation of a whole chunk
+	for _, tt := range []struct{ provider, model string }{
+		{ProviderTypeOpenAI, "gpt-4-turbo"},
+		{ProviderTypeAnthropic, "claude-3-opus-20240229"},
+	} {
+		if model := FindModel(tt.provider, tt.model); model == nil || model.MaxOutputTokens != 4096 {
+			t.Fatalf("%s: expected a catalog model with a 4096-token output limit", tt.model)
+		}
+		got := defaultChunkTokens(tt.provider, tt.model)
+		if want := 4096 * 100 / outputGrowthPercent; got != want {
+			t.Errorf("defaultChunkTokens(%s) = %d, want %d", tt.model, got, want)
+		}
+		if got*outputGrowthPercent/100 > 4096 {
+			t.Errorf("defaultChunkTokens(%s) = %d, whose translation exceeds 4096 output tokens", tt.model, got)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -51,6 +53,11 @@ func (p *ClaudeCodeProvider) GetSupportedLanguages() map[string]string {
 	return supportedLanguages
 }
 
+// BuildPrompt returns the prompt piped to the claude command for content
+func (p *ClaudeCodeProvider) BuildPrompt(content string, options TranslationOptions) string {
+	return p.generatePrompt(options, content)
+}
+
 // Translate translates the given content using Claude Code CLI
 func (p *ClaudeCodeProvider) Translate(ctx context.Context, content string, options TranslationOptions) (*TranslationResponse, error) {
 	if p.config.Verbose {
@@ -61,15 +68,18 @@ func (p *ClaudeCodeProvider) Translate(ctx context.Context, content string, opti
 		}
 	}
 
-	// Generate prompt using existing logic
-	prompt := p.generatePrompt(options.TargetLanguage, options.CustomInstruction, content)
+	prompt := p.BuildPrompt(content, options)
 
 	if p.config.Verbose {
 		log("Generated prompt length: %d characters", len(prompt))
-		// Save prompt to file for debugging
-		if err := os.WriteFile("/tmp/xlat_prompt.txt", []byte(prompt), 0644); err == nil {
-			log("Prompt saved to /tmp/xlat_prompt.txt for debugging")
-		}
+	}
+	if options.DebugDumpDir != "" {
+		dumpDebugFile(options.DebugDumpDir, "prompt", prompt)
+	}
+
+	// Fail fast instead of wasting a round-trip on an oversized prompt
+	if err := checkPromptFits(ProviderTypeClaude, p.config.ClaudeModel, prompt, defaultOutputTokenReserve); err != nil {
+		return nil, err
 	}
 
 	// Execute Claude command
@@ -80,10 +90,9 @@ func (p *ClaudeCodeProvider) Translate(ctx context.Context, content string, opti
 
 	if p.config.Verbose {
 		log("Claude command executed successfully, output length: %d characters", len(result))
-		// Save output to file for debugging
-		if err := os.WriteFile("/tmp/xlat_output.txt", []byte(result), 0644); err == nil {
-			log("Output saved to /tmp/xlat_output.txt for debugging")
-		}
+	}
+	if options.DebugDumpDir != "" {
+		dumpDebugFile(options.DebugDumpDir, "output", result)
 	}
 
 	// For now, return simple success response
@@ -92,14 +101,27 @@ func (p *ClaudeCodeProvider) Translate(ctx context.Context, content string, opti
 		Content: result,
 		Status:  "success",
 		Message: "Translation completed successfully",
+		// The CLI reports no usage, so estimate it from the prompt and output length
+		Usage: &TokenUsage{
+			Provider:     ProviderTypeClaude,
+			Model:        p.config.ClaudeModel,
+			InputTokens:  estimateTokens(prompt),
+			OutputTokens: estimateTokens(result),
+			Estimated:    true,
+		},
 	}
 
 	return response, nil
 }
 
 // generatePrompt generates the translation prompt (migrated from main.go)
-func (p *ClaudeCodeProvider) generatePrompt(targetLang, transformInstruction, content string) string {
-	langName := supportedLanguages[targetLang]
+func (p *ClaudeCodeProvider) generatePrompt(options TranslationOptions, content string) string {
+	if options.Transform {
+		return p.generateTransformPrompt(options, content)
+	}
+
+	targetLang := options.TargetLanguage
+	langName := languageName(targetLang)
 
 	prompt := fmt.Sprintf(`Translate the following document to %s (%s).
 
@@ -112,8 +134,38 @@ IMPORTANT:
 
 If the document is already in %s, return it unchanged.`, langName, targetLang, langName)
 
-	if transformInstruction != "" {
-		prompt += fmt.Sprintf("\n\nAdditional instruction: %s", transformInstruction)
+	if options.CustomInstruction != "" {
+		prompt += fmt.Sprintf("\n\nAdditional instruction: %s", options.CustomInstruction)
+	}
+
+	if glossary := glossaryInstruction(options.Glossary); glossary != "" {
+		prompt += "\n\n" + glossary
+	}
+
+	if placeholders := placeholderInstruction(content); placeholders != "" {
+		prompt += "\n\n" + placeholders
+	}
+
+	prompt += fmt.Sprintf("\n\nDocument:\n%s", content)
+
+	return prompt
+}
+
+// generateTransformPrompt generates the prompt applying the instruction without translating
+func (p *ClaudeCodeProvider) generateTransformPrompt(options TranslationOptions, content string) string {
+	prompt := fmt.Sprintf(`Apply the following instruction to the document, keeping its original language.
+
+Instruction: %s
+
+IMPORTANT:
+1. Preserve the original document format (Markdown, HTML, plain text, etc.) EXACTLY
+2. Maintain ALL syntax, tags, symbols, and structure unless the instruction requires otherwise
+3. Do NOT change code blocks, URLs, or technical identifiers
+4. Do NOT translate the document
+5. Output ONLY the rewritten document - no explanations, prefixes, or additional text`, options.CustomInstruction)
+
+	if placeholders := placeholderInstruction(content); placeholders != "" {
+		prompt += "\n\n" + placeholders
 	}
 
 	prompt += fmt.Sprintf("\n\nDocument:\n%s", content)
@@ -137,11 +189,13 @@ func (p *ClaudeCodeProvider) executeClaude(ctx context.Context, prompt string) (
 		log("Creating claude command: %s -p --model %s", claudePath, modelFlag)
 	}
 
+	// Keep the CLI's diagnostics for the error message; -v also shows them as they are written
+	var stderr bytes.Buffer
 	cmd := exec.CommandContext(ctx, claudePath, "-p", "--model", modelFlag)
 	cmd.Stdin = strings.NewReader(prompt)
-	cmd.Stderr = os.Stderr
-
+	cmd.Stderr = &stderr
 	if p.config.Verbose {
+		cmd.Stderr = io.MultiWriter(&stderr, os.Stderr)
 		log("Starting claude command execution...")
 	}
 
@@ -150,6 +204,9 @@ func (p *ClaudeCodeProvider) executeClaude(ctx context.Context, prompt string) (
 		if p.config.Verbose {
 			log("Claude command failed with error: %v", err)
 		}
+		if message := claudeErrorOutput(stderr.String()); message != "" {
+			return "", fmt.Errorf("claude command execution failed: %w: %s", err, message)
+		}
 		return "", fmt.Errorf("claude command execution failed: %w", err)
 	}
 
@@ -161,3 +218,33 @@ func (p *ClaudeCodeProvider) executeClaude(ctx context.Context, prompt string) (
 
 	return result, nil
 }
+
+// dumpDebugFile writes content to a new file named doc-<kind>-*.txt in dir for --debug-dump.
+// The file is only readable by the user; failures are warnings, not translation errors.
+func dumpDebugFile(dir, kind, content string) {
+	file, err := os.CreateTemp(dir, "doc-"+kind+"-*.txt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save the %s for --debug-dump: %v\n", kind, err)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := file.WriteString(content); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save the %s for --debug-dump: %v\n", kind, err)
+		return
+	}
+	progress("Saved the %s to %s", kind, file.Name())
+}
+
+// maxClaudeErrorOutput caps the stderr quoted in a claude command error
+const maxClaudeErrorOutput = 2000
+
+// claudeErrorOutput returns the trimmed stderr of a failed claude command, keeping its end
+// (where the diagnostic usually is) when it is long
+func claudeErrorOutput(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if len(stderr) > maxClaudeErrorOutput {
+		stderr = "..." + strings.ToValidUTF8(stderr[len(stderr)-maxClaudeErrorOutput:], "")
+	}
+	return stderr
+}
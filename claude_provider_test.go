@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestClaudeCodeProviderErrorIncludesStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the claude command")
+	}
+
+	script := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'Invalid model: sonet' >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &ClaudeCodeProvider{config: ProviderConfig{ClaudeCodePath: script, ClaudeModel: "sonet"}}
+	_, err := provider.executeClaude(context.Background(), "Hello")
+	if err == nil {
+		t.Fatal("Expected an error from the failing command")
+	}
+	if !strings.Contains(err.Error(), "Invalid model: sonet") || !strings.Contains(err.Error(), "exit status 1") {
+		t.Errorf("Expected the exit status and stderr in the error, got %v", err)
+	}
+}
+
+func TestClaudeErrorOutput(t *testing.T) {
+	if got := claudeErrorOutput("  \n"); got != "" {
+		t.Errorf("claudeErrorOutput(blank) = %q, want empty", got)
+	}
+
+	long := strings.Repeat("x", maxClaudeErrorOutput) + "the real error"
+	got := claudeErrorOutput(long)
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "the real error") {
+		t.Errorf("Expected the end of long output to be kept, got %q...", got[:20])
+	}
+}
+
+func TestDumpDebugFile(t *testing.T) {
+	originalQuiet := quiet
+	quiet = true
+	t.Cleanup(func() { quiet = originalQuiet })
+
+	dir := t.TempDir()
+	dumpDebugFile(dir, "prompt", "Translate this")
+	dumpDebugFile(dir, "prompt", "Translate that")
+
+	files, err := filepath.Glob(filepath.Join(dir, "doc-prompt-*.txt"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("Expected two prompt files, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "Translate th") {
+		t.Errorf("Unexpected dump content %q", data)
+	}
+	if info, err := os.Stat(files[0]); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("Dump file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
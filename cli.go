@@ -1,39 +1,122 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/bigdra50/doc/internal/config"
 )
 
+// defaultMaxRepairs is the number of repair attempts made by --auto-repair
+const defaultMaxRepairs = 2
+
 // CLIArgs represents parsed command line arguments
 type CLIArgs struct {
 	Verbose              bool
+	Quiet                bool // Suppress progress output; errors and debug logs still print
 	TargetLanguage       string
 	TransformInstruction string
+	IsTransformCommand   bool // Apply TransformInstruction without translating (doc transform)
+	InputFile            string
+	InputFiles           []string // Files concatenated into one document with --files
+	DiffMode             bool
+	OutputFile           string
+	Force                bool
+	MaxRepairs           int      // Auto-repair attempts after structure validation failures (0 = off)
+	MaxChunkTokens       int      // Chunk size override for large documents (0 = derive from the model)
+	Temperature          *float64 // Sampling temperature override (nil = provider default)
+	MaxTokens            int      // Output token limit override (0 = size to the document)
+	Stream               bool
+	JSON                 bool // Print the result as a JSON object instead of raw text
+	Estimate             bool // Print the input size and estimated cost, then exit without translating
+	PrintPrompt          bool // Print the prompts that would be sent, then exit without translating
+	NoCache              bool
+	NoProtect            bool // Send code and URLs to the provider as-is instead of as placeholders
+	KeepCRLF             bool // Keep CRLF line endings in the input instead of normalizing them to LF
+	GlossaryFile         string
+	DebugDumpDir         string // Save each prompt and raw output to files in this directory
+	Model                string // Model override for the selected provider
+	AutoModel            bool   // Pick the cheapest catalog model whose context window fits the document
+	Provider             string // Provider override for this run
+	SkipIfTranslated     bool
+	ClearCache           bool
+	CompletionShell      string // Shell to print a completion script for
 	ShowList             bool
+	ListJSON             bool // Print --list and --list-models as JSON on stdout
+	ListByName           bool // Sort --list by language name instead of code
+	ListGrouped          bool // Group --list by region
 	ShowListModels       bool
 	ListModelsProvider   string
+	ListModelsSortBy     string
+	ListModelsTier       string
+	ShowListProviders    bool
+	ShowListProfiles     bool
+	Profile              string // Named config profile layered over the base config
+	ConfigFile           string // Config file used instead of the default config.toml
 	ShowConfig           bool
+	ShowVersion          bool
+	ShowHelp             bool
 	SetConfig            []string // Key=value pairs
+	UnsetConfig          []string // Keys to reset to their defaults
 	InitConfig           bool
-	
+
 	// Merge command fields
-	IsMergeCommand       bool
-	MergeDirectory       string
-	MergeOutputFile      string
-	MergeRecursive       bool
-	MergeOrder           string
-	MergeSeparator       string
-	MergeIncludeMeta     bool
-	MergeGenerateTOC     bool
-	MergeTOCDepth        int
-	MergeAdjustHeaders   bool
-	MergeBaseLevel       int
-	MergeIncludePatterns []string
-	MergeExcludePatterns []string
-	MergeDryRun          bool
+	IsMergeCommand        bool
+	MergeDirectories      []string // Input directories, merged in argument order
+	MergeOutputFile       string
+	MergeRecursive        bool
+	MergeOrder            string
+	MergeReverse          bool
+	MergeSeparator        string
+	MergeSeparatorFile    string // File whose content replaces MergeSeparator
+	MergeIncludeMeta      bool
+	MergeGenerateTOC      bool
+	MergeTOCDepth         int
+	MergeAdjustHeaders    bool
+	MergeBaseLevel        int
+	MergeIncludePatterns  []string
+	MergeExcludePatterns  []string
+	MergeExcludeDirs      []string // Subdirectory patterns skipped with -r
+	MergeRespectGitignore bool     // Skip .gitignore'd files without -r, where it is the default
+	MergeNoGitignore      bool     // Include .gitignore'd files in recursive scans
+	MergeKeepCRLF         bool     // Keep CRLF line endings of the merged files
+	MergeStripComments    bool     // Remove HTML comments from the merged files
+	MergeExtensions       []string
+	MergeMaxFileSize      int64 // Skip files larger than this many bytes; 0 means unlimited
+	MergeFromStdin        bool  // Read the paths of the files to merge from stdin instead of scanning
+	MergeDryRun           bool
+	MergeForce            bool // Overwrite an output file that was not written by doc merge without asking
+	MergeSmartTitle       bool
+	MergeTitle            string // Explicit document title, overriding the generated one
+	MergeNoTitle          bool   // Omit the document title H1, e.g. when the first file has its own
+	MergeCheck            bool
+	MergeAppendSources    bool
+	MergeSourcesHeading   string
+	MergeRewriteLinks     bool
+	MergeFrontMatter      string // strip, heading or keep ("" = strip)
+	MergeFileHeadings     bool
+	MergePageBreaks       bool
+	MergeDedupeAnchors    bool
+	MergePageBreakMarker  string // Marker written by --page-breaks ("" = HTML page-break div)
+	MergeTOCOnly          bool   // Write only the table of contents, linking to the source files
+	MergeReadingTime      bool   // Show the word count and reading time under the title
+	MergeFormat           string // Output format: md (default) or html
+	MergeManifest         string // JSON file describing the merged files and their offsets in the output
+	MergeTOCFile          string // Write the TOC to this file instead of inline
+
+	// Translate-dir command fields
+	IsTranslateDirCommand       bool
+	TranslateDirDirectory       string
+	TranslateDirRecursive       bool
+	TranslateDirExcludePatterns []string
+	TranslateDirConcurrency     int    // Files translated in parallel (0 = default)
+	TranslateDirOutDirTemplate  string // Output directory per language, e.g. "i18n/{{.Lang}}" ("" = next to the source)
 }
 
 // parseArgs parses command line arguments and returns CLIArgs
@@ -41,20 +124,44 @@ func parseArgs() (*CLIArgs, error) {
 	args := os.Args[1:]
 	cliArgs := &CLIArgs{
 		// Set merge defaults
-		MergeOrder:        "filename",
-		MergeSeparator:    "\n\n---\n\n",
-		MergeGenerateTOC:  true,
-		MergeTOCDepth:     3,
-		MergeBaseLevel:    2, // Start from H2, H1 reserved for document title
+		MergeOrder:         "filename",
+		MergeSeparator:     "\n\n---\n\n",
+		MergeGenerateTOC:   true,
+		MergeTOCDepth:      3,
+		MergeBaseLevel:     2,    // Start from H2, H1 reserved for document title
 		MergeAdjustHeaders: true, // Default to true for better document structure
 	}
 
-	// Handle verbose flag
-	if len(args) > 0 && args[0] == "-v" {
-		cliArgs.Verbose = true
-		args = args[1:]
-		if verbose {
-			log("Verbose mode enabled")
+	// Handle global flags
+globalFlags:
+	for len(args) > 0 {
+		switch args[0] {
+		case "-v":
+			cliArgs.Verbose = true
+			args = args[1:]
+			if verbose {
+				log("Verbose mode enabled")
+			}
+		case "-q", "--quiet":
+			cliArgs.Quiet = true
+			args = args[1:]
+		case "--profile":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("--profile requires a name")
+			}
+			if err := validateProfileName(args[1]); err != nil {
+				return nil, err
+			}
+			cliArgs.Profile = args[1]
+			args = args[2:]
+		case "--config-file":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("--config-file requires a file path")
+			}
+			cliArgs.ConfigFile = args[1]
+			args = args[2:]
+		default:
+			break globalFlags
 		}
 	}
 
@@ -65,20 +172,80 @@ func parseArgs() (*CLIArgs, error) {
 	// Check if this is a merge command
 	if args[0] == "merge" {
 		cliArgs.IsMergeCommand = true
+		// The [merge] table replaces the built-in defaults; the flags override both
+		selectConfig(cliArgs)
+		applyMergeDefaults(cliArgs, config.Load().Merge)
 		return parseMergeArgs(cliArgs, args[1:])
 	}
 
+	if args[0] == "--help" || args[0] == "-h" {
+		cliArgs.ShowHelp = true
+		return cliArgs, nil
+	}
+
+	if args[0] == "--version" || args[0] == "-V" {
+		cliArgs.ShowVersion = true
+		return cliArgs, nil
+	}
+
+	if args[0] == "cache" {
+		if len(args) != 2 || args[1] != "clear" {
+			return nil, fmt.Errorf("usage: doc cache clear")
+		}
+		cliArgs.ClearCache = true
+		return cliArgs, nil
+	}
+
+	if args[0] == "completion" {
+		if len(args) != 2 || !isValidCompletionShell(args[1]) {
+			return nil, fmt.Errorf("usage: doc completion %s", strings.Join(completionShells, "|"))
+		}
+		cliArgs.CompletionShell = args[1]
+		return cliArgs, nil
+	}
+
+	if args[0] == "translate-dir" {
+		return parseTranslateDirArgs(cliArgs, args[1:])
+	}
+
+	if args[0] == "transform" {
+		cliArgs.IsTransformCommand = true
+		return parseTranslationArgs(cliArgs, args[1:])
+	}
+
 	// Handle --list options
 	if args[0] == "--list" {
 		cliArgs.ShowList = true
+		for _, arg := range args[1:] {
+			switch arg {
+			case "--json":
+				cliArgs.ListJSON = true
+			case "--by-name":
+				cliArgs.ListByName = true
+			case "--group":
+				cliArgs.ListGrouped = true
+			default:
+				return nil, fmt.Errorf("unknown --list option: %s", arg)
+			}
+		}
+		if cliArgs.ListJSON && (cliArgs.ListByName || cliArgs.ListGrouped) {
+			return nil, fmt.Errorf("--by-name and --group cannot be combined with --json")
+		}
 		return cliArgs, nil
 	}
 
 	if args[0] == "--list-models" {
 		cliArgs.ShowListModels = true
-		if len(args) > 1 {
-			cliArgs.ListModelsProvider = args[1]
-		}
+		return parseListModelsArgs(cliArgs, args[1:])
+	}
+
+	if args[0] == "--list-providers" {
+		cliArgs.ShowListProviders = true
+		return cliArgs, nil
+	}
+
+	if args[0] == "--list-profiles" {
+		cliArgs.ShowListProfiles = true
 		return cliArgs, nil
 	}
 
@@ -101,28 +268,457 @@ func parseArgs() (*CLIArgs, error) {
 		return cliArgs, nil
 	}
 
+	if args[0] == "--unset" {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("--unset requires one or more keys")
+		}
+		cliArgs.UnsetConfig = args[1:]
+		return cliArgs, nil
+	}
+
+	return parseTranslationArgs(cliArgs, args)
+}
+
+// parseListModelsArgs parses the optional provider and sorting/filtering flags for --list-models
+func parseListModelsArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			cliArgs.ListModelsProvider = arg
+			continue
+		}
+
+		switch arg {
+		case "--sort-by":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--sort-by requires a value")
+			}
+			i++
+			if !isValidModelSort(args[i]) {
+				return nil, fmt.Errorf("invalid sort '%s'. Valid values: cost, context, tier", args[i])
+			}
+			cliArgs.ListModelsSortBy = args[i]
+		case "--filter":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--filter requires a key=value pair")
+			}
+			i++
+			key, value, ok := strings.Cut(args[i], "=")
+			if !ok || key != "tier" {
+				return nil, fmt.Errorf("invalid filter '%s'. Supported filters: tier=<%s>", args[i], strings.Join(ModelTiers(), "|"))
+			}
+			if !slices.Contains(ModelTiers(), value) {
+				return nil, fmt.Errorf("invalid tier '%s'. Valid tiers: %s", value, strings.Join(ModelTiers(), ", "))
+			}
+			cliArgs.ListModelsTier = value
+		case "--tier":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--tier requires a value")
+			}
+			i++
+			if !slices.Contains(ModelTiers(), args[i]) {
+				return nil, fmt.Errorf("invalid tier '%s'. Valid tiers: %s", args[i], strings.Join(ModelTiers(), ", "))
+			}
+			cliArgs.ListModelsTier = args[i]
+		case "--json":
+			cliArgs.ListJSON = true
+		default:
+			return nil, fmt.Errorf("unknown --list-models option: %s", arg)
+		}
+	}
+
+	return cliArgs, nil
+}
+
+// parseTranslationArgs parses the target language, optional transform instruction and translation flags
+func parseTranslationArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
+	// Parse non-flag arguments
+	nonFlagArgs := []string{}
+	filesMode := false // Positional arguments after the language are input files, not an instruction
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			nonFlagArgs = append(nonFlagArgs, arg)
+			continue
+		}
+
+		// Handle flags
+		switch arg {
+		case "-f", "--file", "--input":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a file path", arg)
+			}
+			i++
+			cliArgs.InputFile = args[i]
+		case "--files":
+			filesMode = true
+		case "-o", "--output":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a file path", arg)
+			}
+			i++
+			cliArgs.OutputFile = args[i]
+		case "--force":
+			cliArgs.Force = true
+		case "--diff":
+			cliArgs.DiffMode = true
+		case "--auto-repair":
+			if cliArgs.MaxRepairs == 0 {
+				cliArgs.MaxRepairs = defaultMaxRepairs
+			}
+		case "--max-repairs":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--max-repairs requires a value")
+			}
+			i++
+			repairs := parseIntOrError(args[i], "--max-repairs")
+			if repairs < 1 {
+				return nil, fmt.Errorf("--max-repairs must be at least 1")
+			}
+			cliArgs.MaxRepairs = repairs
+		case "--max-chunk-tokens":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--max-chunk-tokens requires a value")
+			}
+			i++
+			tokens := parseIntOrError(args[i], "--max-chunk-tokens")
+			if tokens < 1 {
+				return nil, fmt.Errorf("--max-chunk-tokens must be at least 1")
+			}
+			cliArgs.MaxChunkTokens = tokens
+		case "--max-tokens":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--max-tokens requires a value")
+			}
+			i++
+			tokens := parseIntOrError(args[i], "--max-tokens")
+			if tokens < 1 {
+				return nil, fmt.Errorf("--max-tokens must be at least 1")
+			}
+			cliArgs.MaxTokens = tokens
+		case "--temperature":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--temperature requires a value")
+			}
+			i++
+			temperature, err := strconv.ParseFloat(args[i], 64)
+			if err != nil || temperature < 0 || temperature > maxTemperature {
+				return nil, fmt.Errorf("--temperature must be a number from 0.0 to %.1f", maxTemperature)
+			}
+			cliArgs.Temperature = &temperature
+		case "--stream":
+			cliArgs.Stream = true
+		case "--json":
+			cliArgs.JSON = true
+		case "--estimate":
+			cliArgs.Estimate = true
+		case "--print-prompt":
+			cliArgs.PrintPrompt = true
+		case "--no-cache":
+			cliArgs.NoCache = true
+		case "--no-protect":
+			cliArgs.NoProtect = true
+		case "--keep-crlf":
+			cliArgs.KeepCRLF = true
+		case "--glossary":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--glossary requires a file path")
+			}
+			i++
+			cliArgs.GlossaryFile = args[i]
+		case "--skip-if-translated":
+			cliArgs.SkipIfTranslated = true
+		case "--debug-dump":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--debug-dump requires a directory")
+			}
+			i++
+			cliArgs.DebugDumpDir = args[i]
+		case "--auto-model":
+			cliArgs.AutoModel = true
+		case "--model":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--model requires a model ID")
+			}
+			i++
+			cliArgs.Model = args[i]
+		case "--provider":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--provider requires a provider name")
+			}
+			i++
+			if !slices.Contains(providerTypes, args[i]) {
+				return nil, fmt.Errorf("invalid provider '%s'. Must be one of: %s", args[i], strings.Join(providerTypes, ", "))
+			}
+			cliArgs.Provider = args[i]
+		case "-q", "--quiet":
+			cliArgs.Quiet = true
+		case "--profile":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--profile requires a name")
+			}
+			i++
+			if err := validateProfileName(args[i]); err != nil {
+				return nil, err
+			}
+			cliArgs.Profile = args[i]
+		default:
+			return nil, fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	// Streamed text goes straight to stdout, so it cannot be written to a file or reassembled into a patch
+	if cliArgs.Stream && cliArgs.OutputFile != "" {
+		return nil, fmt.Errorf("--stream cannot be combined with --output")
+	}
+	if cliArgs.Stream && cliArgs.DiffMode {
+		return nil, fmt.Errorf("--stream cannot be combined with --diff")
+	}
+	if cliArgs.Stream && cliArgs.JSON {
+		return nil, fmt.Errorf("--stream cannot be combined with --json")
+	}
+	if cliArgs.AutoModel && cliArgs.Model != "" {
+		return nil, fmt.Errorf("--auto-model cannot be combined with --model")
+	}
+	// The prompt preview goes to stdout in place of a translation
+	if cliArgs.PrintPrompt {
+		conflicts := []struct {
+			flag string
+			set  bool
+		}{
+			{"--output", cliArgs.OutputFile != ""}, {"--diff", cliArgs.DiffMode}, {"--stream", cliArgs.Stream},
+			{"--json", cliArgs.JSON}, {"--estimate", cliArgs.Estimate},
+		}
+		for _, conflict := range conflicts {
+			if conflict.set {
+				return nil, fmt.Errorf("--print-prompt cannot be combined with %s", conflict.flag)
+			}
+		}
+	}
+
+	if cliArgs.IsTransformCommand {
+		return parseTransformArgs(cliArgs, nonFlagArgs, filesMode)
+	}
+
 	// Parse target language and optional transform instruction
-	cliArgs.TargetLanguage = args[0]
-	if len(args) > 1 {
-		cliArgs.TransformInstruction = args[1]
+	if len(nonFlagArgs) < 1 {
+		return nil, fmt.Errorf("missing target language")
+	}
+
+	cliArgs.TargetLanguage = nonFlagArgs[0]
+	if filesMode {
+		if len(nonFlagArgs) < 2 {
+			return nil, fmt.Errorf("--files requires at least one input file")
+		}
+		if cliArgs.InputFile != "" {
+			return nil, fmt.Errorf("--files cannot be combined with --file")
+		}
+		if cliArgs.DiffMode {
+			return nil, fmt.Errorf("--files cannot be combined with --diff")
+		}
+		cliArgs.InputFiles = nonFlagArgs[1:]
+	} else if len(nonFlagArgs) > 1 {
+		cliArgs.TransformInstruction = nonFlagArgs[1]
+	}
+
+	return cliArgs, nil
+}
+
+// parseTransformArgs parses the positional arguments of the transform command: the instruction,
+// followed by the input files with --files
+func parseTransformArgs(cliArgs *CLIArgs, nonFlagArgs []string, filesMode bool) (*CLIArgs, error) {
+	if len(nonFlagArgs) < 1 || strings.TrimSpace(nonFlagArgs[0]) == "" {
+		return nil, fmt.Errorf("missing transform instruction")
+	}
+	cliArgs.TransformInstruction = nonFlagArgs[0]
+
+	// There is no target language to detect or to translate terms into
+	if cliArgs.SkipIfTranslated {
+		return nil, fmt.Errorf("--skip-if-translated cannot be used with transform")
+	}
+	if cliArgs.GlossaryFile != "" {
+		return nil, fmt.Errorf("--glossary cannot be used with transform")
+	}
+
+	if filesMode {
+		if len(nonFlagArgs) < 2 {
+			return nil, fmt.Errorf("--files requires at least one input file")
+		}
+		if cliArgs.InputFile != "" {
+			return nil, fmt.Errorf("--files cannot be combined with --file")
+		}
+		if cliArgs.DiffMode {
+			return nil, fmt.Errorf("--files cannot be combined with --diff")
+		}
+		cliArgs.InputFiles = nonFlagArgs[1:]
+	} else if len(nonFlagArgs) > 1 {
+		return nil, fmt.Errorf("transform takes a single instruction; quote it, e.g. doc transform \"make it formal\"")
+	}
+
+	return cliArgs, nil
+}
+
+// parseTranslateDirArgs parses arguments for the translate-dir command
+func parseTranslateDirArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
+	cliArgs.IsTranslateDirCommand = true
+
+	nonFlagArgs := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			nonFlagArgs = append(nonFlagArgs, arg)
+			continue
+		}
+
+		switch arg {
+		case "-r", "--recursive":
+			cliArgs.TranslateDirRecursive = true
+		case "--exclude":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--exclude requires a pattern")
+			}
+			i++
+			cliArgs.TranslateDirExcludePatterns = append(cliArgs.TranslateDirExcludePatterns, args[i])
+		case "--concurrency":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--concurrency requires a value")
+			}
+			i++
+			concurrency := parseIntOrError(args[i], "--concurrency")
+			if concurrency < 1 {
+				return nil, fmt.Errorf("--concurrency must be at least 1")
+			}
+			cliArgs.TranslateDirConcurrency = concurrency
+		case "--force":
+			cliArgs.Force = true
+		case "--model":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--model requires a model ID")
+			}
+			i++
+			cliArgs.Model = args[i]
+		case "--provider":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--provider requires a provider name")
+			}
+			i++
+			if !slices.Contains(providerTypes, args[i]) {
+				return nil, fmt.Errorf("invalid provider '%s'. Must be one of: %s", args[i], strings.Join(providerTypes, ", "))
+			}
+			cliArgs.Provider = args[i]
+		case "-q", "--quiet":
+			cliArgs.Quiet = true
+		case "--profile":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--profile requires a name")
+			}
+			i++
+			if err := validateProfileName(args[i]); err != nil {
+				return nil, err
+			}
+			cliArgs.Profile = args[i]
+		case "--no-cache":
+			cliArgs.NoCache = true
+		case "--no-protect":
+			cliArgs.NoProtect = true
+		case "--glossary":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--glossary requires a file path")
+			}
+			i++
+			cliArgs.GlossaryFile = args[i]
+		case "--out-dir-template":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--out-dir-template requires a template such as \"i18n/{{.Lang}}\"")
+			}
+			i++
+			if _, err := parseOutDirTemplate(args[i]); err != nil {
+				return nil, err
+			}
+			cliArgs.TranslateDirOutDirTemplate = args[i]
+		default:
+			return nil, fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+
+	if len(nonFlagArgs) < 2 {
+		return nil, fmt.Errorf("translate-dir command requires a directory and a target language")
+	}
+
+	cliArgs.TranslateDirDirectory = nonFlagArgs[0]
+	cliArgs.TargetLanguage = nonFlagArgs[1]
+	if len(nonFlagArgs) > 2 {
+		cliArgs.TransformInstruction = nonFlagArgs[2]
 	}
 
 	return cliArgs, nil
 }
 
+// selectConfig points the config package at the config file and profile chosen on the command line
+func selectConfig(cliArgs *CLIArgs) {
+	if cliArgs.ConfigFile != "" {
+		config.SetConfigPath(cliArgs.ConfigFile)
+	}
+	if cliArgs.Profile != "" {
+		config.SetProfile(cliArgs.Profile)
+	}
+}
+
+// applyMergeDefaults sets the merge options configured in the [merge] table. Invalid values are
+// reported and leave the built-in default in place.
+func applyMergeDefaults(cliArgs *CLIArgs, defaults config.MergeDefaults) {
+	if defaults.Recursive != nil {
+		cliArgs.MergeRecursive = *defaults.Recursive
+	}
+	if defaults.Order != "" {
+		if isValidOrder(defaults.Order) {
+			cliArgs.MergeOrder = defaults.Order
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: invalid merge order %q in config; using %s\n", defaults.Order, cliArgs.MergeOrder)
+		}
+	}
+	if defaults.Separator != nil {
+		cliArgs.MergeSeparator = *defaults.Separator
+	}
+	if defaults.TOC != nil {
+		cliArgs.MergeGenerateTOC = *defaults.TOC
+	}
+	if defaults.TOCDepth != 0 {
+		if defaults.TOCDepth >= 1 && defaults.TOCDepth <= 6 {
+			cliArgs.MergeTOCDepth = defaults.TOCDepth
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: merge toc_depth must be between 1 and 6, got %d; using %d\n", defaults.TOCDepth, cliArgs.MergeTOCDepth)
+		}
+	}
+	if defaults.BaseLevel != 0 {
+		if defaults.BaseLevel >= 1 && defaults.BaseLevel <= 6 {
+			cliArgs.MergeBaseLevel = defaults.BaseLevel
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: merge base_level must be between 1 and 6, got %d; using %d\n", defaults.BaseLevel, cliArgs.MergeBaseLevel)
+		}
+	}
+	if defaults.AdjustHeaders != nil {
+		cliArgs.MergeAdjustHeaders = *defaults.AdjustHeaders
+	}
+}
+
 // parseMergeArgs parses arguments for the merge command
 func parseMergeArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
 	cliArgs.IsMergeCommand = true
-	
+
 	if len(args) < 1 {
 		return nil, fmt.Errorf("merge command requires a directory argument")
 	}
 
 	// Parse non-flag arguments
 	nonFlagArgs := []string{}
+	separatorGiven := false
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
-		
+
 		if !strings.HasPrefix(arg, "-") {
 			nonFlagArgs = append(nonFlagArgs, arg)
 			continue
@@ -134,12 +730,98 @@ func parseMergeArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
 			cliArgs.MergeRecursive = true
 		case "--dry-run":
 			cliArgs.MergeDryRun = true
+		case "--force":
+			cliArgs.MergeForce = true
+		case "-q", "--quiet":
+			cliArgs.Quiet = true
+		case "--check":
+			cliArgs.MergeCheck = true
+		case "--toc-only":
+			cliArgs.MergeTOCOnly = true
+		case "--append-sources":
+			cliArgs.MergeAppendSources = true
+		case "--sources-heading":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--sources-heading requires a value")
+			}
+			i++
+			cliArgs.MergeAppendSources = true
+			cliArgs.MergeSourcesHeading = args[i]
 		case "--include-meta":
 			cliArgs.MergeIncludeMeta = true
+		case "--reading-time":
+			cliArgs.MergeReadingTime = true
 		case "--no-toc":
 			cliArgs.MergeGenerateTOC = false
 		case "--adjust-headers":
 			cliArgs.MergeAdjustHeaders = true
+		case "--smart-title":
+			cliArgs.MergeSmartTitle = true
+		case "--title":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--title requires a value")
+			}
+			i++
+			if strings.TrimSpace(args[i]) == "" {
+				return nil, fmt.Errorf("--title must not be empty")
+			}
+			cliArgs.MergeTitle = strings.TrimSpace(args[i])
+		case "--no-title":
+			cliArgs.MergeNoTitle = true
+		case "--from-stdin":
+			cliArgs.MergeFromStdin = true
+		case "--max-file-size":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--max-file-size requires a value")
+			}
+			i++
+			size, err := parseFileSize(args[i])
+			if err != nil {
+				return nil, err
+			}
+			if size <= 0 {
+				return nil, fmt.Errorf("--max-file-size must be greater than 0")
+			}
+			cliArgs.MergeMaxFileSize = size
+		case "--manifest":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--manifest requires a file path")
+			}
+			i++
+			cliArgs.MergeManifest = args[i]
+		case "--format":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--format requires a value")
+			}
+			i++
+			if !slices.Contains(mergeFormats, args[i]) {
+				return nil, fmt.Errorf("invalid format '%s'. Valid formats: %s", args[i], strings.Join(mergeFormats, ", "))
+			}
+			cliArgs.MergeFormat = args[i]
+		case "--rewrite-links":
+			cliArgs.MergeRewriteLinks = true
+		case "--file-headings":
+			cliArgs.MergeFileHeadings = true
+		case "--dedupe-anchors":
+			cliArgs.MergeDedupeAnchors = true
+		case "--page-breaks":
+			cliArgs.MergePageBreaks = true
+		case "--page-break-marker":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--page-break-marker requires a value")
+			}
+			i++
+			cliArgs.MergePageBreaks = true
+			cliArgs.MergePageBreakMarker = args[i]
+		case "--front-matter":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--front-matter requires a value")
+			}
+			i++
+			if !isValidFrontMatterMode(args[i]) {
+				return nil, fmt.Errorf("invalid front matter mode '%s'. Valid modes: strip, heading, keep", args[i])
+			}
+			cliArgs.MergeFrontMatter = args[i]
 		case "-o", "--output":
 			if i+1 >= len(args) {
 				return nil, fmt.Errorf("%s requires a value", arg)
@@ -152,15 +834,30 @@ func parseMergeArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
 			}
 			i++
 			if !isValidOrder(args[i]) {
-				return nil, fmt.Errorf("invalid order '%s'. Valid orders: filename, modified, size, custom", args[i])
+				return nil, fmt.Errorf("invalid order '%s'. Valid orders: filename, numeric, modified, size, custom", args[i])
 			}
 			cliArgs.MergeOrder = args[i]
+		case "--reverse":
+			cliArgs.MergeReverse = true
 		case "--separator":
 			if i+1 >= len(args) {
 				return nil, fmt.Errorf("--separator requires a value")
 			}
 			i++
 			cliArgs.MergeSeparator = args[i]
+			separatorGiven = true
+		case "--separator-file":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--separator-file requires a file path")
+			}
+			i++
+			cliArgs.MergeSeparatorFile = args[i]
+		case "--toc-file":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--toc-file requires a value")
+			}
+			i++
+			cliArgs.MergeTOCFile = args[i]
 		case "--toc-depth":
 			if i+1 >= len(args) {
 				return nil, fmt.Errorf("--toc-depth requires a value")
@@ -193,22 +890,97 @@ func parseMergeArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
 			}
 			i++
 			cliArgs.MergeExcludePatterns = append(cliArgs.MergeExcludePatterns, args[i])
+		case "--respect-gitignore":
+			cliArgs.MergeRespectGitignore = true
+		case "--no-gitignore":
+			cliArgs.MergeNoGitignore = true
+		case "--keep-crlf":
+			cliArgs.MergeKeepCRLF = true
+		case "--strip-comments":
+			cliArgs.MergeStripComments = true
+		case "--exclude-dir":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--exclude-dir requires a pattern")
+			}
+			i++
+			cliArgs.MergeExcludeDirs = append(cliArgs.MergeExcludeDirs, args[i])
+		case "--ext":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--ext requires a comma-separated list of extensions")
+			}
+			i++
+			for _, ext := range strings.Split(args[i], ",") {
+				ext = strings.TrimSpace(ext)
+				if ext == "" {
+					continue
+				}
+				if !strings.HasPrefix(ext, ".") {
+					ext = "." + ext
+				}
+				cliArgs.MergeExtensions = append(cliArgs.MergeExtensions, ext)
+			}
+			if len(cliArgs.MergeExtensions) == 0 {
+				return nil, fmt.Errorf("--ext requires at least one extension")
+			}
 		default:
 			return nil, fmt.Errorf("unknown merge option: %s", arg)
 		}
 	}
 
 	// Assign non-flag arguments
-	if len(nonFlagArgs) < 1 {
+	if cliArgs.MergeFromStdin {
+		// The files come from stdin, so the only argument can be the output file
+		if len(nonFlagArgs) > 1 || (len(nonFlagArgs) == 1 && cliArgs.MergeOutputFile != "") {
+			return nil, fmt.Errorf("--from-stdin reads the files from stdin and takes no directory arguments")
+		}
+		if len(nonFlagArgs) == 1 {
+			cliArgs.MergeOutputFile = nonFlagArgs[0]
+		}
+	} else if len(nonFlagArgs) < 1 {
 		return nil, fmt.Errorf("merge command requires a directory argument")
+	} else {
+		// Every argument is an input directory except a trailing output file. Without -o, the last
+		// argument is the output file unless it is an existing directory.
+		directories := nonFlagArgs
+		if cliArgs.MergeOutputFile == "" && len(nonFlagArgs) > 1 {
+			last := nonFlagArgs[len(nonFlagArgs)-1]
+			if info, err := os.Stat(last); err != nil || !info.IsDir() {
+				cliArgs.MergeOutputFile = last
+				directories = nonFlagArgs[:len(nonFlagArgs)-1]
+			}
+		}
+		cliArgs.MergeDirectories = directories
+	}
+
+	if separatorGiven && cliArgs.MergeSeparatorFile != "" {
+		return nil, fmt.Errorf("--separator and --separator-file cannot be used together")
+	}
+
+	if cliArgs.MergeNoTitle && (cliArgs.MergeTitle != "" || cliArgs.MergeSmartTitle) {
+		return nil, fmt.Errorf("--no-title cannot be combined with --title or --smart-title")
+	}
+
+	if cliArgs.MergeFormat == "html" && (cliArgs.MergeTOCOnly || cliArgs.MergeTOCFile != "") {
+		return nil, fmt.Errorf("--format html cannot be combined with --toc-only or --toc-file")
+	}
+
+	if cliArgs.MergeManifest != "" && (cliArgs.MergeFormat == "html" || cliArgs.MergeTOCOnly) {
+		return nil, fmt.Errorf("--manifest cannot be combined with --format html or --toc-only")
+	}
+
+	if cliArgs.MergeTOCOnly {
+		if cliArgs.MergeCheck || cliArgs.MergeTOCFile != "" {
+			return nil, fmt.Errorf("--toc-only cannot be combined with --check or --toc-file")
+		}
+		// The table of contents goes to stdout unless an output file is given
+		return cliArgs, nil
 	}
-	
-	cliArgs.MergeDirectory = nonFlagArgs[0]
-	
-	if len(nonFlagArgs) > 1 {
-		cliArgs.MergeOutputFile = nonFlagArgs[1]
-	} else if cliArgs.MergeOutputFile == "" {
+
+	if cliArgs.MergeOutputFile == "" {
 		cliArgs.MergeOutputFile = "merged.md"
+		if cliArgs.MergeFormat == "html" {
+			cliArgs.MergeOutputFile = "merged.html"
+		}
 	}
 
 	return cliArgs, nil
@@ -216,7 +988,7 @@ func parseMergeArgs(cliArgs *CLIArgs, args []string) (*CLIArgs, error) {
 
 // isValidOrder checks if the order type is valid
 func isValidOrder(order string) bool {
-	validOrders := []string{"filename", "modified", "size", "custom"}
+	validOrders := []string{"filename", "numeric", "modified", "size", "custom"}
 	for _, valid := range validOrders {
 		if order == valid {
 			return true
@@ -225,59 +997,205 @@ func isValidOrder(order string) bool {
 	return false
 }
 
+// isValidFrontMatterMode checks if the --front-matter mode is valid
+func isValidFrontMatterMode(mode string) bool {
+	switch mode {
+	case "strip", "heading", "keep":
+		return true
+	}
+	return false
+}
+
+// isValidModelSort checks if the model sort key is valid
+func isValidModelSort(sortBy string) bool {
+	switch sortBy {
+	case "cost", "context", "tier":
+		return true
+	}
+	return false
+}
+
+// validateProfileName checks that a profile name can be used in a config file name
+func validateProfileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name must not be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+		}
+	}
+	return nil
+}
+
 // parseIntOrError parses an integer or returns an error
 func parseIntOrError(s, flag string) int {
 	if val, err := strconv.Atoi(s); err == nil {
 		return val
 	}
 	fmt.Fprintf(os.Stderr, "Error: %s requires a valid integer\n", flag)
-	os.Exit(1)
+	os.Exit(exitUsage)
 	return 0
 }
 
-// showUsage displays the usage information
+// showUsage displays the usage information on stderr after an argument error
 func showUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: \n")
-	fmt.Fprintf(os.Stderr, "  doc [-v] <language_code> [transform_instruction]  # Translation\n")
-	fmt.Fprintf(os.Stderr, "  doc [-v] merge <directory> [output_file] [options] # Merge markdown files\n")
-	fmt.Fprintf(os.Stderr, "\nTranslation Examples:\n")
-	fmt.Fprintf(os.Stderr, "  cat README.md | doc ja\n")
-	fmt.Fprintf(os.Stderr, "  cat README.md | doc -v ru\n")
-	fmt.Fprintf(os.Stderr, "\nMerge Examples:\n")
-	fmt.Fprintf(os.Stderr, "  doc merge ./docs/                    # Merge all .md files to merged.md\n")
-	fmt.Fprintf(os.Stderr, "  doc merge ./docs/ book.md            # Merge to book.md\n")
-	fmt.Fprintf(os.Stderr, "  doc merge ./docs/ -r --include-meta  # Recursive with metadata\n")
-	fmt.Fprintf(os.Stderr, "  doc merge ./docs/ --dry-run          # Preview without merging\n")
-	fmt.Fprintf(os.Stderr, "\nMerge Options:\n")
-	fmt.Fprintf(os.Stderr, "  -o, --output FILE         Output file (default: merged.md)\n")
-	fmt.Fprintf(os.Stderr, "  -r, --recursive           Include subdirectories\n")
-	fmt.Fprintf(os.Stderr, "  --order ORDER             Sort order: filename, modified, size, custom (default: filename)\n")
-	fmt.Fprintf(os.Stderr, "  --separator STRING        File separator (default: \\n\\n---\\n\\n)\n")
-	fmt.Fprintf(os.Stderr, "  --include PATTERN         Include files matching pattern\n")
-	fmt.Fprintf(os.Stderr, "  --exclude PATTERN         Exclude files matching pattern\n")
-	fmt.Fprintf(os.Stderr, "  --include-meta            Include metadata comments\n")
-	fmt.Fprintf(os.Stderr, "  --no-toc                  Disable table of contents\n")
-	fmt.Fprintf(os.Stderr, "  --toc-depth N             TOC depth (1-6, default: 3)\n")
-	fmt.Fprintf(os.Stderr, "  --adjust-headers          Adjust header levels\n")
-	fmt.Fprintf(os.Stderr, "  --base-level N            Base header level (1-6, default: 1)\n")
-	fmt.Fprintf(os.Stderr, "  --dry-run                 Preview without writing\n")
-	fmt.Fprintf(os.Stderr, "\nGeneral Commands:\n")
-	fmt.Fprintf(os.Stderr, "  doc --list          # Show supported language codes\n")
-	fmt.Fprintf(os.Stderr, "  doc --list-models   # Show all available models\n")
-	fmt.Fprintf(os.Stderr, "  doc --list-models openai # Show OpenAI models only\n")
-	fmt.Fprintf(os.Stderr, "\nConfiguration Commands:\n")
-	fmt.Fprintf(os.Stderr, "  doc --config        # Show current configuration\n")
-	fmt.Fprintf(os.Stderr, "  doc --init-config   # Create default config file\n")
-	fmt.Fprintf(os.Stderr, "  doc --set provider=openai # Set configuration value\n")
-	fmt.Fprintf(os.Stderr, "  doc --set openai_api_key=sk-... # Set API key\n")
-	fmt.Fprintf(os.Stderr, "\nEnvironment Variables (override config file):\n")
-	fmt.Fprintf(os.Stderr, "  LLM_PROVIDER      - Provider type: claude-code, openai, anthropic (default: claude-code)\n")
-	fmt.Fprintf(os.Stderr, "  OPENAI_API_KEY    - OpenAI API key (required for openai provider)\n")
-	fmt.Fprintf(os.Stderr, "  ANTHROPIC_API_KEY - Anthropic API key (required for anthropic provider)\n")
-	fmt.Fprintf(os.Stderr, "  OPENAI_MODEL      - OpenAI model to use (default: gpt-4o-mini)\n")
-	fmt.Fprintf(os.Stderr, "  ANTHROPIC_MODEL   - Anthropic model to use (default: claude-3-5-haiku-20241022)\n")
-	fmt.Fprintf(os.Stderr, "  CLAUDE_MODEL      - Claude Code model to use (default: sonnet)\n")
-	fmt.Fprintf(os.Stderr, "\nConfig File: $XDG_CONFIG_HOME/bigdra50/doc/config.toml (or ~/.config/bigdra50/doc/config.toml)\n")
+	printUsage(os.Stderr)
+}
+
+// printUsage writes the usage information to w
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: \n")
+	fmt.Fprintf(w, "  doc [-v] [-q] [--profile NAME] <language_code> [transform_instruction] [options] # Translation\n")
+	fmt.Fprintf(w, "  doc [-v] [-q] [--profile NAME] transform <instruction> [options] # Rewrite without translating\n")
+	fmt.Fprintf(w, "  doc [-v] [-q] merge <directory>... [output_file] [options] # Merge markdown files\n")
+	fmt.Fprintf(w, "  doc [-v] [-q] [--profile NAME] translate-dir <directory> <language_code> [transform_instruction] [options] # Translate each file\n")
+	fmt.Fprintf(w, "\nGlobal Options:\n")
+	fmt.Fprintf(w, "  -v                        Print debug logs\n")
+	fmt.Fprintf(w, "  -q, --quiet               Hide progress and spinners (errors, warnings and -v debug logs still print)\n")
+	fmt.Fprintf(w, "  --config-file PATH        Use the config file at PATH instead of the default config.toml\n")
+	fmt.Fprintf(w, "\nTranslation Examples:\n")
+	fmt.Fprintf(w, "  cat README.md | doc ja\n")
+	fmt.Fprintf(w, "  cat README.md | doc -v ru\n")
+	fmt.Fprintf(w, "  doc ja -f README.md\n")
+	fmt.Fprintf(w, "  doc ja -f README.md -o README.ja.md\n")
+	fmt.Fprintf(w, "  doc ja --files intro.md usage.md -o guide.ja.md\n")
+	fmt.Fprintf(w, "\nTranslation Options:\n")
+	fmt.Fprintf(w, "  -f, --file FILE           Read the document from FILE (takes precedence over stdin)\n")
+	fmt.Fprintf(w, "  --input FILE              Alias for --file\n")
+	fmt.Fprintf(w, "  --files                   Treat arguments after LANG as files to join and translate as one document\n")
+	fmt.Fprintf(w, "  -o, --output FILE         Write the translation to FILE instead of stdout\n")
+	fmt.Fprintf(w, "  --force                   Overwrite the output file if it already exists\n")
+	fmt.Fprintf(w, "  --diff                    Input is a unified diff; translate only added lines\n")
+	fmt.Fprintf(w, "  --auto-repair             Retry with a stricter prompt if code fences, links or lists break\n")
+	fmt.Fprintf(w, "  --max-repairs N           Maximum repair attempts (implies --auto-repair, default: 2)\n")
+	fmt.Fprintf(w, "  --max-chunk-tokens N      Split large documents into chunks of ~N tokens (default: fit the model)\n")
+	fmt.Fprintf(w, "  --max-tokens N            Output token limit per request (default: sized to the document and model)\n")
+	fmt.Fprintf(w, "  --temperature T           Sampling temperature from 0.0 to 2.0 (default: 0.1; anthropic allows up to 1.0)\n")
+	fmt.Fprintf(w, "  --stream                  Print the translation to stdout as it arrives (openai provider)\n")
+	fmt.Fprintf(w, "  --json                    Print a JSON object with the content, status, provider, model and duration\n")
+	fmt.Fprintf(w, "  --estimate                Print the input size, token count and estimated cost without translating\n")
+	fmt.Fprintf(w, "  --print-prompt            Print the prompt(s) that would be sent to the provider without translating\n")
+	fmt.Fprintf(w, "  --no-cache                Always call the provider instead of reusing cached translations\n")
+	fmt.Fprintf(w, "  --no-protect              Send code and URLs to the model instead of replacing them with placeholders\n")
+	fmt.Fprintf(w, "  --keep-crlf               Keep CRLF line endings in the input (default: normalize to LF)\n")
+	fmt.Fprintf(w, "  --glossary FILE           Enforce term translations (source<TAB>target per line, empty target = keep)\n")
+	fmt.Fprintf(w, "  --skip-if-translated      Output the document unchanged if it is already in the target language\n")
+	fmt.Fprintf(w, "  --debug-dump DIR          Save each prompt and raw model output to a new file in DIR (claude-code)\n")
+	fmt.Fprintf(w, "  --provider NAME           Use provider NAME for this run: %s\n", strings.Join(providerTypes, ", "))
+	fmt.Fprintf(w, "  --model MODEL             Use MODEL instead of the configured model (see doc --list-models)\n")
+	fmt.Fprintf(w, "  --auto-model              Use the cheapest model whose context window fits the document (openai, anthropic)\n")
+	fmt.Fprintf(w, "  --profile NAME            Use config.NAME.toml over the base config\n")
+	fmt.Fprintf(w, "\nTransform Examples (translation options apply, except --glossary and --skip-if-translated):\n")
+	fmt.Fprintf(w, "  doc transform \"convert to a formal tone\" -f notes.md\n")
+	fmt.Fprintf(w, "  cat spec.md | doc transform \"summarize in five bullet points\"\n")
+	fmt.Fprintf(w, "\nTranslate-dir Examples:\n")
+	fmt.Fprintf(w, "  doc translate-dir ./docs ja            # Write guide.ja.md next to guide.md\n")
+	fmt.Fprintf(w, "  doc translate-dir ./docs ja -r --concurrency 4\n")
+	fmt.Fprintf(w, "  doc translate-dir ./docs ja --out-dir-template \"i18n/{{.Lang}}\" # Write i18n/ja/guide.md\n")
+	fmt.Fprintf(w, "\nTranslate-dir Options:\n")
+	fmt.Fprintf(w, "  -r, --recursive           Include subdirectories\n")
+	fmt.Fprintf(w, "  --exclude PATTERN         Exclude files matching pattern\n")
+	fmt.Fprintf(w, "  --concurrency N           Translate N files in parallel (default: 1)\n")
+	fmt.Fprintf(w, "  --force                   Overwrite existing translated files\n")
+	fmt.Fprintf(w, "  --out-dir-template TMPL   Write translations under the directory TMPL renders ({{.Lang}} = language code)\n")
+	fmt.Fprintf(w, "  --no-cache                Always call the provider instead of reusing cached translations\n")
+	fmt.Fprintf(w, "  --no-protect              Send code and URLs to the model instead of replacing them with placeholders\n")
+	fmt.Fprintf(w, "  --glossary FILE           Enforce term translations (see Translation Options)\n")
+	fmt.Fprintf(w, "  --provider NAME           Use provider NAME for this run\n")
+	fmt.Fprintf(w, "  --model MODEL             Use MODEL instead of the configured model\n")
+	fmt.Fprintf(w, "\nMerge Examples (output starts with a <!-- Generated by doc merge --> line; reruns skip and overwrite such files):\n")
+	fmt.Fprintf(w, "  doc merge ./docs/                    # Merge all .md files to merged.md\n")
+	fmt.Fprintf(w, "  doc merge ./docs/ book.md            # Merge to book.md\n")
+	fmt.Fprintf(w, "  doc merge ./docs/ -r --include-meta  # Recursive with metadata\n")
+	fmt.Fprintf(w, "  doc merge ./docs/ --dry-run          # Preview without merging\n")
+	fmt.Fprintf(w, "  doc merge ./guide ./reference book.md # Merge several directories in order\n")
+	fmt.Fprintf(w, "\nMerge Options:\n")
+	fmt.Fprintf(w, "  -o, --output FILE         Output file (default: merged.md)\n")
+	fmt.Fprintf(w, "  -r, --recursive           Include subdirectories\n")
+	fmt.Fprintf(w, "  --order ORDER             Sort order: filename, numeric, modified, size, custom (default: filename)\n")
+	fmt.Fprintf(w, "  --reverse                 Reverse the sort order (ignored with --order custom)\n")
+	fmt.Fprintf(w, "  --separator STRING        File separator (default: \\n\\n---\\n\\n)\n")
+	fmt.Fprintf(w, "  --separator-file FILE     Read the file separator from FILE, e.g. a multi-line divider\n")
+	fmt.Fprintf(w, "  --page-breaks             Start each file on a new page (replaces the separator)\n")
+	fmt.Fprintf(w, "  --page-break-marker TEXT  Page-break marker, e.g. \\newpage (implies --page-breaks)\n")
+	fmt.Fprintf(w, "  --include PATTERN         Include files matching pattern\n")
+	fmt.Fprintf(w, "  --exclude PATTERN         Exclude files matching pattern\n")
+	fmt.Fprintf(w, "  --exclude-dir PATTERN     Skip subdirectories matching pattern (name or path, e.g. drafts)\n")
+	fmt.Fprintf(w, "  --respect-gitignore       Skip files ignored by .gitignore (default with -r)\n")
+	fmt.Fprintf(w, "  --no-gitignore            Include files ignored by .gitignore\n")
+	fmt.Fprintf(w, "  --ext LIST                Markdown extensions (default: .md,.markdown,.mdown,.mkd)\n")
+	fmt.Fprintf(w, "  --keep-crlf               Keep CRLF line endings of the merged files (default: normalize to LF)\n")
+	fmt.Fprintf(w, "  --from-stdin              Read the file paths to merge from stdin, one per line\n")
+	fmt.Fprintf(w, "  --max-file-size SIZE      Skip files larger than SIZE, e.g. 5MB (default: unlimited)\n")
+	fmt.Fprintf(w, "  --include-meta            Include metadata comments (with word count and reading time)\n")
+	fmt.Fprintf(w, "  --strip-comments          Remove HTML comments from the files (not in code; --include-meta's are kept)\n")
+	fmt.Fprintf(w, "  --format <md|html>        Output format; html renders a standalone page (default: md)\n")
+	fmt.Fprintf(w, "  --manifest <file>         Write a JSON manifest of the merged files and their output offsets\n")
+	fmt.Fprintf(w, "  --reading-time            Show the word count and reading time under the title\n")
+	fmt.Fprintf(w, "  --append-sources          Append a visible section listing merged files\n")
+	fmt.Fprintf(w, "  --sources-heading TEXT    Heading for the sources section (default: Sources)\n")
+	fmt.Fprintf(w, "  --no-toc                  Disable table of contents\n")
+	fmt.Fprintf(w, "  --toc-depth N             TOC depth (1-6, default: 3)\n")
+	fmt.Fprintf(w, "  --toc-file FILE           Write the TOC to FILE instead of inline\n")
+	fmt.Fprintf(w, "  --toc-only                Write only a TOC linking to the source files (to stdout without an output file)\n")
+	fmt.Fprintf(w, "  --adjust-headers          Adjust header levels\n")
+	fmt.Fprintf(w, "  --base-level N            Base header level (1-6, default: 1)\n")
+	fmt.Fprintf(w, "  --title <text>            Document title (default: derived from the output filename)\n")
+	fmt.Fprintf(w, "  --no-title                Omit the document title, keeping the files' own H1s\n")
+	fmt.Fprintf(w, "  --smart-title             Use the first file's H1 as the title if present\n")
+	fmt.Fprintf(w, "  --file-headings           Start each file with a heading (its leading H1 or its filename)\n")
+	fmt.Fprintf(w, "  --dedupe-anchors          Suffix headings that repeat an earlier file's heading with the file name\n")
+	fmt.Fprintf(w, "  --rewrite-links           Rewrite relative links and images to resolve from the output file\n")
+	fmt.Fprintf(w, "  --front-matter MODE       YAML front matter: strip, heading (use its title), keep (default: strip)\n")
+	fmt.Fprintf(w, "  --dry-run                 Preview without writing\n")
+	fmt.Fprintf(w, "  --check                   Exit non-zero with a diff if the output is out of date\n")
+	fmt.Fprintf(w, "  --force                   Overwrite an existing output file not written by doc merge without asking\n")
+	fmt.Fprintf(w, "\nGeneral Commands:\n")
+	fmt.Fprintf(w, "  doc --list          # Show supported language codes\n")
+	fmt.Fprintf(w, "  doc --list --json   # Print language codes and names as JSON (also for --list-models)\n")
+	fmt.Fprintf(w, "  doc --list --by-name --group # Sort languages by name, grouped by region\n")
+	fmt.Fprintf(w, "  doc --list-models   # Show all available models\n")
+	fmt.Fprintf(w, "  doc --list-models openai # Show OpenAI models only\n")
+	fmt.Fprintf(w, "  doc --list-models --sort-by cost --filter tier=economy # Cheapest economy models\n")
+	fmt.Fprintf(w, "  doc --list-models openai --tier economy # Economy-tier OpenAI models only\n")
+	fmt.Fprintf(w, "  doc --list-providers # Show providers usable on this machine\n")
+	fmt.Fprintf(w, "  doc --version       # Show version, commit and build date\n")
+	fmt.Fprintf(w, "  doc completion bash # Print a shell completion script (bash, zsh, fish)\n")
+	fmt.Fprintf(w, "  doc --help          # Show this help\n")
+	fmt.Fprintf(w, "\nConfiguration Commands:\n")
+	fmt.Fprintf(w, "  doc --config        # Show current configuration\n")
+	fmt.Fprintf(w, "  doc --init-config   # Create default config file\n")
+	fmt.Fprintf(w, "  doc --set provider=openai # Set configuration value\n")
+	fmt.Fprintf(w, "  doc --set openai_api_key=sk-... # Set API key\n")
+	fmt.Fprintf(w, "  doc --unset openai_api_key # Reset a key to its default\n")
+	fmt.Fprintf(w, "  doc --profile work --set provider=anthropic # Set a value in the work profile\n")
+	fmt.Fprintf(w, "  doc --list-profiles # Show available profiles\n")
+	fmt.Fprintf(w, "  doc cache clear     # Remove cached translations\n")
+	fmt.Fprintf(w, "\nEnvironment Variables (override config file):\n")
+	fmt.Fprintf(w, "  LLM_PROVIDER      - Provider type: claude-code, openai, anthropic, ollama (default: claude-code)\n")
+	fmt.Fprintf(w, "  OPENAI_API_KEY    - OpenAI API key (required for openai provider)\n")
+	fmt.Fprintf(w, "  ANTHROPIC_API_KEY - Anthropic API key (required for anthropic provider)\n")
+	fmt.Fprintf(w, "  OPENAI_MODEL      - OpenAI model to use (default: gpt-4o-mini)\n")
+	fmt.Fprintf(w, "  ANTHROPIC_MODEL   - Anthropic model to use (default: claude-3-5-haiku-20241022)\n")
+	fmt.Fprintf(w, "  CLAUDE_MODEL      - Claude Code model to use (default: sonnet)\n")
+	fmt.Fprintf(w, "  OPENAI_BASE_URL   - OpenAI-compatible API base URL (default: https://api.openai.com/v1)\n")
+	fmt.Fprintf(w, "  AZURE_OPENAI_ENDPOINT    - Azure OpenAI resource URL; the openai provider then uses Azure\n")
+	fmt.Fprintf(w, "  AZURE_OPENAI_DEPLOYMENT  - Azure OpenAI deployment name (required with the endpoint)\n")
+	fmt.Fprintf(w, "  AZURE_OPENAI_API_VERSION - Azure OpenAI API version (default: 2024-06-01)\n")
+	fmt.Fprintf(w, "  OLLAMA_MODEL      - Ollama model to use (default: llama3.1)\n")
+	fmt.Fprintf(w, "  OLLAMA_BASE_URL   - Ollama server URL (default: http://localhost:11434)\n")
+	fmt.Fprintf(w, "  HTTP_TIMEOUT      - Timeout for API requests in seconds (default: 120)\n")
+	fmt.Fprintf(w, "\nConfig File: $XDG_CONFIG_HOME/bigdra50/doc/config.toml (or ~/.config/bigdra50/doc/config.toml)\n")
+	fmt.Fprintf(w, "Profiles: config.NAME.toml in the same directory, selected with --profile NAME\n")
+	fmt.Fprintf(w, "Set DOC_CONFIG=PATH or --config-file PATH to use another config file (profiles stay in the directory above)\n")
+	fmt.Fprintf(w, "\nExit Codes:\n")
+	fmt.Fprintf(w, "  0  Success\n")
+	fmt.Fprintf(w, "  1  Other failure (e.g. unreadable input file, existing output file)\n")
+	fmt.Fprintf(w, "  2  Invalid arguments or unsupported language\n")
+	fmt.Fprintf(w, "  3  Missing or invalid configuration (e.g. no API key)\n")
+	fmt.Fprintf(w, "  4  Provider or API error during translation\n")
+	fmt.Fprintf(w, "  5  No document or an empty document\n")
 }
 
 // showProviderHelp displays provider-specific help information
@@ -295,23 +1213,69 @@ func showProviderHelp(providerType string) {
 		fmt.Fprintf(os.Stderr, "\nClaude Code Provider Help:\n")
 		fmt.Fprintf(os.Stderr, "  Ensure Claude Code CLI is installed and available in PATH\n")
 		fmt.Fprintf(os.Stderr, "  Install: npm install -g @anthropic-ai/claude-code\n")
+	case ProviderTypeOllama:
+		fmt.Fprintf(os.Stderr, "\nOllama Provider Help:\n")
+		fmt.Fprintf(os.Stderr, "  Ensure the Ollama server is running and the model is pulled\n")
+		fmt.Fprintf(os.Stderr, "  Example: ollama pull llama3.1 && ollama serve\n")
+		fmt.Fprintf(os.Stderr, "  Set OLLAMA_BASE_URL if the server is not at http://localhost:11434\n")
 	}
 }
 
-// showAllModels displays all available models
-func showAllModels() {
+// showProviders displays all providers with their availability and default model
+func showProviders() {
+	fmt.Fprintf(os.Stderr, "Available Providers:\n\n")
+
+	cfg := LoadConfig()
+	for _, providerType := range providerTypes {
+		probe := cfg
+		probe.ProviderType = providerType
+		probe.Verbose = false
+
+		status := "available"
+		if err := probeProvider(probe); err != nil {
+			status = fmt.Sprintf("unavailable (%v)", err)
+		}
+
+		marker := " "
+		if providerType == cfg.ProviderType {
+			marker = "*"
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %-12s model: %-28s %s\n", marker, providerType, configuredModel(cfg, providerType), status)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n* = currently selected provider\n")
+}
+
+// probeProvider reports why a provider cannot be used. API providers only need valid settings; a
+// local Ollama server is also asked whether it is running.
+func probeProvider(cfg ProviderConfig) error {
+	provider, err := NewLLMProvider(cfg)
+	if err != nil {
+		return err
+	}
+	if ollama, ok := provider.(*OllamaProvider); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), ollamaPingTimeout)
+		defer cancel()
+		return ollama.Ping(ctx)
+	}
+	return nil
+}
+
+// showAllModels displays all available models, sorted and filtered as requested
+func showAllModels(sortBy, tier string) {
 	fmt.Fprintf(os.Stderr, "Available Models:\n\n")
 
 	catalog := GetModelCatalog()
 
 	fmt.Fprintf(os.Stderr, "OpenAI Models:\n")
-	for _, model := range catalog.OpenAI {
+	for _, model := range SortModels(FilterModelsByTier(catalog.OpenAI, tier), sortBy) {
 		fmt.Fprintf(os.Stderr, "  %-25s %s (tier: %s, cost: $%.2f/$%.2f per 1M tokens)\n",
 			model.ID, model.Name, model.Tier, model.InputCostPer1M, model.OutputCostPer1M)
 	}
 
 	fmt.Fprintf(os.Stderr, "\nAnthropic Models:\n")
-	for _, model := range catalog.Anthropic {
+	for _, model := range SortModels(FilterModelsByTier(catalog.Anthropic, tier), sortBy) {
 		fmt.Fprintf(os.Stderr, "  %-25s %s (tier: %s, cost: $%.2f/$%.2f per 1M tokens)\n",
 			model.ID, model.Name, model.Tier, model.InputCostPer1M, model.OutputCostPer1M)
 	}
@@ -322,25 +1286,47 @@ func showAllModels() {
 	fmt.Fprintf(os.Stderr, "  %-25s %s\n", "haiku", "Claude Haiku (fast)")
 }
 
-// showModelsForProvider displays models for a specific provider
-func showModelsForProvider(provider string) {
+// writeModelsJSON writes the model catalog as JSON to w, sorted and filtered as requested. With a
+// provider, only that provider's models are written, as an array.
+func writeModelsJSON(w io.Writer, provider, sortBy, tier string) error {
+	var models any
+	if provider != "" {
+		if !slices.Contains(providerTypes, provider) {
+			return fmt.Errorf("unknown provider '%s'. Available providers: %s", provider, strings.Join(providerTypes, ", "))
+		}
+		models = SortModels(FilterModelsByTier(GetModelsByProvider(provider), tier), sortBy)
+	} else {
+		catalog := GetModelCatalog()
+		models = ModelCatalog{
+			OpenAI:    SortModels(FilterModelsByTier(catalog.OpenAI, tier), sortBy),
+			Anthropic: SortModels(FilterModelsByTier(catalog.Anthropic, tier), sortBy),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(models)
+}
+
+// showModelsForProvider displays models for a specific provider, sorted and filtered as requested
+func showModelsForProvider(provider, sortBy, tier string) {
 	switch provider {
 	case "openai":
 		fmt.Fprintf(os.Stderr, "OpenAI Models:\n")
-		for _, model := range GetModelsByProvider(ProviderTypeOpenAI) {
+		for _, model := range SortModels(FilterModelsByTier(GetModelsByProvider(ProviderTypeOpenAI), tier), sortBy) {
 			fmt.Fprintf(os.Stderr, "  %-25s %s (tier: %s)\n", model.ID, model.Name, model.Tier)
 			fmt.Fprintf(os.Stderr, "    Cost: $%.2f input / $%.2f output per 1M tokens\n",
 				model.InputCostPer1M, model.OutputCostPer1M)
-			fmt.Fprintf(os.Stderr, "    Context: %d tokens\n", model.ContextWindow)
+			fmt.Fprintf(os.Stderr, "    Context: %d tokens, max output: %d tokens\n", model.ContextWindow, model.MaxOutputTokens)
 			fmt.Fprintf(os.Stderr, "    Best for: %v\n\n", model.RecommendedFor)
 		}
 	case "anthropic":
 		fmt.Fprintf(os.Stderr, "Anthropic Models:\n")
-		for _, model := range GetModelsByProvider(ProviderTypeAnthropic) {
+		for _, model := range SortModels(FilterModelsByTier(GetModelsByProvider(ProviderTypeAnthropic), tier), sortBy) {
 			fmt.Fprintf(os.Stderr, "  %-25s %s (tier: %s)\n", model.ID, model.Name, model.Tier)
 			fmt.Fprintf(os.Stderr, "    Cost: $%.2f input / $%.2f output per 1M tokens\n",
 				model.InputCostPer1M, model.OutputCostPer1M)
-			fmt.Fprintf(os.Stderr, "    Context: %d tokens\n", model.ContextWindow)
+			fmt.Fprintf(os.Stderr, "    Context: %d tokens, max output: %d tokens\n", model.ContextWindow, model.MaxOutputTokens)
 			fmt.Fprintf(os.Stderr, "    Best for: %v\n\n", model.RecommendedFor)
 		}
 	case "claude-code":
@@ -348,8 +1334,12 @@ func showModelsForProvider(provider string) {
 		fmt.Fprintf(os.Stderr, "  %-25s %s\n", "opus", "High capability, best performance")
 		fmt.Fprintf(os.Stderr, "  %-25s %s\n", "sonnet", "Balanced performance and speed (default)")
 		fmt.Fprintf(os.Stderr, "  %-25s %s\n", "haiku", "Fast response, lower cost")
+	case "ollama":
+		fmt.Fprintf(os.Stderr, "Ollama Models:\n")
+		fmt.Fprintf(os.Stderr, "  Any model pulled into the local Ollama server (default: %s)\n", GetDefaultModel(ProviderTypeOllama))
+		fmt.Fprintf(os.Stderr, "  Run 'ollama list' to see installed models\n")
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown provider: %s\n", provider)
-		fmt.Fprintf(os.Stderr, "Available providers: openai, anthropic, claude-code\n")
+		fmt.Fprintf(os.Stderr, "Available providers: openai, anthropic, claude-code, ollama\n")
 	}
 }
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -17,14 +21,14 @@ func TestParseMergeArgs(t *testing.T) {
 			name: "Basic merge command",
 			args: []string{"./docs"},
 			expected: &CLIArgs{
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "merged.md",
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
@@ -33,33 +37,154 @@ func TestParseMergeArgs(t *testing.T) {
 			name: "Merge with output file",
 			args: []string{"./docs", "book.md"},
 			expected: &CLIArgs{
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "book.md",
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "book.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
 		},
+		{
+			name: "Merge keeping CRLF",
+			args: []string{"./docs", "--keep-crlf"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeKeepCRLF:      true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Merge with separator file",
+			args: []string{"./docs", "--separator-file", "divider.md"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeSeparatorFile: "divider.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Merge with force",
+			args: []string{"./docs", "book.md", "--force"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "book.md",
+				MergeForce:         true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Merge with separator and separator file",
+			args:    []string{"./docs", "--separator", "***", "--separator-file", "divider.md"},
+			wantErr: true,
+		},
+		{
+			name: "Merge with title",
+			args: []string{"./docs", "-o", "book.md", "--title", "My Handbook"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "book.md",
+				MergeTitle:         "My Handbook",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Merge as HTML",
+			args: []string{"./docs", "--format", "html"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.html",
+				MergeFormat:        "html",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Merge with invalid format",
+			args:    []string{"./docs", "--format", "pdf"},
+			wantErr: true,
+		},
+		{
+			name: "Merge from stdin",
+			args: []string{"--from-stdin", "book.md"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeFromStdin:     true,
+				MergeOutputFile:    "book.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Merge from stdin with a directory",
+			args:    []string{"--from-stdin", "./docs", "-o", "book.md"},
+			wantErr: true,
+		},
+		{
+			name:    "Merge with empty title",
+			args:    []string{"./docs", "--title", " "},
+			wantErr: true,
+		},
 		{
 			name: "Merge with flags",
 			args: []string{"./docs", "-r", "--include-meta", "--dry-run"},
 			expected: &CLIArgs{
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "merged.md",
-				MergeRecursive:    true,
-				MergeIncludeMeta:  true,
-				MergeDryRun:       true,
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeRecursive:     true,
+				MergeIncludeMeta:   true,
+				MergeDryRun:        true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
@@ -68,14 +193,14 @@ func TestParseMergeArgs(t *testing.T) {
 			name: "Merge with output option",
 			args: []string{"./docs", "-o", "custom.md"},
 			expected: &CLIArgs{
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "custom.md",
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "custom.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
@@ -84,14 +209,14 @@ func TestParseMergeArgs(t *testing.T) {
 			name: "Merge with order option",
 			args: []string{"./docs", "--order", "modified"},
 			expected: &CLIArgs{
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "merged.md",
-				MergeOrder:        "modified",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeOrder:         "modified",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
@@ -101,7 +226,7 @@ func TestParseMergeArgs(t *testing.T) {
 			args: []string{"./docs", "--include", "*.md", "--exclude", "README.md"},
 			expected: &CLIArgs{
 				IsMergeCommand:       true,
-				MergeDirectory:       "./docs",
+				MergeDirectories:     []string{"./docs"},
 				MergeOutputFile:      "merged.md",
 				MergeIncludePatterns: []string{"*.md"},
 				MergeExcludePatterns: []string{"README.md"},
@@ -114,6 +239,46 @@ func TestParseMergeArgs(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Merge with extensions",
+			args: []string{"./docs", "--ext", ".md, markdown"},
+			expected: &CLIArgs{
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeExtensions:    []string{".md", ".markdown"},
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Merge with page break marker",
+			args: []string{"./docs", "--page-break-marker", "\\newpage"},
+			expected: &CLIArgs{
+				IsMergeCommand:       true,
+				MergeDirectories:     []string{"./docs"},
+				MergeOutputFile:      "merged.md",
+				MergePageBreaks:      true,
+				MergePageBreakMarker: "\\newpage",
+				MergeOrder:           "filename",
+				MergeSeparator:       "\n\n---\n\n",
+				MergeGenerateTOC:     true,
+				MergeTOCDepth:        3,
+				MergeBaseLevel:       2,
+				MergeAdjustHeaders:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Merge with invalid front matter mode",
+			args:    []string{"./docs", "--front-matter", "drop"},
+			wantErr: true,
+		},
 		{
 			name:    "Merge without directory",
 			args:    []string{},
@@ -134,11 +299,11 @@ func TestParseMergeArgs(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cliArgs := &CLIArgs{
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			}
 
@@ -168,6 +333,9 @@ func TestParseArgsWithMergeCommand(t *testing.T) {
 	originalArgs := os.Args
 	defer func() { os.Args = originalArgs }()
 
+	// Merge defaults from the user's config must not leak into the expectations
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
 	tests := []struct {
 		name     string
 		args     []string
@@ -178,14 +346,14 @@ func TestParseArgsWithMergeCommand(t *testing.T) {
 			name: "Parse merge command",
 			args: []string{"doc", "merge", "./docs"},
 			expected: &CLIArgs{
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "merged.md",
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
@@ -194,15 +362,33 @@ func TestParseArgsWithMergeCommand(t *testing.T) {
 			name: "Parse verbose merge command",
 			args: []string{"doc", "-v", "merge", "./docs"},
 			expected: &CLIArgs{
-				Verbose:           true,
-				IsMergeCommand:    true,
-				MergeDirectory:    "./docs",
-				MergeOutputFile:   "merged.md",
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				Verbose:            true,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse quiet verbose merge command",
+			args: []string{"doc", "-q", "-v", "merge", "./docs"},
+			expected: &CLIArgs{
+				Verbose:            true,
+				Quiet:              true,
+				IsMergeCommand:     true,
+				MergeDirectories:   []string{"./docs"},
+				MergeOutputFile:    "merged.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
@@ -211,16 +397,440 @@ func TestParseArgsWithMergeCommand(t *testing.T) {
 			name: "Parse regular translation command",
 			args: []string{"doc", "ja"},
 			expected: &CLIArgs{
-				TargetLanguage:    "ja",
-				MergeOrder:        "filename",
-				MergeSeparator:    "\n\n---\n\n",
-				MergeGenerateTOC:  true,
-				MergeTOCDepth:     3,
-				MergeBaseLevel:    2,
+				TargetLanguage:     "ja",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse translation command with input file",
+			args: []string{"doc", "ja", "--input", "README.md"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				InputFile:          "README.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Input flag without value",
+			args:    []string{"doc", "ja", "--input"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translation command with profile",
+			args: []string{"doc", "-v", "--profile", "work", "ja"},
+			expected: &CLIArgs{
+				Verbose:            true,
+				Profile:            "work",
+				TargetLanguage:     "ja",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse translation command with --files",
+			args: []string{"doc", "ja", "--files", "intro.md", "usage.md", "-o", "guide.ja.md"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				InputFiles:         []string{"intro.md", "usage.md"},
+				OutputFile:         "guide.ja.md",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "--files without input files",
+			args:    []string{"doc", "ja", "--files"},
+			wantErr: true,
+		},
+		{
+			name:    "--files with --file",
+			args:    []string{"doc", "ja", "--files", "a.md", "-f", "b.md"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translation command with temperature",
+			args: []string{"doc", "ja", "--temperature", "0"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				Temperature:        new(float64),
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Max tokens below one",
+			args:    []string{"doc", "ja", "--max-tokens", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "Temperature out of range",
+			args:    []string{"doc", "ja", "--temperature", "2.5"},
+			wantErr: true,
+		},
+		{
+			name:    "Temperature not a number",
+			args:    []string{"doc", "ja", "--temperature", "warm"},
+			wantErr: true,
+		},
+		{
+			name: "Parse transform command",
+			args: []string{"doc", "transform", "convert to a formal tone", "-f", "notes.md", "-o", "formal.md"},
+			expected: &CLIArgs{
+				IsTransformCommand:   true,
+				TransformInstruction: "convert to a formal tone",
+				InputFile:            "notes.md",
+				OutputFile:           "formal.md",
+				MergeOrder:           "filename",
+				MergeSeparator:       "\n\n---\n\n",
+				MergeGenerateTOC:     true,
+				MergeTOCDepth:        3,
+				MergeBaseLevel:       2,
+				MergeAdjustHeaders:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse transform command with files",
+			args: []string{"doc", "transform", "summarize", "--files", "a.md", "b.md"},
+			expected: &CLIArgs{
+				IsTransformCommand:   true,
+				TransformInstruction: "summarize",
+				InputFiles:           []string{"a.md", "b.md"},
+				MergeOrder:           "filename",
+				MergeSeparator:       "\n\n---\n\n",
+				MergeGenerateTOC:     true,
+				MergeTOCDepth:        3,
+				MergeBaseLevel:       2,
+				MergeAdjustHeaders:   true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Transform without instruction",
+			args:    []string{"doc", "transform", "-f", "notes.md"},
+			wantErr: true,
+		},
+		{
+			name:    "Transform with unquoted instruction",
+			args:    []string{"doc", "transform", "make", "it", "formal"},
+			wantErr: true,
+		},
+		{
+			name:    "Transform with skip-if-translated",
+			args:    []string{"doc", "transform", "summarize", "--skip-if-translated"},
+			wantErr: true,
+		},
+		{
+			name: "List languages as JSON",
+			args: []string{"doc", "--list", "--json"},
+			expected: &CLIArgs{
+				ShowList:           true,
+				ListJSON:           true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "List with unknown option",
+			args:    []string{"doc", "--list", "--yaml"},
+			wantErr: true,
+		},
+		{
+			name: "List models as JSON",
+			args: []string{"doc", "--list-models", "openai", "--json"},
+			expected: &CLIArgs{
+				ShowListModels:     true,
+				ListModelsProvider: "openai",
+				ListJSON:           true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "List models by tier",
+			args: []string{"doc", "--list-models", "openai", "--tier", "economy"},
+			expected: &CLIArgs{
+				ShowListModels:     true,
+				ListModelsProvider: "openai",
+				ListModelsTier:     "economy",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "List models with an unknown tier",
+			args:    []string{"doc", "--list-models", "--tier", "cheap"},
+			wantErr: true,
+		},
+		{
+			name:    "List models with an unknown filter tier",
+			args:    []string{"doc", "--list-models", "--filter", "tier=cheap"},
+			wantErr: true,
+		},
+		{
+			name:    "List models tier without a value",
+			args:    []string{"doc", "--list-models", "--tier"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translation command with debug dump",
+			args: []string{"doc", "ja", "--debug-dump", "./debug"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				DebugDumpDir:       "./debug",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse translation command with print prompt",
+			args: []string{"doc", "ja", "-f", "guide.md", "--print-prompt"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				InputFile:          "guide.md",
+				PrintPrompt:        true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Print prompt with output",
+			args:    []string{"doc", "ja", "--print-prompt", "-o", "out.md"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translation command keeping CRLF",
+			args: []string{"doc", "ja", "-f", "notes.md", "--keep-crlf"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				InputFile:          "notes.md",
+				KeepCRLF:           true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse translation command with config file",
+			args: []string{"doc", "--config-file", "ci.toml", "ja"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				ConfigFile:         "ci.toml",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Config file without a path",
+			args:    []string{"doc", "--config-file"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translation command with auto model",
+			args: []string{"doc", "ja", "-f", "guide.md", "--auto-model"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				InputFile:          "guide.md",
+				AutoModel:          true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Auto model with an explicit model",
+			args:    []string{"doc", "ja", "--auto-model", "--model", "gpt-4o"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translation command with provider and model",
+			args: []string{"doc", "ja", "--provider", "openai", "--model", "gpt-4o"},
+			expected: &CLIArgs{
+				TargetLanguage:     "ja",
+				Provider:           "openai",
+				Model:              "gpt-4o",
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
 				MergeAdjustHeaders: true,
 			},
 			wantErr: false,
 		},
+		{
+			name:    "Unknown provider",
+			args:    []string{"doc", "ja", "--provider", "gemini"},
+			wantErr: true,
+		},
+		{
+			name:    "Profile name with path separator",
+			args:    []string{"doc", "--profile", "../work", "ja"},
+			wantErr: true,
+		},
+		{
+			name: "Parse translate-dir command",
+			args: []string{"doc", "translate-dir", "./docs", "ja", "-r", "--exclude", "CHANGELOG.md", "--concurrency", "4"},
+			expected: &CLIArgs{
+				TargetLanguage:              "ja",
+				IsTranslateDirCommand:       true,
+				TranslateDirDirectory:       "./docs",
+				TranslateDirRecursive:       true,
+				TranslateDirExcludePatterns: []string{"CHANGELOG.md"},
+				TranslateDirConcurrency:     4,
+				MergeOrder:                  "filename",
+				MergeSeparator:              "\n\n---\n\n",
+				MergeGenerateTOC:            true,
+				MergeTOCDepth:               3,
+				MergeBaseLevel:              2,
+				MergeAdjustHeaders:          true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse translate-dir command with output directory template",
+			args: []string{"doc", "translate-dir", "./docs", "ja", "--out-dir-template", "i18n/{{.Lang}}"},
+			expected: &CLIArgs{
+				TargetLanguage:             "ja",
+				IsTranslateDirCommand:      true,
+				TranslateDirDirectory:      "./docs",
+				TranslateDirOutDirTemplate: "i18n/{{.Lang}}",
+				MergeOrder:                 "filename",
+				MergeSeparator:             "\n\n---\n\n",
+				MergeGenerateTOC:           true,
+				MergeTOCDepth:              3,
+				MergeBaseLevel:             2,
+				MergeAdjustHeaders:         true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Translate-dir with an invalid output directory template",
+			args:    []string{"doc", "translate-dir", "./docs", "ja", "--out-dir-template", "i18n/{{.Language}}"},
+			wantErr: true,
+		},
+		{
+			name:    "Translate-dir without language",
+			args:    []string{"doc", "translate-dir", "./docs"},
+			wantErr: true,
+		},
+		{
+			name: "Parse unset command",
+			args: []string{"doc", "--unset", "openai_api_key", "openai_model"},
+			expected: &CLIArgs{
+				UnsetConfig:        []string{"openai_api_key", "openai_model"},
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Unset without keys",
+			args:    []string{"doc", "--unset"},
+			wantErr: true,
+		},
+		{
+			name: "Parse help flag",
+			args: []string{"doc", "--help"},
+			expected: &CLIArgs{
+				ShowHelp:           true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Parse version flag",
+			args: []string{"doc", "-V"},
+			expected: &CLIArgs{
+				ShowVersion:        true,
+				MergeOrder:         "filename",
+				MergeSeparator:     "\n\n---\n\n",
+				MergeGenerateTOC:   true,
+				MergeTOCDepth:      3,
+				MergeBaseLevel:     2,
+				MergeAdjustHeaders: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Stream with output file",
+			args:    []string{"doc", "ja", "--stream", "-o", "README.ja.md"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -248,6 +858,59 @@ func TestParseArgsWithMergeCommand(t *testing.T) {
 	}
 }
 
+func TestParseArgsMergeConfigDefaults(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	configDir := filepath.Join(tempDir, "bigdra50", "doc")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configFile := `[merge]
+recursive = true
+order = "modified"
+separator = "\n\n"
+toc = false
+toc_depth = 2
+base_level = 1
+adjust_headers = false
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Args = []string{"doc", "merge", "./docs"}
+	result, err := parseArgs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &CLIArgs{
+		IsMergeCommand:   true,
+		MergeDirectories: []string{"./docs"},
+		MergeOutputFile:  "merged.md",
+		MergeRecursive:   true,
+		MergeOrder:       "modified",
+		MergeSeparator:   "\n\n",
+		MergeTOCDepth:    2,
+		MergeBaseLevel:   1,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("parseArgs() = %+v, want %+v", result, expected)
+	}
+
+	// Flags override the configured defaults
+	os.Args = []string{"doc", "merge", "./docs", "--order", "size", "--toc-depth", "4", "--adjust-headers"}
+	result, err = parseArgs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.MergeOrder != "size" || result.MergeTOCDepth != 4 || !result.MergeAdjustHeaders || !result.MergeRecursive {
+		t.Errorf("Expected flags to override config defaults, got %+v", result)
+	}
+}
+
 func TestIsValidOrder(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -255,6 +918,7 @@ func TestIsValidOrder(t *testing.T) {
 		want  bool
 	}{
 		{"filename", "filename", true},
+		{"numeric", "numeric", true},
 		{"modified", "modified", true},
 		{"size", "size", true},
 		{"custom", "custom", true},
@@ -269,4 +933,72 @@ func TestIsValidOrder(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestShowProviders(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("DOC_CONFIG", "")
+	t.Setenv("PATH", tempDir) // No claude command
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "sk-test-0123456789abcdef")
+	t.Setenv("OPENAI_API_KEY_FILE", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY_FILE", "")
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	t.Setenv("CLAUDE_CODE_PATH", "")
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"0.5.7"}`))
+	}))
+	defer ollama.Close()
+	t.Setenv("OLLAMA_BASE_URL", ollama.URL)
+
+	stderrFile, err := os.CreateTemp(tempDir, "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStderr := os.Stderr
+	os.Stderr = stderrFile
+	t.Cleanup(func() { os.Stderr = originalStderr })
+	showProviders()
+	os.Stderr = originalStderr
+
+	output, err := os.ReadFile(stderrFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(strings.TrimPrefix(line, "*")); len(fields) > 0 {
+			lines[fields[0]] = line
+		}
+	}
+	tests := []struct {
+		provider  string
+		selected  bool
+		available bool
+	}{
+		{ProviderTypeClaude, false, false},
+		{ProviderTypeOpenAI, true, true},
+		{ProviderTypeAnthropic, false, false},
+		{ProviderTypeOllama, false, true},
+	}
+	for _, tt := range tests {
+		line, ok := lines[tt.provider]
+		if !ok {
+			t.Errorf("Expected %s to be listed, got:\n%s", tt.provider, output)
+			continue
+		}
+		if selected := strings.HasPrefix(line, "*"); selected != tt.selected {
+			t.Errorf("%s: selected = %v, want %v: %q", tt.provider, selected, tt.selected, line)
+		}
+		if available := !strings.Contains(line, "unavailable"); available != tt.available {
+			t.Errorf("%s: available = %v, want %v: %q", tt.provider, available, tt.available, line)
+		}
+	}
+	if strings.Contains(string(output), "sk-test-0123456789abcdef") {
+		t.Errorf("Expected the API key not to be printed, got:\n%s", output)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import "strings"
+
+// stripHTMLComments removes <!-- ... --> comments from markdown, including comments spanning
+// several lines. Comments in fenced code blocks and code spans are kept, and lines that held
+// nothing but comments are removed entirely.
+func stripHTMLComments(content string) string {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	var fence codeFenceTracker
+	inComment := false
+
+	for _, line := range lines {
+		if !inComment && fence.inCode(line) {
+			out = append(out, line)
+			continue
+		}
+
+		var kept string
+		kept, inComment = stripLineComments(line, inComment)
+		if strings.TrimSpace(kept) == "" && strings.TrimSpace(line) != "" {
+			continue
+		}
+		out = append(out, kept)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// stripLineComments removes the comments from one line, which starts inside a comment if
+// inComment is set, and reports whether a comment is still open at the end of the line
+func stripLineComments(line string, inComment bool) (string, bool) {
+	if !inComment && !strings.Contains(line, "<!--") {
+		return line, false
+	}
+
+	var sb strings.Builder
+	spans := inlineCodePattern.FindAllStringIndex(line, -1)
+
+	// Offset in the kept text where the last comment was removed, or -1
+	cut := -1
+	if inComment {
+		cut = 0
+	}
+
+	for i := 0; i < len(line); {
+		if inComment {
+			end := strings.Index(line[i:], "-->")
+			if end < 0 {
+				break
+			}
+			i += end + len("-->")
+			inComment = false
+			continue
+		}
+
+		start := strings.Index(line[i:], "<!--")
+		if start < 0 {
+			sb.WriteString(line[i:])
+			break
+		}
+		start += i
+
+		// A comment opener inside a code span is literal text
+		if span := spanContaining(spans, start); span != nil {
+			sb.WriteString(line[i:span[1]])
+			i = span[1]
+			continue
+		}
+
+		sb.WriteString(line[i:start])
+		cut = sb.Len()
+		i = start + len("<!--")
+		inComment = true
+	}
+
+	// Drop the space that separated a trailing comment from the text, as in "text <!-- note -->".
+	// Whitespace after the comment is kept, so a hard line break ("text <!-- note -->  ") survives.
+	kept := sb.String()
+	if cut >= 0 && strings.Trim(kept[cut:], " \t") == "" {
+		kept = strings.TrimRight(kept[:cut], " \t") + kept[cut:]
+	}
+	return kept, inComment
+}
+
+// spanContaining returns the [start, end) span that contains offset, or nil
+func spanContaining(spans [][]int, offset int) []int {
+	for _, span := range spans {
+		if span[0] <= offset && offset < span[1] {
+			return span
+		}
+	}
+	return nil
+}
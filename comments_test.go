@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripHTMLComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Comment on its own line",
+			content:  "# Guide\n\n<!-- TODO: expand -->\nSome text",
+			expected: "# Guide\n\nSome text",
+		},
+		{
+			name:     "Inline comment",
+			content:  "Some <!-- editor note --> text <!-- again -->",
+			expected: "Some  text",
+		},
+		{
+			name:     "Hard line break after an inline comment",
+			content:  "First line <!-- note -->  \nSecond line",
+			expected: "First line  \nSecond line",
+		},
+		{
+			name:     "Trailing whitespace in a code span line is kept",
+			content:  "Use `<!--` to open a comment  \nNext",
+			expected: "Use `<!--` to open a comment  \nNext",
+		},
+		{
+			name:     "Multi-line comment",
+			content:  "Before\n<!--\n# Draft heading\nnotes\n-->\nAfter",
+			expected: "Before\nAfter",
+		},
+		{
+			name:     "Text after a multi-line comment is kept",
+			content:  "Before <!-- start\nmiddle\nend --> after",
+			expected: "Before\n after",
+		},
+		{
+			name:     "Comments in code blocks and code spans are kept",
+			content:  "```html\n<!-- keep -->\n```\nUse `<!-- x -->` for comments",
+			expected: "```html\n<!-- keep -->\n```\nUse `<!-- x -->` for comments",
+		},
+		{
+			name:     "Hard line breaks are kept",
+			content:  "Line one  \nLine two",
+			expected: "Line one  \nLine two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := stripHTMLComments(tt.content); result != tt.expected {
+				t.Errorf("stripHTMLComments() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripHTMLCommentsUnclosed(t *testing.T) {
+	// An unclosed comment hides the rest of the document, as it does when rendered
+	result := stripHTMLComments("Text\n<!-- never closed\nmore")
+	if strings.Contains(result, "more") || !strings.HasPrefix(result, "Text") {
+		t.Errorf("stripHTMLComments() = %q, want only the text before the comment", result)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// completionShells lists the shells supported by doc completion
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionCommands are the words completed in the command position, before any language code
+var completionCommands = []string{
+	"merge", "translate-dir", "transform", "cache", "completion",
+	"--help", "--version", "--list", "--list-models", "--list-providers", "--list-profiles",
+	"--config", "--init-config", "--set", "--unset", "--profile", "--config-file", "-v", "-q",
+	"--quiet",
+}
+
+// translationCompletionFlags are the flags completed after a language code
+var translationCompletionFlags = []string{
+	"-f", "--file", "--input", "--files", "-o", "--output", "--force", "--diff",
+	"--auto-repair", "--max-repairs", "--max-chunk-tokens", "--max-tokens", "--temperature",
+	"--stream", "--json", "--estimate", "--print-prompt", "--no-cache", "--no-protect",
+	"--keep-crlf", "--glossary", "--skip-if-translated", "--debug-dump", "--provider", "--model",
+	"--auto-model", "--profile", "-q", "--quiet",
+}
+
+// mergeCompletionFlags are the flags completed after merge
+var mergeCompletionFlags = []string{
+	"-r", "--recursive", "-o", "--output", "--order", "--reverse", "--separator", "--separator-file",
+	"--include-meta", "--format", "--manifest", "--reading-time", "--no-toc", "--toc-file",
+	"--toc-only", "--toc-depth", "--base-level", "--adjust-headers", "--title", "--no-title",
+	"--smart-title",
+	"--rewrite-links", "--file-headings", "--dedupe-anchors", "--page-breaks",
+	"--page-break-marker", "--front-matter", "--append-sources", "--sources-heading", "--include",
+	"--exclude", "--exclude-dir", "--respect-gitignore", "--no-gitignore", "--ext", "--keep-crlf",
+	"--strip-comments", "--max-file-size", "--from-stdin", "--dry-run", "--check", "--force", "-q",
+	"--quiet",
+}
+
+// translateDirCompletionFlags are the flags completed after translate-dir
+var translateDirCompletionFlags = []string{
+	"-r", "--recursive", "--exclude", "--concurrency", "--force", "--no-cache", "--no-protect",
+	"--glossary", "--out-dir-template", "--provider", "--model", "--profile", "-q", "--quiet",
+}
+
+// completionFileFlags are the flags whose value is a file path
+var completionFileFlags = []string{
+	"-f", "--file", "--input", "-o", "--output", "--glossary", "--toc-file", "--manifest",
+	"--separator-file", "--debug-dump", "--config-file",
+}
+
+// completionFlagValues lists the values completed after flags with a fixed set of choices
+var completionFlagValues = map[string][]string{
+	"--provider":     providerTypes,
+	"--order":        {"filename", "numeric", "modified", "size", "custom"},
+	"--front-matter": {"strip", "heading", "keep"},
+	"--format":       mergeFormats,
+	"--sort-by":      {"cost", "context", "tier"},
+	"--tier":         ModelTiers(),
+}
+
+// isValidCompletionShell checks if completion scripts can be generated for shell
+func isValidCompletionShell(shell string) bool {
+	return slices.Contains(completionShells, shell)
+}
+
+// completionLanguages returns the supported language codes in sorted order
+func completionLanguages() []string {
+	return slices.Sorted(maps.Keys(supportedLanguages))
+}
+
+// writeCompletion writes the completion script for shell to w
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w)
+	case "zsh":
+		return writeZshCompletion(w)
+	case "fish":
+		return writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell '%s'. Supported shells: %s", shell, strings.Join(completionShells, ", "))
+	}
+}
+
+// writeBashCompletion writes a bash completion script; load it with: source <(doc completion bash)
+func writeBashCompletion(w io.Writer) error {
+	var values strings.Builder
+	for _, flag := range slices.Sorted(maps.Keys(completionFlagValues)) {
+		fmt.Fprintf(&values, "        %s)\n            COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n            return\n            ;;\n",
+			flag, strings.Join(completionFlagValues[flag], " "))
+	}
+
+	_, err := fmt.Fprintf(w, `# bash completion for doc
+_doc() {
+    local cur prev cmd i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "$prev" in
+%s        %s)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+    esac
+
+    # The command is the first word after the global flags
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            -v|-q|--quiet) ;;
+            --profile|--config-file) ((i++)) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    case "$cmd" in
+        "")
+            COMPREPLY=($(compgen -W "%s %s" -- "$cur"))
+            ;;
+        merge)
+            if [[ "$cur" == -* ]]; then
+                COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -d -- "$cur"))
+            fi
+            ;;
+        translate-dir)
+            if [[ "$cur" == -* ]]; then
+                COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -d -W "%s" -- "$cur"))
+            fi
+            ;;
+        cache)
+            COMPREPLY=($(compgen -W "clear" -- "$cur"))
+            ;;
+        completion)
+            COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            ;;
+        --list)
+            COMPREPLY=($(compgen -W "--json --by-name --group" -- "$cur"))
+            ;;
+        --list-models)
+            COMPREPLY=($(compgen -W "%s --sort-by --filter --tier --json" -- "$cur"))
+            ;;
+        -*)
+            ;;
+        *)
+            if [[ "$cur" == -* ]]; then
+                COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -f -- "$cur"))
+            fi
+            ;;
+    esac
+}
+
+complete -F _doc doc
+`,
+		values.String(), strings.Join(completionFileFlags, "|"),
+		strings.Join(completionCommands, " "), strings.Join(completionLanguages(), " "),
+		strings.Join(mergeCompletionFlags, " "),
+		strings.Join(translateDirCompletionFlags, " "), strings.Join(completionLanguages(), " "),
+		strings.Join(completionShells, " "),
+		strings.Join(providerTypes, " "),
+		strings.Join(translationCompletionFlags, " "))
+	return err
+}
+
+// writeZshCompletion writes a zsh completion script; load it with: source <(doc completion zsh)
+func writeZshCompletion(w io.Writer) error {
+	var values strings.Builder
+	for _, flag := range slices.Sorted(maps.Keys(completionFlagValues)) {
+		fmt.Fprintf(&values, "        %s) compadd -- %s; return ;;\n", flag, strings.Join(completionFlagValues[flag], " "))
+	}
+
+	_, err := fmt.Fprintf(w, `#compdef doc
+
+_doc() {
+    local -a languages
+    languages=(%s)
+
+    case $words[CURRENT-1] in
+%s        %s) _files; return ;;
+    esac
+
+    # The command is the first word after the global flags
+    local cmd i
+    for ((i = 2; i < CURRENT; i++)); do
+        case $words[i] in
+            -v|-q|--quiet) ;;
+            --profile|--config-file) ((i++)) ;;
+            *) cmd=$words[i]; break ;;
+        esac
+    done
+
+    case $cmd in
+        '')
+            compadd -- %s $languages
+            ;;
+        merge)
+            if [[ $PREFIX == -* ]]; then
+                compadd -- %s
+            else
+                _files -/
+            fi
+            ;;
+        translate-dir)
+            if [[ $PREFIX == -* ]]; then
+                compadd -- %s
+            else
+                compadd -- $languages
+                _files -/
+            fi
+            ;;
+        cache)
+            compadd -- clear
+            ;;
+        completion)
+            compadd -- %s
+            ;;
+        --list)
+            compadd -- --json --by-name --group
+            ;;
+        --list-models)
+            compadd -- %s --sort-by --filter --tier --json
+            ;;
+        -*)
+            ;;
+        *)
+            if [[ $PREFIX == -* ]]; then
+                compadd -- %s
+            else
+                _files
+            fi
+            ;;
+    esac
+}
+
+compdef _doc doc
+`,
+		strings.Join(completionLanguages(), " "),
+		values.String(), strings.Join(completionFileFlags, "|"),
+		strings.Join(completionCommands, " "),
+		strings.Join(mergeCompletionFlags, " "),
+		strings.Join(translateDirCompletionFlags, " "),
+		strings.Join(completionShells, " "),
+		strings.Join(providerTypes, " "),
+		strings.Join(translationCompletionFlags, " "))
+	return err
+}
+
+// writeFishCompletion writes a fish completion script; load it with: doc completion fish | source
+func writeFishCompletion(w io.Writer) error {
+	commands := []string{"merge", "translate-dir", "transform", "cache", "completion"}
+	inCommand := func(command string) string {
+		return fmt.Sprintf("__fish_seen_subcommand_from %s", command)
+	}
+	// Translation flags apply once a language code has been given
+	translating := fmt.Sprintf("not __fish_use_subcommand; and not __fish_seen_subcommand_from %s", strings.Join(commands, " "))
+
+	var sb strings.Builder
+	sb.WriteString("# fish completion for doc\n")
+	sb.WriteString("complete -c doc -f\n\n")
+
+	sb.WriteString("# Commands and languages\n")
+	fmt.Fprintf(&sb, "complete -c doc -n __fish_use_subcommand -a %q\n", strings.Join(commands, " "))
+	for _, code := range completionLanguages() {
+		fmt.Fprintf(&sb, "complete -c doc -n __fish_use_subcommand -a %s -d %q\n", code, supportedLanguages[code])
+	}
+	for _, flag := range completionCommands {
+		if strings.HasPrefix(flag, "-") {
+			fmt.Fprintf(&sb, "complete -c doc -n __fish_use_subcommand %s\n", fishFlag(flag))
+		}
+	}
+	fmt.Fprintf(&sb, "complete -c doc -n %q -a clear\n", inCommand("cache"))
+	fmt.Fprintf(&sb, "complete -c doc -n %q -a %q\n", inCommand("completion"), strings.Join(completionShells, " "))
+
+	sections := []struct {
+		comment   string
+		condition string
+		flags     []string
+	}{
+		{"Translation", translating, translationCompletionFlags},
+		{"Transform", inCommand("transform"), translationCompletionFlags},
+		{"Merge", inCommand("merge"), mergeCompletionFlags},
+		{"Translate-dir", inCommand("translate-dir"), translateDirCompletionFlags},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(&sb, "\n# %s flags\n", section.comment)
+		for _, flag := range section.flags {
+			fmt.Fprintf(&sb, "complete -c doc -n %q %s\n", section.condition, fishFlag(flag))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// fishFlag returns the fish complete options for flag, including the values it takes
+func fishFlag(flag string) string {
+	option := "-l " + strings.TrimPrefix(flag, "--")
+	if !strings.HasPrefix(flag, "--") {
+		option = "-s " + strings.TrimPrefix(flag, "-")
+	}
+
+	if slices.Contains(completionFileFlags, flag) {
+		return option + " -r -F"
+	}
+	if values, ok := completionFlagValues[flag]; ok {
+		return option + fmt.Sprintf(" -x -a %q", strings.Join(values, " "))
+	}
+	return option
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			var sb strings.Builder
+			if err := writeCompletion(&sb, shell); err != nil {
+				t.Fatalf("writeCompletion(%q) error = %v", shell, err)
+			}
+			script := sb.String()
+
+			words := append([]string{"merge", "translate-dir", "anthropic", "dedupe-anchors", "files"}, completionLanguages()...)
+			for _, word := range words {
+				if !strings.Contains(script, word) {
+					t.Errorf("%s completion is missing %q", shell, word)
+				}
+			}
+		})
+	}
+
+	if err := writeCompletion(&strings.Builder{}, "tcsh"); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
+
+func TestCompletionFlagsAreDocumented(t *testing.T) {
+	var usage strings.Builder
+	printUsage(&usage)
+
+	flagLists := [][]string{completionCommands, translationCompletionFlags, mergeCompletionFlags, translateDirCompletionFlags}
+	for _, flags := range flagLists {
+		for _, flag := range flags {
+			if !strings.Contains(usage.String(), flag) {
+				t.Errorf("Completion flag %q does not appear in the usage text", flag)
+			}
+		}
+	}
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/bigdra50/doc/internal/config"
 )
 
 func TestLoadConfigFromEnv(t *testing.T) {
@@ -47,3 +51,240 @@ func TestLoadConfigFromEnv(t *testing.T) {
 		t.Errorf("Expected API key 'test-key', got %s", config.OpenAIAPIKey)
 	}
 }
+
+func TestLoadConfigAPIKeyFile(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+
+	keyFile := filepath.Join(tempDir, "openai_key")
+	if err := os.WriteFile(keyFile, []byte("sk-from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-inline")
+	t.Setenv("OPENAI_API_KEY_FILE", keyFile)
+
+	config := LoadConfig()
+	if config.OpenAIAPIKey != "sk-from-file" {
+		t.Errorf("Expected key from file 'sk-from-file', got %q", config.OpenAIAPIKey)
+	}
+}
+
+func TestLoadConfigOpenAIMaxRetries(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+
+	// Defaults apply when the key is absent
+	if config := LoadConfig(); config.OpenAIMaxRetries != 3 {
+		t.Errorf("Expected default openai_max_retries 3, got %d", config.OpenAIMaxRetries)
+	}
+
+	// An explicit 0 disables retries
+	configDir := filepath.Join(tempDir, "bigdra50", "doc")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("openai_max_retries = 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if config := LoadConfig(); config.OpenAIMaxRetries != 0 {
+		t.Errorf("Expected openai_max_retries 0 from config file, got %d", config.OpenAIMaxRetries)
+	}
+}
+
+func TestConfigPathOverride(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "xdg"))
+
+	envPath := filepath.Join(tempDir, "ci", "doc.toml")
+	t.Setenv("DOC_CONFIG", envPath)
+	if path := config.GetConfigPath(); path != envPath {
+		t.Errorf("Expected DOC_CONFIG path %s, got %s", envPath, path)
+	}
+
+	// Saving creates the file at the override instead of under XDG_CONFIG_HOME
+	cfg := config.Defaults()
+	cfg.OpenAIModel = "gpt-4o"
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if loaded := LoadConfig(); loaded.OpenAIModel != "gpt-4o" {
+		t.Errorf("Expected openai_model from %s, got %q", envPath, loaded.OpenAIModel)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "xdg")); !os.IsNotExist(err) {
+		t.Errorf("Expected the XDG config directory to be left alone, got %v", err)
+	}
+
+	// --config-file takes precedence over DOC_CONFIG
+	flagPath := filepath.Join(tempDir, "flag.toml")
+	if err := os.WriteFile(flagPath, []byte("openai_model = \"gpt-4o-mini\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	selectConfig(&CLIArgs{ConfigFile: flagPath})
+	defer config.SetConfigPath("")
+	if path := config.GetConfigPath(); path != flagPath {
+		t.Errorf("Expected --config-file path %s, got %s", flagPath, path)
+	}
+	if loaded := LoadConfig(); loaded.OpenAIModel != "gpt-4o-mini" {
+		t.Errorf("Expected openai_model from %s, got %q", flagPath, loaded.OpenAIModel)
+	}
+}
+
+func TestLoadConfigHTTPTimeout(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if config := LoadConfig(); config.HTTPTimeoutSeconds != 120 {
+		t.Errorf("Expected default http_timeout_seconds 120, got %d", config.HTTPTimeoutSeconds)
+	}
+
+	t.Setenv("HTTP_TIMEOUT", "30")
+	if config := LoadConfig(); config.HTTPTimeoutSeconds != 30 {
+		t.Errorf("Expected HTTP_TIMEOUT to set 30, got %d", config.HTTPTimeoutSeconds)
+	}
+
+	// Non-positive values are rejected in favor of the previous value
+	originalStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = originalStderr }()
+
+	t.Setenv("HTTP_TIMEOUT", "0")
+	if config := LoadConfig(); config.HTTPTimeoutSeconds != 120 {
+		t.Errorf("Expected invalid HTTP_TIMEOUT to keep 120, got %d", config.HTTPTimeoutSeconds)
+	}
+}
+
+func TestLoadConfigWarnings(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("LLM_PROVIDER", "")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("ANTHROPIC_MODEL", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+
+	configDir := filepath.Join(tempDir, "bigdra50", "doc")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "provider = \"openia\"\nopenai_modle = \"gpt-4o\"\nanthropic_model = \"claude-9\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderrFile, err := os.CreateTemp(tempDir, "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStderr := os.Stderr
+	os.Stderr = stderrFile
+	LoadConfig()
+	os.Stderr = originalStderr
+
+	output, err := os.ReadFile(stderrFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"unknown keys", "openai_modle", `invalid provider "openia"`, `anthropic_model "claude-9"`} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("Expected warning containing %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestLoadConfigProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("LLM_PROVIDER", "")
+	t.Setenv("OPENAI_MODEL", "")
+	defer config.SetProfile("")
+
+	configDir := filepath.Join(tempDir, "bigdra50", "doc")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	base := "provider = \"openai\"\nopenai_model = \"gpt-4o\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.SetProfile("work")
+	if err := config.UpdateProfile("work", map[string]any{"provider": "anthropic", "http_timeout_seconds": 30}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The profile wins; keys it does not set come from the base config
+	cfg := LoadConfig()
+	if cfg.ProviderType != "anthropic" || cfg.HTTPTimeoutSeconds != 30 || cfg.OpenAIModel != "gpt-4o" {
+		t.Errorf("Expected profile over base config, got provider=%s timeout=%d openai_model=%s",
+			cfg.ProviderType, cfg.HTTPTimeoutSeconds, cfg.OpenAIModel)
+	}
+
+	if err := config.UpdateProfile("work", nil, []string{"provider"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := LoadConfig(); cfg.ProviderType != "openai" || cfg.HTTPTimeoutSeconds != 30 {
+		t.Errorf("Expected unset provider to fall back to base config, got provider=%s timeout=%d",
+			cfg.ProviderType, cfg.HTTPTimeoutSeconds)
+	}
+
+	profiles, err := config.ListProfiles()
+	if err != nil || len(profiles) != 1 || profiles[0] != "work" {
+		t.Errorf("ListProfiles() = %v, %v; want [work]", profiles, err)
+	}
+}
+
+func TestMaskConfigValue(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{"openai_api_key", "x7Qz9K", "***"},
+		{"anthropic_api_key", "x7Qz9K", "***"},
+		{"gemini_api_key", "x7Qz9K", "***"},
+		{"openai_api_key", "", "(not set)"},
+		{"openai_api_key", "sk-1234567890abcdef", "sk-1...cdef"},
+		{"openai_model", "gpt-4o", "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		if result := maskConfigValue(tt.key, tt.value); result != tt.expected {
+			t.Errorf("maskConfigValue(%q, %q) = %q, want %q", tt.key, tt.value, result, tt.expected)
+		}
+	}
+}
+
+func TestShowCurrentConfigMasksShortKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("DOC_CONFIG", "")
+	t.Setenv("OPENAI_API_KEY_FILE", "")
+	t.Setenv("ANTHROPIC_API_KEY_FILE", "")
+
+	const key = "x7Qz9K" // A 6-character test stub
+	t.Setenv("OPENAI_API_KEY", key)
+	t.Setenv("ANTHROPIC_API_KEY", key)
+
+	stdoutFile, err := os.CreateTemp(tempDir, "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStdout := os.Stdout
+	os.Stdout = stdoutFile
+	t.Cleanup(func() { os.Stdout = originalStdout })
+	showCurrentConfig()
+	os.Stdout = originalStdout
+
+	output, err := os.ReadFile(stdoutFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(output), key) {
+		t.Errorf("Expected the API keys to be masked, got:\n%s", output)
+	}
+	for _, want := range []string{`openai_api_key = "***"`, `anthropic_api_key = "***"`} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("Expected %s in the configuration, got:\n%s", want, output)
+		}
+	}
+}
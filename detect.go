@@ -0,0 +1,178 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// languageSampleSize is the number of characters of prose sampled to detect a document's language
+const languageSampleSize = 2000
+
+// minDetectionLetters is the minimum number of letters needed before a guess is trusted
+const minDetectionLetters = 40
+
+var (
+	inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+	urlPattern        = regexp.MustCompile(`\]\([^)]*\)|https?://\S+`)
+)
+
+// scriptLanguages maps scripts used by a single supported language to that language
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	code   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Tamil, "ta"},
+	{unicode.Telugu, "te"},
+	{unicode.Thai, "th"},
+	{unicode.Ethiopic, "am"},
+}
+
+// stopWords lists very common words of Latin-script languages, used to tell them apart
+var stopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "this", "are", "be", "it", "on", "as"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "es", "por", "para", "con", "una", "del", "se"},
+	"fr": {"le", "la", "les", "des", "et", "est", "que", "une", "pour", "dans", "du", "en", "pas", "sur", "avec"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "den", "zu", "von", "für", "auf", "sich"},
+	"it": {"il", "la", "di", "che", "e", "è", "per", "un", "una", "con", "non", "del", "della", "sono", "gli"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "é", "para", "com", "um", "uma", "não", "do", "da"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "met", "voor", "niet", "zijn", "in", "die"},
+}
+
+// detectLanguage guesses the language of a document from a sample of its prose using scripts and
+// stop words. It returns "" when the language cannot be determined with reasonable confidence.
+func detectLanguage(content string) string {
+	sample := proseSample(content, languageSampleSize)
+
+	var letters, latin, kana, han, cyrillic, arabic int
+	scriptCounts := make([]int, len(scriptLanguages))
+	for _, r := range sample {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		default:
+			for i, sl := range scriptLanguages {
+				if unicode.Is(sl.script, r) {
+					scriptCounts[i]++
+				}
+			}
+		}
+	}
+
+	if letters < minDetectionLetters {
+		return ""
+	}
+
+	// CJK text often embeds Latin technical terms, so a smaller share is enough
+	if kana > 0 && (kana+han)*10 >= letters*3 {
+		return "ja"
+	}
+	if han*10 >= letters*3 {
+		return "zh"
+	}
+	for i, sl := range scriptLanguages {
+		if scriptCounts[i]*10 >= letters*3 {
+			return sl.code
+		}
+	}
+	if arabic*10 >= letters*3 {
+		// Arabic, Persian and Urdu share the script; the latter two add letters of their own
+		switch {
+		case strings.ContainsAny(sample, "ٹڈڑںے"):
+			return "ur"
+		case strings.ContainsAny(sample, "پچژگ"):
+			return "fa"
+		}
+		return "ar"
+	}
+	if cyrillic*2 >= letters {
+		// Russian, Ukrainian, Serbian and Bulgarian share the script; tell them apart by the
+		// letters only one of them uses
+		lower := strings.ToLower(sample)
+		switch {
+		case strings.ContainsAny(lower, "ыэ"):
+			return "ru"
+		case strings.ContainsAny(lower, "іїєґ"):
+			return "uk"
+		case strings.ContainsAny(lower, "ђћџљњј"):
+			return "sr"
+		}
+		return ""
+	}
+
+	if latin*10 >= letters*8 {
+		return detectLatinLanguage(sample)
+	}
+	return ""
+}
+
+// detectLatinLanguage picks the Latin-script language whose stop words are most frequent in sample
+func detectLatinLanguage(sample string) string {
+	words := strings.FieldsFunc(strings.ToLower(sample), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for code, list := range stopWords {
+		score := 0
+		for _, word := range words {
+			for _, stop := range list {
+				if word == stop {
+					score++
+					break
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore, secondScore = code, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+
+	// Require stop words to be common and clearly ahead of the runner-up (e.g. Spanish vs Portuguese)
+	if bestScore*100 < len(words)*15 || bestScore*10 < secondScore*13 {
+		return ""
+	}
+	return best
+}
+
+// proseSample returns up to size characters of content with fenced code, inline code and URLs removed
+func proseSample(content string, size int) string {
+	var sb strings.Builder
+	var fence codeFenceTracker
+	for _, line := range strings.Split(content, "\n") {
+		if fence.inCode(line) {
+			continue
+		}
+		line = inlineCodePattern.ReplaceAllString(line, " ")
+		line = urlPattern.ReplaceAllString(line, " ")
+		sb.WriteString(line + "\n")
+	}
+
+	sample := []rune(sb.String())
+	if len(sample) > size {
+		sample = sample[:size]
+	}
+	return string(sample)
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "English",
+			content:  "# Getting Started\n\nThis guide explains how to install the tool and use it with your documents. It is the best place to start.",
+			expected: "en",
+		},
+		{
+			name:     "Japanese with Latin terms",
+			content:  "# はじめに\n\nこのガイドでは `doc` コマンドのインストール方法と、Markdown ドキュメントでの使い方を説明します。API キーを設定してください。",
+			expected: "ja",
+		},
+		{
+			name:     "Chinese",
+			content:  "# 入门指南\n\n本指南介绍如何安装该工具以及如何在文档中使用它。这是开始使用的最佳位置，请仔细阅读每一个步骤并按照说明进行配置。",
+			expected: "zh",
+		},
+		{
+			name:     "Korean",
+			content:  "# 시작하기\n\n이 가이드는 도구를 설치하고 문서에서 사용하는 방법을 설명합니다. 처음 시작하기에 가장 좋은 곳입니다.",
+			expected: "ko",
+		},
+		{
+			name:     "Russian",
+			content:  "# Начало работы\n\nЭто руководство объясняет, как установить инструмент и использовать его с вашими документами. Вы быстро всё поймёте.",
+			expected: "ru",
+		},
+		{
+			name:     "Ukrainian",
+			content:  "# Початок роботи\n\nЦей посібник пояснює, як встановити інструмент і використовувати його з вашими документами. Це найкраще місце для початку.",
+			expected: "uk",
+		},
+		{
+			name:     "Arabic",
+			content:  "# البدء\n\nيشرح هذا الدليل كيفية تثبيت الأداة واستخدامها مع مستنداتك. إنه أفضل مكان للبدء.",
+			expected: "ar",
+		},
+		{
+			name:     "Persian",
+			content:  "# شروع به کار\n\nاین راهنما چگونگی نصب ابزار و استفاده از آن را با اسناد شما توضیح می‌دهد. این بهترین جا برای شروع است.",
+			expected: "fa",
+		},
+		{
+			name:     "Urdu",
+			content:  "# شروع کرنا\n\nیہ گائیڈ بتاتی ہے کہ ٹول کو کیسے انسٹال کریں اور اپنی دستاویزات کے ساتھ استعمال کریں۔",
+			expected: "ur",
+		},
+		{
+			name:     "Bengali",
+			content:  "# শুরু করা\n\nএই নির্দেশিকা ব্যাখ্যা করে কিভাবে টুলটি ইনস্টল করতে হয় এবং আপনার নথিতে এটি ব্যবহার করতে হয়।",
+			expected: "bn",
+		},
+		{
+			name:     "German",
+			content:  "# Erste Schritte\n\nDiese Anleitung erklärt, wie man das Werkzeug installiert und mit den Dokumenten verwendet. Es ist nicht schwer, und die Schritte sind einfach.",
+			expected: "de",
+		},
+		{
+			name:     "French",
+			content:  "# Premiers pas\n\nCe guide explique comment installer l'outil et comment l'utiliser avec vos documents. Il est le meilleur point de départ pour les nouveaux utilisateurs.",
+			expected: "fr",
+		},
+		{
+			name:     "Code only",
+			content:  "```go\nfunc main() {\n\tfmt.Println(\"the and of to is in that for with this\")\n}\n```",
+			expected: "",
+		},
+		{
+			name:     "Too short",
+			content:  "Hello",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguage(tt.content); got != tt.expected {
+				t.Errorf("detectLanguage() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
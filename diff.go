@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// diffOp represents a single line in an edit script
+type diffOp struct {
+	Kind byte // ' ' for equal, '-' for deletion, '+' for insertion
+	Text string
+}
+
+// splitDiffLines splits content into lines, ignoring the final newline
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines computes a minimal line edit script from a to b using the Myers algorithm
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Backtrack through the trace to recover the edit script
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{Kind: ' ', Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{Kind: '+', Text: b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{Kind: '-', Text: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{Kind: ' ', Text: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff returns a unified diff between two contents, or an empty string if they are equal
+func unifiedDiff(fromName, toName, from, to string) string {
+	ops := diffLines(splitDiffLines(from), splitDiffLines(to))
+
+	// Collect the op index ranges that make up each hunk
+	type hunkRange struct{ start, end int }
+	var hunks []hunkRange
+	for i, op := range ops {
+		if op.Kind == ' ' {
+			continue
+		}
+		start := max(i-diffContextLines, 0)
+		end := min(i+diffContextLines+1, len(ops))
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunkRange{start, end})
+		}
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Track line numbers in both files while walking ops
+	aLine, bLine, pos := 1, 1, 0
+	for _, h := range hunks {
+		for ; pos < h.start; pos++ {
+			aLine++
+			bLine++
+		}
+
+		aStart, bStart := aLine, bLine
+		aCount, bCount := 0, 0
+		var body strings.Builder
+		for ; pos < h.end; pos++ {
+			op := ops[pos]
+			body.WriteByte(op.Kind)
+			body.WriteString(op.Text)
+			body.WriteByte('\n')
+			switch op.Kind {
+			case ' ':
+				aCount++
+				bCount++
+				aLine++
+				bLine++
+			case '-':
+				aCount++
+				aLine++
+			case '+':
+				bCount++
+				bLine++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", formatHunkRange(aStart, aCount), formatHunkRange(bStart, bCount))
+		sb.WriteString(body.String())
+	}
+
+	return sb.String()
+}
+
+// formatHunkRange formats a hunk range as used in unified diff headers
+func formatHunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
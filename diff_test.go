@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "Identical content",
+			from:     "a\nb\nc\n",
+			to:       "a\nb\nc\n",
+			expected: "",
+		},
+		{
+			name:     "Changed line",
+			from:     "a\nb\nc\n",
+			to:       "a\nB\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "Added line to empty file",
+			from:     "",
+			to:       "a\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:     "Separate hunks",
+			from:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			to:       "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			expected: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := unifiedDiff("old", "new", tt.from, tt.to)
+			if result != tt.expected {
+				t.Errorf("unifiedDiff() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "errors"
+
+// Process exit codes, so that scripts can tell classes of failures apart
+const (
+	exitFailure    = 1 // Any other failure, e.g. an unreadable or existing output file
+	exitUsage      = 2 // Invalid command line arguments
+	exitConfig     = 3 // Missing or invalid configuration, e.g. no API key
+	exitProvider   = 4 // The translation provider or its API failed
+	exitEmptyInput = 5 // No document was provided, or it was empty
+)
+
+// exitCodeError attaches a process exit code to an error
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode marks err to end the process with code
+func withExitCode(code int, err error) error {
+	return &exitCodeError{code: code, err: err}
+}
+
+// exitCode returns the process exit code for err, exitFailure if none was attached
+func exitCode(err error) int {
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Plain error", errors.New("boom"), exitFailure},
+		{"Marked error", withExitCode(exitConfig, errors.New("no API key")), exitConfig},
+		{"Wrapped marked error", fmt.Errorf("translate-dir: %w", withExitCode(exitProvider, errors.New("timeout"))), exitProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.err); code != tt.expected {
+				t.Errorf("exitCode() = %d, want %d", code, tt.expected)
+			}
+		})
+	}
+
+	if _, err := readDocumentFrom(strings.NewReader("  \n"), "stdin"); exitCode(err) != exitEmptyInput {
+		t.Errorf("Expected empty document to exit with %d, got %d (%v)", exitEmptyInput, exitCode(err), err)
+	}
+}
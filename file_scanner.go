@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -15,14 +16,32 @@ type MarkdownFile struct {
 	Name    string
 	ModTime time.Time
 	Size    int64
+	Root    string // Directory the file was scanned from
+
+	// HeadingRenames maps heading text to its replacement, set by --dedupe-anchors
+	HeadingRenames map[string]string
+
+	// Content holds the file's bytes once loaded by loadFileContents, so that the merge passes
+	// share a single read
+	Content []byte
 }
 
+// DefaultMarkdownExtensions lists the file extensions treated as markdown when none are configured
+var DefaultMarkdownExtensions = []string{".md", ".markdown", ".mdown", ".mkd"}
+
 // FileScanner handles scanning directories for markdown files
 type FileScanner struct {
-	Directory       string
-	Recursive       bool
-	IncludePatterns []string
-	ExcludePatterns []string
+	Directory        string
+	Recursive        bool
+	IncludePatterns  []string
+	ExcludePatterns  []string
+	ExcludeDirs      []string // Patterns for subdirectories skipped entirely during a recursive scan
+	RespectGitignore bool     // Skip paths ignored by the repository's .gitignore files
+	Extensions       []string // Markdown file extensions (default: DefaultMarkdownExtensions)
+	MaxFileSize      int64    // Files larger than this many bytes are skipped; 0 means unlimited
+
+	// Oversized lists the files skipped for exceeding MaxFileSize, filled in by ScanMarkdownFiles
+	Oversized []MarkdownFile
 }
 
 // ScanMarkdownFiles scans the directory and returns markdown files
@@ -33,6 +52,12 @@ func (fs *FileScanner) ScanMarkdownFiles() ([]MarkdownFile, error) {
 	}
 
 	var files []MarkdownFile
+	fs.Oversized = nil
+
+	var gitignore *gitignoreMatcher
+	if fs.RespectGitignore {
+		gitignore = newGitignoreMatcher(fs.Directory)
+	}
 
 	walkFunc := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -45,11 +70,24 @@ func (fs *FileScanner) ScanMarkdownFiles() ([]MarkdownFile, error) {
 			if !fs.Recursive && path != fs.Directory {
 				return filepath.SkipDir
 			}
+			if path != fs.Directory && fs.isExcludedDir(path) {
+				return filepath.SkipDir
+			}
+			if gitignore != nil {
+				if path != fs.Directory && (info.Name() == ".git" || gitignore.ignored(path, true)) {
+					return filepath.SkipDir
+				}
+				gitignore.loadDir(path)
+			}
 			return nil
 		}
 
 		// Check if it's a markdown file
-		if !strings.HasSuffix(strings.ToLower(info.Name()), ".md") {
+		if !fs.hasMarkdownExtension(info.Name()) {
+			return nil
+		}
+
+		if gitignore != nil && gitignore.ignored(path, false) {
 			return nil
 		}
 
@@ -74,12 +112,18 @@ func (fs *FileScanner) ScanMarkdownFiles() ([]MarkdownFile, error) {
 			}
 		}
 
-		files = append(files, MarkdownFile{
+		file := MarkdownFile{
 			Path:    path,
 			Name:    info.Name(),
 			ModTime: info.ModTime(),
 			Size:    info.Size(),
-		})
+			Root:    fs.Directory,
+		}
+		if fs.MaxFileSize > 0 && file.Size > fs.MaxFileSize {
+			fs.Oversized = append(fs.Oversized, file)
+			return nil
+		}
+		files = append(files, file)
 
 		return nil
 	}
@@ -91,40 +135,202 @@ func (fs *FileScanner) ScanMarkdownFiles() ([]MarkdownFile, error) {
 	return files, nil
 }
 
-// SortMarkdownFiles sorts markdown files based on the specified order
-func SortMarkdownFiles(files []MarkdownFile, order string) []MarkdownFile {
+// isExcludedDir reports whether a subdirectory matches an ExcludeDirs pattern, either by its name
+// or by its slash-separated path relative to the scanned directory (e.g. "guide/drafts")
+func (fs *FileScanner) isExcludedDir(path string) bool {
+	relPath, err := filepath.Rel(fs.Directory, path)
+	if err != nil {
+		relPath = path
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	for _, pattern := range fs.ExcludeDirs {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if matchPattern(filepath.Base(path), pattern) || matchPattern(relPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMarkdownExtension reports whether name has one of the scanner's markdown extensions
+func (fs *FileScanner) hasMarkdownExtension(name string) bool {
+	extensions := fs.Extensions
+	if len(extensions) == 0 {
+		extensions = DefaultMarkdownExtensions
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, allowed := range extensions {
+		if ext == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// SortMarkdownFiles sorts markdown files based on the specified order.
+// When reverse is set the result is returned in descending order; it is ignored
+// for the custom order, where .docorder already defines the sequence.
+func SortMarkdownFiles(files []MarkdownFile, order string, reverse bool) []MarkdownFile {
 	sorted := make([]MarkdownFile, len(files))
 	copy(sorted, files)
 
+	// Ties fall back to the filename and then the path, so equal keys sort the same on every run
 	switch order {
-	case "filename":
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].Name < sorted[j].Name
+	case "numeric":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if a, b := sorted[i].Name, sorted[j].Name; naturalLess(a, b) != naturalLess(b, a) {
+				return naturalLess(a, b)
+			}
+			return fileNameLess(sorted[i], sorted[j])
 		})
 	case "modified":
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].ModTime.Before(sorted[j].ModTime)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if !sorted[i].ModTime.Equal(sorted[j].ModTime) {
+				return sorted[i].ModTime.Before(sorted[j].ModTime)
+			}
+			return fileNameLess(sorted[i], sorted[j])
 		})
 	case "size":
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].Size < sorted[j].Size
-		})
-	case "custom":
-		// TODO: Implement custom ordering based on .docorder file
-		// For now, fallback to filename
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].Name < sorted[j].Name
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].Size != sorted[j].Size {
+				return sorted[i].Size < sorted[j].Size
+			}
+			return fileNameLess(sorted[i], sorted[j])
 		})
 	default:
-		// Default to filename ordering
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].Name < sorted[j].Name
+		// Filename ordering, also the base of the custom order where ApplyDocOrder moves listed
+		// files to the front
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return fileNameLess(sorted[i], sorted[j])
 		})
 	}
 
+	if reverse && order != "custom" {
+		slices.Reverse(sorted)
+	}
 	return sorted
 }
 
+// fileNameLess orders files by name, then by path for files of the same name in different
+// subdirectories
+func fileNameLess(a, b MarkdownFile) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Path < b.Path
+}
+
+// naturalLess compares two names with embedded integer runs ordered numerically,
+// so "chapter2.md" sorts before "chapter10.md"
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare digit runs by value: ignore leading zeros, then compare by length and digits
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	// Names equal up to numeric value (e.g. "01" vs "1"): fall back to plain ordering
+	return a < b
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// docOrderFile is the file in the merge directory that lists files for --order custom
+const docOrderFile = ".docorder"
+
+// ReadDocOrder reads the .docorder file in dir and returns the listed file names.
+// Blank lines and lines starting with # are ignored.
+func ReadDocOrder(dir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, docOrderFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("custom order requires a %s file in %s", docOrderFile, dir)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", docOrderFile, err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+
+	return names, nil
+}
+
+// ApplyDocOrder orders files by their position in the .docorder list.
+// Entries match a file's path relative to dir or its name; unlisted files keep
+// their existing order after the listed ones, and listed names without a file are skipped.
+func ApplyDocOrder(files []MarkdownFile, dir string, order []string) []MarkdownFile {
+	used := make([]bool, len(files))
+	var ordered []MarkdownFile
+
+	for _, name := range order {
+		name = filepath.ToSlash(filepath.Clean(name))
+		found := false
+		for i, file := range files {
+			if used[i] {
+				continue
+			}
+			relPath, err := filepath.Rel(dir, file.Path)
+			if err != nil {
+				relPath = file.Name
+			}
+			if filepath.ToSlash(relPath) == name || file.Name == name {
+				ordered = append(ordered, file)
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			log("Warning: %s lists %s, but no such file was found; skipping", docOrderFile, name)
+		}
+	}
+
+	for i, file := range files {
+		if !used[i] {
+			ordered = append(ordered, file)
+		}
+	}
+
+	return ordered
+}
+
 // matchPattern matches a filename against a pattern
 func matchPattern(filename, pattern string) bool {
 	matched, err := filepath.Match(pattern, filename)
@@ -133,4 +339,4 @@ func matchPattern(filename, pattern string) bool {
 		return false
 	}
 	return matched
-}
\ No newline at end of file
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"sort"
 	"testing"
 	"time"
@@ -19,12 +21,16 @@ func TestScanMarkdownFiles(t *testing.T) {
 
 	// Create test files
 	testFiles := map[string]string{
-		"chapter1.md":       "# Chapter 1\nContent 1",
-		"chapter2.md":       "# Chapter 2\nContent 2", 
-		"README.md":         "# README\nReadme content",
-		"notes.txt":         "Not a markdown file",
+		"chapter1.md":        "# Chapter 1\nContent 1",
+		"chapter2.md":        "# Chapter 2\nContent 2",
+		"README.md":          "# README\nReadme content",
+		"notes.txt":          "Not a markdown file",
+		"guide.markdown":     "# Guide\nGuide content",
 		"subdir/chapter3.md": "# Chapter 3\nContent 3",
-		"subdir/notes.md":   "# Notes\nNotes content",
+		"subdir/notes.md":    "# Notes\nNotes content",
+		"drafts/wip.md":      "# WIP\nDraft content",
+		"drafts/old/v1.md":   "# V1\nOld draft",
+		"subdir/drafts/x.md": "# X\nNested draft",
 	}
 
 	for path, content := range testFiles {
@@ -39,31 +45,57 @@ func TestScanMarkdownFiles(t *testing.T) {
 	}
 
 	tests := []struct {
-		name      string
-		directory string
-		recursive bool
-		includes  []string
-		excludes  []string
-		expected  []string
-		wantErr   bool
+		name       string
+		directory  string
+		recursive  bool
+		includes   []string
+		excludes   []string
+		excludeDir []string
+		extensions []string
+		maxSize    int64
+		expected   []string
+		wantErr    bool
 	}{
 		{
 			name:      "Basic scan",
 			directory: tempDir,
 			recursive: false,
-			expected:  []string{"chapter1.md", "chapter2.md", "README.md"},
+			expected:  []string{"chapter1.md", "chapter2.md", "guide.markdown", "README.md"},
 		},
 		{
 			name:      "Recursive scan",
 			directory: tempDir,
 			recursive: true,
-			expected:  []string{"chapter1.md", "chapter2.md", "README.md", "subdir/chapter3.md", "subdir/notes.md"},
+			expected: []string{"chapter1.md", "chapter2.md", "guide.markdown", "README.md", "subdir/chapter3.md", "subdir/notes.md",
+				"drafts/wip.md", "drafts/old/v1.md", "subdir/drafts/x.md"},
+		},
+		{
+			name:       "Exclude directory by name",
+			directory:  tempDir,
+			recursive:  true,
+			excludeDir: []string{"drafts"},
+			expected:   []string{"chapter1.md", "chapter2.md", "guide.markdown", "README.md", "subdir/chapter3.md", "subdir/notes.md"},
+		},
+		{
+			name:       "Exclude directory by path",
+			directory:  tempDir,
+			recursive:  true,
+			excludeDir: []string{"subdir/drafts/"},
+			expected: []string{"chapter1.md", "chapter2.md", "guide.markdown", "README.md", "subdir/chapter3.md", "subdir/notes.md",
+				"drafts/wip.md", "drafts/old/v1.md"},
+		},
+		{
+			name:       "Custom extensions",
+			directory:  tempDir,
+			recursive:  false,
+			extensions: []string{".markdown", ".txt"},
+			expected:   []string{"guide.markdown", "notes.txt"},
 		},
 		{
 			name:      "With exclude pattern",
 			directory: tempDir,
 			recursive: false,
-			excludes:  []string{"README.md"},
+			excludes:  []string{"README.md", "*.markdown"},
 			expected:  []string{"chapter1.md", "chapter2.md"},
 		},
 		{
@@ -73,6 +105,13 @@ func TestScanMarkdownFiles(t *testing.T) {
 			includes:  []string{"chapter*.md"},
 			expected:  []string{"chapter1.md", "chapter2.md", "subdir/chapter3.md"},
 		},
+		{
+			name:      "Max file size",
+			directory: tempDir,
+			recursive: false,
+			maxSize:   22,
+			expected:  []string{"chapter1.md", "chapter2.md", "guide.markdown"},
+		},
 		{
 			name:      "Non-existent directory",
 			directory: "/non/existent/path",
@@ -87,6 +126,9 @@ func TestScanMarkdownFiles(t *testing.T) {
 				Recursive:       tt.recursive,
 				IncludePatterns: tt.includes,
 				ExcludePatterns: tt.excludes,
+				ExcludeDirs:     tt.excludeDir,
+				Extensions:      tt.extensions,
+				MaxFileSize:     tt.maxSize,
 			}
 
 			files, err := scanner.ScanMarkdownFiles()
@@ -169,6 +211,7 @@ func TestSortMarkdownFiles(t *testing.T) {
 	tests := []struct {
 		name     string
 		sortType string
+		reverse  bool
 		expected []string
 	}{
 		{
@@ -186,12 +229,30 @@ func TestSortMarkdownFiles(t *testing.T) {
 			sortType: "size",
 			expected: []string{"z_file.md", "m_file.md", "a_file.md"},
 		},
+		{
+			name:     "Sort by filename reversed",
+			sortType: "filename",
+			reverse:  true,
+			expected: []string{"z_file.md", "m_file.md", "a_file.md"},
+		},
+		{
+			name:     "Sort by modified time reversed (newest first)",
+			sortType: "modified",
+			reverse:  true,
+			expected: []string{"m_file.md", "a_file.md", "z_file.md"},
+		},
+		{
+			name:     "Custom order ignores reverse",
+			sortType: "custom",
+			reverse:  true,
+			expected: []string{"a_file.md", "m_file.md", "z_file.md"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sorted := SortMarkdownFiles(markdownFiles, tt.sortType)
-			
+			sorted := SortMarkdownFiles(markdownFiles, tt.sortType, tt.reverse)
+
 			result := make([]string, len(sorted))
 			for i, file := range sorted {
 				result[i] = file.Name
@@ -204,6 +265,112 @@ func TestSortMarkdownFiles(t *testing.T) {
 	}
 }
 
+func TestSortMarkdownFilesTies(t *testing.T) {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var files []MarkdownFile
+	for _, path := range []string{"docs/c.md", "docs/b/a.md", "docs/a.md", "docs/b.md"} {
+		files = append(files, MarkdownFile{Path: path, Name: filepath.Base(path), ModTime: modTime, Size: 100})
+	}
+
+	// Equal sizes and times fall back to the filename, then the path, whatever the input order
+	expected := []string{"docs/a.md", "docs/b/a.md", "docs/b.md", "docs/c.md"}
+	for _, order := range []string{"size", "modified"} {
+		for range 5 {
+			shuffled := slices.Clone(files)
+			rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+			sorted := SortMarkdownFiles(shuffled, order, false)
+			result := make([]string, len(sorted))
+			for i, file := range sorted {
+				result[i] = file.Path
+			}
+			if !reflect.DeepEqual(result, expected) {
+				t.Fatalf("SortMarkdownFiles(%s) = %v, want %v", order, result, expected)
+			}
+		}
+	}
+}
+
+func TestSortMarkdownFilesNumeric(t *testing.T) {
+	var files []MarkdownFile
+	for _, name := range []string{"10.md", "2.md", "11.md", "1.md", "chapter10.md", "chapter2.md", "appendix.md"} {
+		files = append(files, MarkdownFile{Name: name})
+	}
+
+	sorted := SortMarkdownFiles(files, "numeric", false)
+
+	result := make([]string, len(sorted))
+	for i, file := range sorted {
+		result[i] = file.Name
+	}
+
+	expected := []string{"1.md", "2.md", "10.md", "11.md", "appendix.md", "chapter2.md", "chapter10.md"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("SortMarkdownFiles(numeric) = %v, want %v", result, expected)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2.md", "10.md", true},
+		{"10.md", "2.md", false},
+		{"ch1-part10.md", "ch1-part9.md", false},
+		{"01.md", "1.md", true},
+		{"a.md", "a.md", false},
+		{"intro", "intro2", true},
+	}
+
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDocOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	docOrder := "# Chapters\nintro.md\n\nguide/setup.md\nmissing.md\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".docorder"), []byte(docOrder), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := ReadDocOrder(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDocOrder() error: %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"intro.md", "guide/setup.md", "missing.md"}) {
+		t.Errorf("ReadDocOrder() = %v", order)
+	}
+
+	files := []MarkdownFile{
+		{Path: filepath.Join(tempDir, "appendix.md"), Name: "appendix.md"},
+		{Path: filepath.Join(tempDir, "guide", "setup.md"), Name: "setup.md"},
+		{Path: filepath.Join(tempDir, "intro.md"), Name: "intro.md"},
+		{Path: filepath.Join(tempDir, "zzz.md"), Name: "zzz.md"},
+	}
+
+	ordered := ApplyDocOrder(files, tempDir, order)
+
+	result := make([]string, len(ordered))
+	for i, file := range ordered {
+		result[i] = file.Name
+	}
+
+	expected := []string{"intro.md", "setup.md", "appendix.md", "zzz.md"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ApplyDocOrder() = %v, want %v", result, expected)
+	}
+}
+
+func TestReadDocOrderMissing(t *testing.T) {
+	if _, err := ReadDocOrder(t.TempDir()); err == nil {
+		t.Error("Expected error for missing .docorder file")
+	}
+}
+
 func TestMatchPattern(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -227,4 +394,4 @@ func TestMatchPattern(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// gitignoreRule is one pattern line of a .gitignore file
+type gitignoreRule struct {
+	base    string // Slash-separated directory of the .gitignore, relative to the repository root
+	pattern *regexp.Regexp
+	negate  bool // "!pattern" re-includes a path excluded by an earlier rule
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// gitignoreMatcher decides whether paths are ignored by the .gitignore files of a repository.
+// As in git, the last matching rule wins and rules in deeper directories come later.
+type gitignoreMatcher struct {
+	root  string // Repository root (or the scanned directory outside a repository)
+	rules []gitignoreRule
+}
+
+// newGitignoreMatcher returns a matcher for scanning dir. It finds the enclosing repository and
+// loads .git/info/exclude and the .gitignore files from the repository root down to dir's parent;
+// .gitignore files in dir and below are added with loadDir as the scan reaches them.
+func newGitignoreMatcher(dir string) *gitignoreMatcher {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+
+	root := absDir
+	for current := absDir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			root = current
+			break
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	m := &gitignoreMatcher{root: root}
+	m.loadFile(filepath.Join(root, ".git", "info", "exclude"), "")
+
+	// Load the parents outermost first so that deeper rules come later
+	var parents []string
+	for current := absDir; current != root; {
+		current = filepath.Dir(current)
+		parents = append(parents, current)
+	}
+	slices.Reverse(parents)
+	for _, parent := range parents {
+		m.loadDir(parent)
+	}
+
+	return m
+}
+
+// loadDir adds the rules of dir's .gitignore, if it has one
+func (m *gitignoreMatcher) loadDir(dir string) {
+	base := ""
+	if rel, err := filepath.Rel(m.root, absPath(dir)); err == nil && rel != "." {
+		base = filepath.ToSlash(rel)
+	}
+	m.loadFile(filepath.Join(dir, ".gitignore"), base)
+}
+
+// loadFile adds the rules of a gitignore-format file; a missing file has no rules
+func (m *gitignoreMatcher) loadFile(file, base string) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreLine(scanner.Text(), base); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+// ignored reports whether the file or directory at p is ignored
+func (m *gitignoreMatcher) ignored(p string, isDir bool) bool {
+	rel, err := filepath.Rel(m.root, absPath(p))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		target := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, rule.base+"/")
+		}
+
+		if rule.pattern.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// absPath returns the absolute form of p, or p itself if it cannot be resolved
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+// parseGitignoreLine parses one .gitignore line, returning false for blank lines and comments
+func parseGitignoreLine(line, base string) (gitignoreRule, bool) {
+	// Trailing spaces are ignored unless escaped
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+
+	rule := gitignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+
+	// A slash at the start or in the middle anchors the pattern to the .gitignore's directory;
+	// otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := gitignoreGlobToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	pattern, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return gitignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// gitignoreGlobToRegexp converts a gitignore glob to a regular expression: * and ? do not cross
+// directories, while ** matches any number of them
+func gitignoreGlobToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseGitignoreLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		path    string
+		isDir   bool
+		matches bool
+	}{
+		{"*.md", "docs/guide.md", false, true},
+		{"/build", "build", true, true},
+		{"/build", "docs/build", true, false},
+		{"build/", "docs/build", true, true},
+		{"build/", "build", false, false},
+		{"docs/*.md", "docs/a.md", false, true},
+		{"docs/*.md", "docs/sub/a.md", false, false},
+		{"docs/**/*.md", "docs/sub/deep/a.md", false, true},
+		{"**/generated", "a/b/generated", true, true},
+		{"vendor/**", "vendor/pkg/README.md", false, true},
+		{"draft-[0-9].md", "draft-1.md", false, true},
+		{"draft-[!0-9].md", "draft-1.md", false, false},
+		{"\\#notes.md", "#notes.md", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line+" "+tt.path, func(t *testing.T) {
+			rule, ok := parseGitignoreLine(tt.line, "")
+			if !ok {
+				t.Fatalf("parseGitignoreLine(%q) returned no rule", tt.line)
+			}
+			matches := rule.pattern.MatchString(tt.path) && (!rule.dirOnly || tt.isDir)
+			if matches != tt.matches {
+				t.Errorf("%q matching %q = %v, want %v", tt.line, tt.path, matches, tt.matches)
+			}
+		})
+	}
+
+	for _, line := range []string{"", "   ", "# comment"} {
+		if _, ok := parseGitignoreLine(line, ""); ok {
+			t.Errorf("parseGitignoreLine(%q) should not return a rule", line)
+		}
+	}
+}
+
+func TestScanMarkdownFilesRespectGitignore(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		".git/HEAD":                  "ref: refs/heads/main\n",
+		".git/notes.md":              "# Git internals\n",
+		".gitignore":                 "node_modules/\n*.gen.md\n!keep.gen.md\n",
+		"docs/.gitignore":            "/drafts\n",
+		"docs/guide.md":              "# Guide\n",
+		"docs/api.gen.md":            "# Generated\n",
+		"docs/keep.gen.md":           "# Kept\n",
+		"docs/drafts/wip.md":         "# WIP\n",
+		"docs/sub/drafts/ok.md":      "# Not anchored to docs/sub\n",
+		"node_modules/pkg/README.md": "# Vendored\n",
+	}
+	for path, content := range testFiles {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		directory string
+		respect   bool
+		expected  []string
+	}{
+		{
+			name:      "Repository root",
+			directory: tempDir,
+			respect:   true,
+			expected:  []string{"docs/guide.md", "docs/keep.gen.md", "docs/sub/drafts/ok.md"},
+		},
+		{
+			name:      "Subdirectory uses the root .gitignore",
+			directory: filepath.Join(tempDir, "docs"),
+			respect:   true,
+			expected:  []string{"docs/guide.md", "docs/keep.gen.md", "docs/sub/drafts/ok.md"},
+		},
+		{
+			name:      "Disabled",
+			directory: filepath.Join(tempDir, "docs"),
+			respect:   false,
+			expected:  []string{"docs/api.gen.md", "docs/drafts/wip.md", "docs/guide.md", "docs/keep.gen.md", "docs/sub/drafts/ok.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := &FileScanner{Directory: tt.directory, Recursive: true, RespectGitignore: tt.respect}
+			files, err := scanner.ScanMarkdownFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var paths []string
+			for _, file := range files {
+				relPath, err := filepath.Rel(tempDir, file.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, filepath.ToSlash(relPath))
+			}
+			sort.Strings(paths)
+
+			if !reflect.DeepEqual(paths, tt.expected) {
+				t.Errorf("ScanMarkdownFiles() = %v, want %v", paths, tt.expected)
+			}
+		})
+	}
+}
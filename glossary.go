@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GlossaryEntry is a term with its required translation; an empty Target means "do not translate"
+type GlossaryEntry struct {
+	Source string
+	Target string
+}
+
+// loadGlossary reads a glossary file with one source-term<TAB>target-term pair per line.
+// Blank lines and lines starting with # are ignored; a missing or empty target keeps the term untranslated.
+func loadGlossary(path string) ([]GlossaryEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open glossary: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []GlossaryEntry
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		source, target, _ := strings.Cut(line, "\t")
+		source = strings.TrimSpace(source)
+		if source == "" {
+			return nil, fmt.Errorf("%s:%d: missing source term", path, lineNum)
+		}
+		entries = append(entries, GlossaryEntry{Source: source, Target: strings.TrimSpace(target)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+
+	log("Loaded %d glossary terms from %s", len(entries), path)
+	return entries, nil
+}
+
+// glossaryInstruction formats glossary entries as a prompt section, or returns "" if there are none
+func glossaryInstruction(entries []GlossaryEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Glossary - translate these terms exactly as specified, everywhere they appear:")
+	for _, entry := range entries {
+		if entry.Target == "" {
+			fmt.Fprintf(&sb, "\n- %q: do not translate", entry.Source)
+		} else {
+			fmt.Fprintf(&sb, "\n- %q -> %q", entry.Source, entry.Target)
+		}
+	}
+	return sb.String()
+}
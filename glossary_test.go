@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadGlossary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terms.tsv")
+	content := "# term\ttranslation\nrepository\tリポジトリ\r\n\nKubernetes\t\nGo\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := loadGlossary(path)
+	if err != nil {
+		t.Fatalf("loadGlossary() error: %v", err)
+	}
+
+	expected := []GlossaryEntry{
+		{Source: "repository", Target: "リポジトリ"},
+		{Source: "Kubernetes"},
+		{Source: "Go"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("loadGlossary() = %+v, want %+v", entries, expected)
+	}
+
+	if err := os.WriteFile(path, []byte("\tオーファン\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadGlossary(path); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("Expected an error with the line number, got %v", err)
+	}
+}
+
+func TestGlossaryInPrompts(t *testing.T) {
+	options := TranslationOptions{
+		TargetLanguage: "ja",
+		Glossary: []GlossaryEntry{
+			{Source: "repository", Target: "リポジトリ"},
+			{Source: "Kubernetes"},
+		},
+	}
+
+	claude := &ClaudeCodeProvider{}
+	prompts := map[string]string{
+		"translationUserPrompt": translationUserPrompt(options, "Hello"),
+		"generatePrompt":        claude.generatePrompt(options, "Hello"),
+	}
+	for name, prompt := range prompts {
+		if !strings.Contains(prompt, `"repository" -> "リポジトリ"`) {
+			t.Errorf("%s is missing the glossary translation:\n%s", name, prompt)
+		}
+		if !strings.Contains(prompt, `"Kubernetes": do not translate`) {
+			t.Errorf("%s is missing the do-not-translate term:\n%s", name, prompt)
+		}
+	}
+
+	if glossaryInstruction(nil) != "" {
+		t.Error("Expected no glossary section without entries")
+	}
+}
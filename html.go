@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// mergeFormats lists the output formats supported by merge --format
+var mergeFormats = []string{"md", "html"}
+
+var (
+	// htmlImagePattern matches markdown images after HTML escaping
+	htmlImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+&#34;[^)]*&#34;)?\)`)
+	// htmlLinkPattern matches markdown links after HTML escaping
+	htmlLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]*)(?:\s+&#34;[^)]*&#34;)?\)`)
+	// htmlStrongPattern matches **strong** and __strong__ text
+	htmlStrongPattern = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	// htmlEmphasisPattern matches *emphasized* text
+	htmlEmphasisPattern = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	// htmlStrikethroughPattern matches ~~deleted~~ text
+	htmlStrikethroughPattern = regexp.MustCompile(`~~([^~]+)~~`)
+)
+
+// htmlStyle is the stylesheet embedded in HTML output
+const htmlStyle = `body { max-width: 48rem; margin: 2rem auto; padding: 0 1rem; font-family: system-ui, sans-serif; line-height: 1.6; }
+pre { overflow-x: auto; padding: 1rem; background: #f6f8fa; }
+code { font-family: ui-monospace, monospace; }
+blockquote { margin-left: 0; padding-left: 1rem; border-left: 4px solid #d0d7de; color: #57606a; }
+nav.toc { margin-bottom: 2rem; padding: 0.5rem 1rem; border: 1px solid #d0d7de; }
+`
+
+// htmlDocument wraps an HTML body in a standalone page with the given title
+func htmlDocument(title, body string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+%s</style>
+</head>
+<body>
+%s</body>
+</html>
+`, html.EscapeString(title), htmlStyle, body)
+}
+
+// markdownToHTML converts markdown to HTML. It is a minimal renderer covering what merged
+// documents use: headings, paragraphs, lists, block quotes, code blocks, thematic breaks, links,
+// images, emphasis and raw HTML blocks. Headings get the same anchors as the generated TOC links.
+func markdownToHTML(markdown string) string {
+	r := &htmlRenderer{seen: make(map[string]int)}
+	r.renderBlocks(strings.Split(markdown, "\n"))
+	return r.sb.String()
+}
+
+// htmlRenderer accumulates the HTML of a document along with the heading anchors used so far
+type htmlRenderer struct {
+	sb   strings.Builder
+	seen map[string]int
+}
+
+// renderBlocks renders a sequence of block-level markdown lines
+func (r *htmlRenderer) renderBlocks(lines []string) {
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+		case isFenceLine(trimmed):
+			i = r.renderCodeBlock(lines, i)
+		case setextLevel(lines, i) > 0:
+			r.renderHeading(setextLevel(lines, i), trimmed)
+			i += 2
+		case atxHeadingLevel(trimmed) > 0:
+			level := atxHeadingLevel(trimmed)
+			r.renderHeading(level, strings.TrimSpace(trimmed[level:]))
+			i++
+		case isThematicBreak(trimmed):
+			r.sb.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, "<"):
+			// Raw HTML, such as comments and page-break markers, runs until a blank line
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				r.sb.WriteString(lines[i] + "\n")
+			}
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				line := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(line, " "))
+			}
+			r.sb.WriteString("<blockquote>\n")
+			r.renderBlocks(quote)
+			r.sb.WriteString("</blockquote>\n")
+		case listItemPattern.MatchString(lines[i]):
+			i = r.renderList(lines, i)
+		default:
+			i = r.renderParagraph(lines, i)
+		}
+	}
+}
+
+// isFenceLine reports whether a trimmed line opens or closes a fenced code block
+func isFenceLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// atxHeadingLevel returns the level of an ATX heading line, or 0 if trimmed is not one.
+// Like extractHeaders, it does not require a space after the #s.
+func atxHeadingLevel(trimmed string) int {
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level == 0 || level > 6 || strings.TrimSpace(trimmed[level:]) == "" {
+		return 0
+	}
+	return level
+}
+
+// startsBlock reports whether line begins a block other than a paragraph
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return isFenceLine(trimmed) || atxHeadingLevel(trimmed) > 0 || isThematicBreak(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "<") || listItemPattern.MatchString(line)
+}
+
+// renderHeading writes a heading with its anchor
+func (r *htmlRenderer) renderHeading(level int, text string) {
+	fmt.Fprintf(&r.sb, "<h%d id=\"%s\">%s</h%d>\n", level, slugify(text, r.seen), renderInline(text), level)
+}
+
+// renderCodeBlock writes the fenced code block starting at lines[start] and returns the index
+// of the line after it
+func (r *htmlRenderer) renderCodeBlock(lines []string, start int) int {
+	opening := strings.TrimSpace(lines[start])
+	marker := opening[:3]
+	indent := len(lines[start]) - len(strings.TrimLeft(lines[start], " "))
+
+	class := ""
+	if info := strings.Fields(strings.TrimLeft(opening, marker[:1])); len(info) > 0 {
+		class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(info[0]))
+	}
+
+	i := start + 1
+	var code []string
+	for ; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), marker); i++ {
+		line := lines[i]
+		strip := min(indent, len(line)-len(strings.TrimLeft(line, " ")))
+		code = append(code, line[strip:])
+	}
+
+	fmt.Fprintf(&r.sb, "<pre><code%s>", class)
+	for _, line := range code {
+		r.sb.WriteString(html.EscapeString(line) + "\n")
+	}
+	r.sb.WriteString("</code></pre>\n")
+	return i + 1
+}
+
+// renderParagraph writes the paragraph starting at lines[start] and returns the index of the
+// line after it
+func (r *htmlRenderer) renderParagraph(lines []string, start int) int {
+	i := start
+	var text []string
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || (i > start && startsBlock(lines[i])) {
+			break
+		}
+		// Two trailing spaces make a hard line break
+		line := renderInline(strings.TrimSpace(lines[i]))
+		if strings.HasSuffix(lines[i], "  ") {
+			line += "<br>"
+		}
+		text = append(text, line)
+	}
+
+	r.sb.WriteString("<p>" + strings.Join(text, "\n") + "</p>\n")
+	return i
+}
+
+// listItem is one item of a list, with its lines relative to the item's content column
+type listItem struct {
+	column int // Width of the item's marker, including indentation and the following spaces
+	lines  []string
+}
+
+// renderList writes the list starting at lines[start], including nested lists, and returns the
+// index of the line after it
+func (r *htmlRenderer) renderList(lines []string, start int) int {
+	first := listItemPattern.FindStringSubmatch(lines[start])
+	indent := len(lines[start]) - len(strings.TrimLeft(lines[start], " \t"))
+	ordered := first[1] != "-" && first[1] != "*" && first[1] != "+"
+
+	var items []listItem
+	var fence codeFenceTracker
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		if fence.marker == "" && strings.TrimSpace(line) != "" {
+			if match := listItemPattern.FindString(line); match != "" && lineIndent <= indent {
+				// A sibling item, unless the marker type changes
+				marker := listItemPattern.FindStringSubmatch(line)[1]
+				if lineIndent < indent || (marker != "-" && marker != "*" && marker != "+") != ordered {
+					break
+				}
+				items = append(items, listItem{column: len(match), lines: []string{line[len(match):]}})
+				fence.inCode(line[len(match):])
+				continue
+			}
+			if lineIndent <= indent && (strings.TrimSpace(lines[i-1]) == "" || startsBlock(line)) {
+				break
+			}
+		}
+		if strings.TrimSpace(line) == "" && fence.marker == "" && !continuesList(lines, i, indent) {
+			break
+		}
+
+		fence.inCode(line)
+		current := &items[len(items)-1]
+		current.lines = append(current.lines, line[min(lineIndent, current.column):])
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	if n := strings.TrimRight(first[1], ".)"); ordered && n != "1" {
+		fmt.Fprintf(&r.sb, "<ol start=\"%s\">\n", n)
+	} else {
+		fmt.Fprintf(&r.sb, "<%s>\n", tag)
+	}
+	for _, item := range items {
+		r.renderListItem(item)
+	}
+	fmt.Fprintf(&r.sb, "</%s>\n", tag)
+	return i
+}
+
+// continuesList reports whether the list goes on after the blank line at lines[n], i.e. the next
+// non-blank line is indented past the list's markers or is another item at the same level
+func continuesList(lines []string, n, indent int) bool {
+	for _, line := range lines[n+1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		return lineIndent > indent || (lineIndent == indent && listItemPattern.MatchString(line))
+	}
+	return false
+}
+
+// renderListItem writes one list item: its leading text inline, and anything after it, such as a
+// nested list or code block, as blocks
+func (r *htmlRenderer) renderListItem(item listItem) {
+	n := 0
+	var text []string
+	for ; n < len(item.lines); n++ {
+		line := item.lines[n]
+		if strings.TrimSpace(line) == "" || startsBlock(line) {
+			break
+		}
+		text = append(text, renderInline(strings.TrimSpace(line)))
+	}
+
+	r.sb.WriteString("<li>" + strings.Join(text, "\n"))
+	if rest := item.lines[n:]; strings.TrimSpace(strings.Join(rest, "")) != "" {
+		r.sb.WriteString("\n")
+		r.renderBlocks(rest)
+	}
+	r.sb.WriteString("</li>\n")
+}
+
+// renderInline converts the inline markdown of one line of text to HTML
+func renderInline(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, span := range inlineCodePattern.FindAllStringIndex(text, -1) {
+		sb.WriteString(renderInlineText(text[last:span[0]]))
+		sb.WriteString("<code>" + html.EscapeString(strings.Trim(text[span[0]:span[1]], "`")) + "</code>")
+		last = span[1]
+	}
+	sb.WriteString(renderInlineText(text[last:]))
+	return sb.String()
+}
+
+// renderInlineText converts links, images and emphasis in text without code spans
+func renderInlineText(text string) string {
+	text = html.EscapeString(text)
+	text = htmlImagePattern.ReplaceAllString(text, `<img src="$2" alt="$1">`)
+	text = htmlLinkPattern.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = htmlStrongPattern.ReplaceAllString(text, `<strong>$1$2</strong>`)
+	text = htmlEmphasisPattern.ReplaceAllString(text, `<em>$1</em>`)
+	return htmlStrikethroughPattern.ReplaceAllString(text, `<del>$1</del>`)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Heading with anchor", "## Getting Started", "<h2 id=\"getting-started\">Getting Started</h2>\n"},
+		{"Repeated headings", "## Setup\n\n## Setup", "<h2 id=\"setup\">Setup</h2>\n<h2 id=\"setup-1\">Setup</h2>\n"},
+		{"Setext heading", "Title\n=====", "<h1 id=\"title\">Title</h1>\n"},
+		{"Paragraph", "One\ntwo", "<p>One\ntwo</p>\n"},
+		{"Inline markup", "A **b** *c* `<d>` [e](f.html)", "<p>A <strong>b</strong> <em>c</em> <code>&lt;d&gt;</code> <a href=\"f.html\">e</a></p>\n"},
+		{"Escaping", "a < b & c", "<p>a &lt; b &amp; c</p>\n"},
+		{"Code block", "```sh\necho <hi>\n```", "<pre><code class=\"language-sh\">echo &lt;hi&gt;\n</code></pre>\n"},
+		{"Nested list", "- a\n  - b\n- c", "<ul>\n<li>a\n<ul>\n<li>b</li>\n</ul>\n</li>\n<li>c</li>\n</ul>\n"},
+		{"Ordered list", "3. a\n4. b", "<ol start=\"3\">\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"Block quote", "> quoted", "<blockquote>\n<p>quoted</p>\n</blockquote>\n"},
+		{"Thematic break", "---", "<hr>\n"},
+		{"Raw HTML", "<!-- Source: a.md -->", "<!-- Source: a.md -->\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownToHTML(tt.input); got != tt.expected {
+				t.Errorf("markdownToHTML(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatMergeOutputHTML(t *testing.T) {
+	cliArgs := &CLIArgs{MergeFormat: "html", MergeTitle: "Guide & Notes"}
+	output := formatMergeOutput(cliArgs, nil, "# Guide & Notes\n\n<nav class=\"toc\">\n\n## Table of Contents\n\n- [Install](#install)\n\n</nav>\n\n## Install\n")
+
+	for _, want := range []string{
+		"<title>Guide &amp; Notes</title>",
+		"<nav class=\"toc\">\n<h2 id=\"table-of-contents\">Table of Contents</h2>\n<ul>\n<li><a href=\"#install\">Install</a></li>\n</ul>\n</nav>\n",
+		"<h2 id=\"install\">Install</h2>",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected HTML output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if got := formatMergeOutput(&CLIArgs{}, nil, "# Doc\n"); got != "# Doc\n" {
+		t.Errorf("Expected markdown output unchanged, got %q", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -18,6 +20,10 @@ type Config struct {
 	OpenAIAPIKey    string `toml:"openai_api_key"`
 	AnthropicAPIKey string `toml:"anthropic_api_key"`
 
+	// API key files (Docker-secret style); take precedence over inline keys
+	OpenAIAPIKeyFile    string `toml:"openai_api_key_file"`
+	AnthropicAPIKeyFile string `toml:"anthropic_api_key_file"`
+
 	// Claude Code CLI path
 	ClaudeCodePath string `toml:"claude_code_path"`
 
@@ -25,18 +31,76 @@ type Config struct {
 	OpenAIModel    string `toml:"openai_model"`
 	AnthropicModel string `toml:"anthropic_model"`
 	ClaudeModel    string `toml:"claude_model"`
+	OllamaModel    string `toml:"ollama_model"`
+
+	// Base URL of the OpenAI API or an OpenAI-compatible gateway
+	OpenAIBaseURL string `toml:"openai_base_url"`
+
+	// Azure OpenAI resource; when azure_endpoint is set, the openai provider calls the
+	// deployment instead of the OpenAI API, authenticating with openai_api_key
+	AzureEndpoint   string `toml:"azure_endpoint"`
+	AzureDeployment string `toml:"azure_deployment"`
+	AzureAPIVersion string `toml:"azure_api_version"`
+
+	// Base URL of the local Ollama server
+	OllamaBaseURL string `toml:"ollama_base_url"`
+
+	// Retries after rate limits (429) and server errors (5xx) from the OpenAI API
+	OpenAIMaxRetries int `toml:"openai_max_retries"`
+
+	// Timeout for HTTP API requests, in seconds
+	HTTPTimeoutSeconds int `toml:"http_timeout_seconds"`
+
+	// Typographic post-processing, opted in per target language (e.g. ja = true)
+	Typography map[string]bool `toml:"typography"`
+
+	// Additional target languages, code to name (e.g. tlh = "Klingon")
+	CustomLanguages map[string]string `toml:"custom_languages"`
+
+	// Defaults for doc merge, overridden by its command-line flags
+	Merge MergeDefaults `toml:"merge,omitempty"`
 
 	// General settings
 	Verbose bool `toml:"verbose"`
 }
 
+// MergeDefaults holds the [merge] table; unset keys keep the built-in merge defaults
+type MergeDefaults struct {
+	Recursive     *bool   `toml:"recursive"`
+	Order         string  `toml:"order,omitempty"`
+	Separator     *string `toml:"separator"` // May be set to "" to join files directly
+	TOC           *bool   `toml:"toc"`
+	TOCDepth      int     `toml:"toc_depth,omitempty"`
+	BaseLevel     int     `toml:"base_level,omitempty"`
+	AdjustHeaders *bool   `toml:"adjust_headers"`
+}
+
 // ProviderType constants
 const (
 	ProviderTypeClaude    = "claude-code"
 	ProviderTypeOpenAI    = "openai"
 	ProviderTypeAnthropic = "anthropic"
+	ProviderTypeOllama    = "ollama"
 )
 
+// ProviderTypes lists all known provider types in display order
+var ProviderTypes = []string{ProviderTypeClaude, ProviderTypeOpenAI, ProviderTypeAnthropic, ProviderTypeOllama}
+
+// DefaultOpenAIBaseURL is the base URL of the official OpenAI API
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// DefaultAzureAPIVersion is the Azure OpenAI REST API version used unless azure_api_version is set
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// DefaultOllamaBaseURL is the base URL of a local Ollama server
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// DefaultOpenAIMaxRetries is the default number of retries for failed OpenAI requests
+const DefaultOpenAIMaxRetries = 3
+
+// DefaultHTTPTimeoutSeconds is the default timeout for HTTP API requests
+const DefaultHTTPTimeoutSeconds = 120
+
 // GetDefaultModel returns the default model for a provider
 func GetDefaultModel(provider string) string {
 	switch provider {
@@ -46,27 +110,50 @@ func GetDefaultModel(provider string) string {
 		return "claude-3-5-haiku-20241022" // Most cost-effective recent option
 	case ProviderTypeClaude:
 		return "sonnet" // Claude Code CLI default
+	case ProviderTypeOllama:
+		return "llama3.1" // Widely available general-purpose local model
 	default:
 		return ""
 	}
 }
 
+// Defaults returns the configuration used when nothing is set in the config file or environment
+func Defaults() Config {
+	return Config{
+		ProviderType:       ProviderTypeClaude,
+		ClaudeCodePath:     "claude",
+		OpenAIModel:        GetDefaultModel(ProviderTypeOpenAI),
+		AnthropicModel:     GetDefaultModel(ProviderTypeAnthropic),
+		ClaudeModel:        GetDefaultModel(ProviderTypeClaude),
+		OllamaModel:        GetDefaultModel(ProviderTypeOllama),
+		OpenAIBaseURL:      DefaultOpenAIBaseURL,
+		AzureAPIVersion:    DefaultAzureAPIVersion,
+		OllamaBaseURL:      DefaultOllamaBaseURL,
+		OpenAIMaxRetries:   DefaultOpenAIMaxRetries,
+		HTTPTimeoutSeconds: DefaultHTTPTimeoutSeconds,
+		Verbose:            false,
+	}
+}
+
 // Load loads configuration from config file, then environment variables
 func Load() Config {
 	// Start with defaults
-	config := Config{
-		ProviderType:   ProviderTypeClaude,
-		ClaudeCodePath: "claude",
-		OpenAIModel:    GetDefaultModel(ProviderTypeOpenAI),
-		AnthropicModel: GetDefaultModel(ProviderTypeAnthropic),
-		ClaudeModel:    GetDefaultModel(ProviderTypeClaude),
-		Verbose:        false,
-	}
-
-	// Load from config file if it exists
-	if configPath := GetConfigPath(); configPath != "" {
-		if fileConfig, err := loadFromFile(configPath); err == nil {
+	config := Defaults()
+
+	// Load from config file if it exists, then from the active profile
+	paths := []string{GetConfigPath()}
+	if profile != "" {
+		paths = append(paths, GetProfilePath(profile))
+	}
+	for _, configPath := range paths {
+		if configPath == "" {
+			continue
+		}
+		fileConfig, err := loadFromFile(configPath)
+		if err == nil {
 			mergeConfig(&config, fileConfig)
+		} else if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse config file %s: %v\n", configPath, err)
 		}
 	}
 
@@ -74,6 +161,9 @@ func Load() Config {
 	loadEnvFile()
 	config = overrideWithEnv(config)
 
+	// Resolve API keys stored in files
+	resolveKeyFiles(&config)
+
 	return config
 }
 
@@ -82,8 +172,25 @@ func LoadFromEnv() Config {
 	return Load()
 }
 
-// GetConfigPath returns the path to the config file following XDG Base Directory spec
+// configPathOverride is the config file selected with SetConfigPath, or "" for the default
+var configPathOverride string
+
+// SetConfigPath makes the config file at path replace the default one; it takes precedence over
+// DOC_CONFIG. An empty path restores the default.
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// GetConfigPath returns the path to the config file: the one selected with SetConfigPath or the
+// DOC_CONFIG environment variable, or config.toml following the XDG Base Directory spec
 func GetConfigPath() string {
+	if configPathOverride != "" {
+		return configPathOverride
+	}
+	if path := os.Getenv("DOC_CONFIG"); path != "" {
+		return path
+	}
+
 	configDir := GetConfigDir()
 	if configDir == "" {
 		return ""
@@ -91,6 +198,82 @@ func GetConfigPath() string {
 	return filepath.Join(configDir, "config.toml")
 }
 
+// profile is the name of the profile layered over the base config, or "" for none
+var profile string
+
+// SetProfile selects the profile whose config.<name>.toml is merged over the base config
+func SetProfile(name string) {
+	profile = name
+}
+
+// Profile returns the name of the selected profile, or "" if none is selected
+func Profile() string {
+	return profile
+}
+
+// GetProfilePath returns the path to the config file of a named profile
+func GetProfilePath(name string) string {
+	configDir := GetConfigDir()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "config."+name+".toml")
+}
+
+// ListProfiles returns the names of all profiles in the config directory, sorted by name
+func ListProfiles() ([]string, error) {
+	configDir := GetConfigDir()
+	if configDir == "" {
+		return nil, fmt.Errorf("could not determine config directory")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(configDir, "config.*.toml"))
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "config."), ".toml")
+		profiles = append(profiles, name)
+	}
+	return profiles, nil
+}
+
+// UpdateProfile sets and removes keys in a profile's config file, leaving its other keys untouched.
+// Keys absent from the profile fall back to the base config.
+func UpdateProfile(name string, set map[string]any, unset []string) error {
+	path := GetProfilePath(name)
+	if path == "" {
+		return fmt.Errorf("could not determine config directory")
+	}
+
+	values := map[string]any{}
+	if _, err := toml.DecodeFile(path, &values); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read profile %s: %v", name, err)
+	}
+	for key, value := range set {
+		values[key] = value
+	}
+	for _, key := range unset {
+		delete(values, key)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create profile file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := toml.NewEncoder(file).Encode(values); err != nil {
+		return fmt.Errorf("failed to encode profile: %v", err)
+	}
+	return nil
+}
+
 // GetConfigDir returns the directory containing the config file following XDG Base Directory spec
 func GetConfigDir() string {
 	// Check XDG_CONFIG_HOME first
@@ -114,12 +297,22 @@ func getConfigSubdir() string {
 
 // SaveConfig saves the config to the config file
 func SaveConfig(config Config) error {
-	configDir := GetConfigDir()
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	configPath := GetConfigPath()
+	if configPath == "" {
+		return fmt.Errorf("could not determine config directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %v", err)
 	}
 
-	configPath := GetConfigPath()
+	// Keys backed by a key file are never persisted inline
+	if config.OpenAIAPIKeyFile != "" {
+		config.OpenAIAPIKey = ""
+	}
+	if config.AnthropicAPIKeyFile != "" {
+		config.AnthropicAPIKey = ""
+	}
+
 	file, err := os.Create(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to create config file: %v", err)
@@ -136,9 +329,28 @@ func SaveConfig(config Config) error {
 
 // loadFromFile loads configuration from a TOML file
 func loadFromFile(path string) (Config, error) {
-	var config Config
-	_, err := toml.DecodeFile(path, &config)
-	return config, err
+	// -1 marks openai_max_retries as unset, since 0 is a valid value (no retries)
+	config := Config{OpenAIMaxRetries: -1}
+	meta, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		return config, err
+	}
+
+	// Misspelled keys would otherwise be ignored without a trace
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		fmt.Fprintf(os.Stderr, "Warning: unknown keys in %s: %s\n", path, strings.Join(keys, ", "))
+	}
+
+	if config.ProviderType != "" && !slices.Contains(ProviderTypes, config.ProviderType) {
+		fmt.Fprintf(os.Stderr, "Warning: invalid provider %q in %s; must be one of: %s\n",
+			config.ProviderType, path, strings.Join(ProviderTypes, ", "))
+	}
+
+	return config, nil
 }
 
 // mergeConfig merges fileConfig into config (fileConfig takes precedence for non-empty values)
@@ -152,6 +364,12 @@ func mergeConfig(config *Config, fileConfig Config) {
 	if fileConfig.AnthropicAPIKey != "" {
 		config.AnthropicAPIKey = fileConfig.AnthropicAPIKey
 	}
+	if fileConfig.OpenAIAPIKeyFile != "" {
+		config.OpenAIAPIKeyFile = fileConfig.OpenAIAPIKeyFile
+	}
+	if fileConfig.AnthropicAPIKeyFile != "" {
+		config.AnthropicAPIKeyFile = fileConfig.AnthropicAPIKeyFile
+	}
 	if fileConfig.ClaudeCodePath != "" {
 		config.ClaudeCodePath = fileConfig.ClaudeCodePath
 	}
@@ -164,22 +382,129 @@ func mergeConfig(config *Config, fileConfig Config) {
 	if fileConfig.ClaudeModel != "" {
 		config.ClaudeModel = fileConfig.ClaudeModel
 	}
+	if fileConfig.OllamaModel != "" {
+		config.OllamaModel = fileConfig.OllamaModel
+	}
+	if fileConfig.OllamaBaseURL != "" {
+		config.OllamaBaseURL = fileConfig.OllamaBaseURL
+	}
+	if fileConfig.OpenAIBaseURL != "" {
+		config.OpenAIBaseURL = fileConfig.OpenAIBaseURL
+	}
+	if fileConfig.AzureEndpoint != "" {
+		config.AzureEndpoint = fileConfig.AzureEndpoint
+	}
+	if fileConfig.AzureDeployment != "" {
+		config.AzureDeployment = fileConfig.AzureDeployment
+	}
+	if fileConfig.AzureAPIVersion != "" {
+		config.AzureAPIVersion = fileConfig.AzureAPIVersion
+	}
+	if fileConfig.OpenAIMaxRetries >= 0 {
+		config.OpenAIMaxRetries = fileConfig.OpenAIMaxRetries
+	}
+	if fileConfig.HTTPTimeoutSeconds != 0 {
+		if fileConfig.HTTPTimeoutSeconds > 0 {
+			config.HTTPTimeoutSeconds = fileConfig.HTTPTimeoutSeconds
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: http_timeout_seconds must be positive, got %d; using %d\n",
+				fileConfig.HTTPTimeoutSeconds, config.HTTPTimeoutSeconds)
+		}
+	}
+	if len(fileConfig.Typography) > 0 {
+		config.Typography = fileConfig.Typography
+	}
+	// Custom languages accumulate, so a profile can add to those of the main config
+	for code, name := range fileConfig.CustomLanguages {
+		if config.CustomLanguages == nil {
+			config.CustomLanguages = make(map[string]string)
+		}
+		config.CustomLanguages[code] = name
+	}
+	mergeMergeDefaults(&config.Merge, fileConfig.Merge)
 	// Verbose is handled separately by CLI flags
 }
 
+// mergeMergeDefaults overrides the merge defaults with the keys set in fileMerge
+func mergeMergeDefaults(merge *MergeDefaults, fileMerge MergeDefaults) {
+	if fileMerge.Recursive != nil {
+		merge.Recursive = fileMerge.Recursive
+	}
+	if fileMerge.Order != "" {
+		merge.Order = fileMerge.Order
+	}
+	if fileMerge.Separator != nil {
+		merge.Separator = fileMerge.Separator
+	}
+	if fileMerge.TOC != nil {
+		merge.TOC = fileMerge.TOC
+	}
+	if fileMerge.TOCDepth != 0 {
+		merge.TOCDepth = fileMerge.TOCDepth
+	}
+	if fileMerge.BaseLevel != 0 {
+		merge.BaseLevel = fileMerge.BaseLevel
+	}
+	if fileMerge.AdjustHeaders != nil {
+		merge.AdjustHeaders = fileMerge.AdjustHeaders
+	}
+}
+
 // overrideWithEnv overrides config values with environment variables
 func overrideWithEnv(config Config) Config {
 	config.ProviderType = getEnvOrDefault("LLM_PROVIDER", config.ProviderType)
 	config.OpenAIAPIKey = getEnvOrDefault("OPENAI_API_KEY", config.OpenAIAPIKey)
 	config.AnthropicAPIKey = getEnvOrDefault("ANTHROPIC_API_KEY", config.AnthropicAPIKey)
+	config.OpenAIAPIKeyFile = getEnvOrDefault("OPENAI_API_KEY_FILE", config.OpenAIAPIKeyFile)
+	config.AnthropicAPIKeyFile = getEnvOrDefault("ANTHROPIC_API_KEY_FILE", config.AnthropicAPIKeyFile)
 	config.ClaudeCodePath = getEnvOrDefault("CLAUDE_CODE_PATH", config.ClaudeCodePath)
 	config.OpenAIModel = getEnvOrDefault("OPENAI_MODEL", config.OpenAIModel)
 	config.AnthropicModel = getEnvOrDefault("ANTHROPIC_MODEL", config.AnthropicModel)
 	config.ClaudeModel = getEnvOrDefault("CLAUDE_MODEL", config.ClaudeModel)
+	config.OpenAIBaseURL = getEnvOrDefault("OPENAI_BASE_URL", config.OpenAIBaseURL)
+	config.AzureEndpoint = getEnvOrDefault("AZURE_OPENAI_ENDPOINT", config.AzureEndpoint)
+	config.AzureDeployment = getEnvOrDefault("AZURE_OPENAI_DEPLOYMENT", config.AzureDeployment)
+	config.AzureAPIVersion = getEnvOrDefault("AZURE_OPENAI_API_VERSION", config.AzureAPIVersion)
+	config.OllamaModel = getEnvOrDefault("OLLAMA_MODEL", config.OllamaModel)
+	config.OllamaBaseURL = getEnvOrDefault("OLLAMA_BASE_URL", config.OllamaBaseURL)
+
+	if value := os.Getenv("HTTP_TIMEOUT"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			config.HTTPTimeoutSeconds = seconds
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: HTTP_TIMEOUT must be a positive number of seconds, got %q; using %d\n",
+				value, config.HTTPTimeoutSeconds)
+		}
+	}
 
 	return config
 }
 
+// resolveKeyFiles replaces API keys with the contents of their key files when set
+func resolveKeyFiles(config *Config) {
+	if key, ok := readKeyFile(config.OpenAIAPIKeyFile); ok {
+		config.OpenAIAPIKey = key
+	}
+	if key, ok := readKeyFile(config.AnthropicAPIKeyFile); ok {
+		config.AnthropicAPIKey = key
+	}
+}
+
+// readKeyFile reads an API key from a file, trimming trailing whitespace
+func readKeyFile(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read API key file %s: %v\n", path, err)
+		return "", false
+	}
+
+	return strings.TrimRight(string(data), " \t\r\n"), true
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
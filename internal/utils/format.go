@@ -16,12 +16,16 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.1fm", d.Minutes())
 }
 
+// minRevealedKeyLength is the shortest key whose first and last four characters are shown by
+// MaskAPIKey; shorter keys would give away most or all of the key
+const minRevealedKeyLength = 16
+
 // MaskAPIKey masks an API key for safe logging
 func MaskAPIKey(key string) string {
 	if key == "" {
 		return "(not set)"
 	}
-	if len(key) < 8 {
+	if len(key) < minRevealedKeyLength {
 		return "***"
 	}
 	return key[:4] + "..." + key[len(key)-4:]
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 )
 
 // supportedLanguages maps language codes to language names
@@ -12,22 +19,26 @@ var supportedLanguages = map[string]string{
 	"ko": "Korean",
 	"zh": "Chinese",
 	"ru": "Russian",
+	"uk": "Ukrainian",
 	"es": "Spanish",
 	"fr": "French",
 	"de": "German",
 	"it": "Italian",
 	"pt": "Portuguese",
+	"ca": "Catalan",
 	"nl": "Dutch",
 	"sv": "Swedish",
 	"no": "Norwegian",
 	"da": "Danish",
 	"fi": "Finnish",
+	"is": "Icelandic",
 	"pl": "Polish",
 	"cs": "Czech",
 	"hu": "Hungarian",
 	"ro": "Romanian",
 	"bg": "Bulgarian",
 	"hr": "Croatian",
+	"sr": "Serbian",
 	"sk": "Slovak",
 	"sl": "Slovenian",
 	"et": "Estonian",
@@ -38,7 +49,12 @@ var supportedLanguages = map[string]string{
 	"tr": "Turkish",
 	"ar": "Arabic",
 	"he": "Hebrew",
+	"fa": "Persian",
 	"hi": "Hindi",
+	"ur": "Urdu",
+	"bn": "Bengali",
+	"ta": "Tamil",
+	"te": "Telugu",
 	"th": "Thai",
 	"vi": "Vietnamese",
 	"id": "Indonesian",
@@ -48,84 +64,341 @@ var supportedLanguages = map[string]string{
 	"am": "Amharic",
 }
 
+// customLanguageCodes records the codes added to supportedLanguages from custom_languages
+var customLanguageCodes = make(map[string]bool)
+
+// customLanguageCodePattern matches the codes accepted in custom_languages
+var customLanguageCodePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,11}$`)
+
+// registerCustomLanguages adds the custom_languages of the config to supportedLanguages, so that
+// they pass validation and their names reach the prompt. Built-in codes cannot be redefined.
+func registerCustomLanguages(languages map[string]string) {
+	for _, code := range slices.Sorted(maps.Keys(languages)) {
+		name := strings.TrimSpace(languages[code])
+		normalized := strings.ToLower(strings.TrimSpace(code))
+
+		switch {
+		case !customLanguageCodePattern.MatchString(normalized) || name == "":
+			fmt.Fprintf(os.Stderr, "Warning: ignoring custom language %q = %q; codes are 2-12 lowercase letters, digits or hyphens and need a name\n", code, languages[code])
+		case supportedLanguages[normalized] != "" && !customLanguageCodes[normalized]:
+			fmt.Fprintf(os.Stderr, "Warning: ignoring custom language %q; it is already built in as %s\n", code, supportedLanguages[normalized])
+		default:
+			supportedLanguages[normalized] = name
+			customLanguageCodes[normalized] = true
+		}
+	}
+}
+
+// regionalLanguages maps BCP-47 region-qualified codes to language names.
+// A regional code is supported wherever its base language code is.
+var regionalLanguages = map[string]string{
+	"en-US": "American English",
+	"en-GB": "British English",
+	"es-ES": "European Spanish",
+	"es-MX": "Mexican Spanish",
+	"fr-CA": "Canadian French",
+	"pt-BR": "Brazilian Portuguese",
+	"pt-PT": "European Portuguese",
+	"zh-CN": "Simplified Chinese",
+	"zh-TW": "Traditional Chinese (Taiwan)",
+	"zh-HK": "Traditional Chinese (Hong Kong)",
+}
+
+// languageAliases maps common alternate codes and names (lowercase) to language codes
+var languageAliases = map[string]string{
+	"jp":                  "ja",
+	"jpn":                 "ja",
+	"cn":                  "zh",
+	"chs":                 "zh-CN",
+	"cht":                 "zh-TW",
+	"zh-hans":             "zh-CN",
+	"zh-hant":             "zh-TW",
+	"mandarin":            "zh",
+	"simplified chinese":  "zh-CN",
+	"traditional chinese": "zh-TW",
+	"kr":                  "ko",
+	"kor":                 "ko",
+	"gr":                  "el",
+	"cz":                  "cs",
+	"dk":                  "da",
+	"se":                  "sv",
+	"nb":                  "no",
+	"nn":                  "no",
+	"norwegian bokmål":    "no",
+	"iw":                  "he",
+	"farsi":               "fa",
+	"ua":                  "uk",
+	"tagalog":             "tl",
+	"brazilian":           "pt-BR",
+	"american":            "en-US",
+	"british":             "en-GB",
+	"eng":                 "en",
+	"ger":                 "de",
+	"deu":                 "de",
+	"fra":                 "fr",
+	"fre":                 "fr",
+	"spa":                 "es",
+	"rus":                 "ru",
+}
+
+// resolveLanguageCode maps user input to a language code, accepting codes in any case
+// (JA, pt-br, pt_BR), English language names (Japanese, french) and common aliases (jp).
+// It returns false if the input matches nothing.
+func resolveLanguageCode(input string) (string, bool) {
+	if languageName(input) != "" {
+		return input, true
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(input, "_", "-")))
+	if normalized == "" {
+		return "", false
+	}
+
+	if _, ok := supportedLanguages[normalized]; ok {
+		return normalized, true
+	}
+	if code, ok := languageAliases[normalized]; ok {
+		return code, true
+	}
+
+	for code, name := range regionalLanguages {
+		if strings.ToLower(code) == normalized || strings.ToLower(name) == normalized {
+			return code, true
+		}
+	}
+	for code, name := range supportedLanguages {
+		if strings.ToLower(name) == normalized {
+			return code, true
+		}
+	}
+
+	return "", false
+}
+
+// languageName returns the name of a language code, including region-qualified codes
+func languageName(code string) string {
+	if name, ok := regionalLanguages[code]; ok {
+		return name
+	}
+	return supportedLanguages[code]
+}
+
+// baseLanguageCode returns the language part of a region-qualified code (pt-BR -> pt)
+func baseLanguageCode(code string) string {
+	base, _, _ := strings.Cut(code, "-")
+	return base
+}
+
 // validateLanguageCode validates a language code against the default supported languages
 func validateLanguageCode(code string) error {
-	if _, exists := supportedLanguages[code]; !exists {
-		return fmt.Errorf("unsupported language code: %s", code)
-	}
-	return nil
+	return validateLanguageCodeWithMap(code, supportedLanguages)
 }
 
-// validateLanguageCodeWithMap validates a language code against a provided map
+// validateLanguageCodeWithMap validates a language code against a provided map,
+// accepting region-qualified codes whose base language is in the map
 func validateLanguageCodeWithMap(code string, supportedLangs map[string]string) error {
-	if _, exists := supportedLangs[code]; !exists {
-		return fmt.Errorf("unsupported language code: %s", code)
+	if _, exists := supportedLangs[code]; exists {
+		return nil
 	}
-	return nil
+	if _, regional := regionalLanguages[code]; regional {
+		if _, exists := supportedLangs[baseLanguageCode(code)]; exists {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported language code: %s", code)
 }
 
-// showSupportedLanguages displays all supported language codes
-func showSupportedLanguages() {
-	fmt.Fprintf(os.Stderr, "Supported language codes:\n")
+// regionalVariants returns the sorted region-qualified codes of a base language code
+func regionalVariants(base string) []string {
+	var variants []string
+	for code := range regionalLanguages {
+		if baseLanguageCode(code) == base {
+			variants = append(variants, code)
+		}
+	}
+	sort.Strings(variants)
+	return variants
+}
+
+// languageGroups assigns the built-in languages to the groups shown by doc --list --group
+var languageGroups = map[string]string{
+	"en": "European", "es": "European", "fr": "European", "de": "European", "it": "European",
+	"pt": "European", "ca": "European", "nl": "European", "sv": "European", "no": "European",
+	"da": "European", "fi": "European", "is": "European", "pl": "European", "cs": "European",
+	"hu": "European", "ro": "European", "bg": "European", "hr": "European", "sr": "European",
+	"sk": "European", "sl": "European", "et": "European", "lv": "European", "lt": "European",
+	"mt": "European", "el": "European", "ru": "European", "uk": "European",
+	"tr": "Middle Eastern", "ar": "Middle Eastern", "he": "Middle Eastern", "fa": "Middle Eastern",
+	"ja": "Asian", "ko": "Asian", "zh": "Asian", "hi": "Asian", "ur": "Asian", "bn": "Asian",
+	"ta": "Asian", "te": "Asian", "th": "Asian", "vi": "Asian", "id": "Asian", "ms": "Asian",
+	"tl": "Asian",
+	"sw": "African", "am": "African",
+}
+
+// languageGroupOrder is the order of the groups in doc --list --group; custom languages come last
+var languageGroupOrder = []string{"European", "Asian", "Middle Eastern", "African", "Custom"}
+
+// languageGroup returns the --list --group heading of a language code
+func languageGroup(code string) string {
+	if customLanguageCodes[code] {
+		return "Custom"
+	}
+	return languageGroups[code]
+}
 
-	// Sort for consistent output
+// sortedLanguageCodes returns the supported language codes sorted by code, or by name with byName
+func sortedLanguageCodes(byName bool) []string {
 	codes := make([]string, 0, len(supportedLanguages))
 	for code := range supportedLanguages {
 		codes = append(codes, code)
 	}
+	sort.Strings(codes)
+
+	if byName {
+		sort.SliceStable(codes, func(i, j int) bool {
+			return supportedLanguages[codes[i]] < supportedLanguages[codes[j]]
+		})
+	}
+	return codes
+}
 
-	// Simple sort
-	for i := 0; i < len(codes); i++ {
-		for j := i + 1; j < len(codes); j++ {
-			if codes[i] > codes[j] {
-				codes[i], codes[j] = codes[j], codes[i]
+// showSupportedLanguages displays all supported language codes, sorted by code or with byName by
+// language name, and with grouped under European, Asian and other group headings
+func showSupportedLanguages(byName, grouped bool) {
+	fmt.Fprintf(os.Stderr, "Supported language codes:\n")
+
+	codes := sortedLanguageCodes(byName)
+	if !grouped {
+		writeLanguageList(codes)
+		return
+	}
+
+	for _, group := range languageGroupOrder {
+		var members []string
+		for _, code := range codes {
+			if languageGroup(code) == group {
+				members = append(members, code)
 			}
 		}
+		if len(members) > 0 {
+			fmt.Fprintf(os.Stderr, "\n%s:\n", group)
+			writeLanguageList(members)
+		}
 	}
+}
 
+// writeLanguageList prints language codes with their names and regional variants
+func writeLanguageList(codes []string) {
 	for _, code := range codes {
+		if customLanguageCodes[code] {
+			fmt.Fprintf(os.Stderr, "  %s - %s (custom)\n", code, supportedLanguages[code])
+			continue
+		}
 		fmt.Fprintf(os.Stderr, "  %s - %s\n", code, supportedLanguages[code])
+		for _, variant := range regionalVariants(code) {
+			fmt.Fprintf(os.Stderr, "    %s - %s\n", variant, regionalLanguages[variant])
+		}
 	}
 }
 
-// getSimilarLanguageCodes finds language codes that start with the input string
+// writeLanguagesJSON writes every accepted language code, regional variants included, as a JSON
+// object of code to name to w
+func writeLanguagesJSON(w io.Writer) error {
+	languages := maps.Clone(supportedLanguages)
+	maps.Copy(languages, regionalLanguages)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(languages)
+}
+
+// getSimilarLanguageCodes finds language codes similar to the input string
 func getSimilarLanguageCodes(input string) []string {
+	return getSimilarLanguageCodesWithMap(input, supportedLanguages)
+}
+
+// maxSuggestions caps the number of "did you mean" suggestions found by edit distance
+const maxSuggestions = 5
+
+// getSimilarLanguageCodesWithMap finds similar language codes in a provided map: codes that start
+// with the input first, then codes whose code or name is within a small edit distance of it
+func getSimilarLanguageCodesWithMap(input string, supportedLangs map[string]string) []string {
 	var similar []string
-	for code := range supportedLanguages {
+	for code := range supportedLangs {
 		if len(code) >= len(input) && code[:len(input)] == input {
 			similar = append(similar, code)
 		}
 	}
 
-	// Simple sort
-	for i := 0; i < len(similar); i++ {
-		for j := i + 1; j < len(similar); j++ {
-			if similar[i] > similar[j] {
-				similar[i], similar[j] = similar[j], similar[i]
+	sort.Strings(similar)
+
+	// Single characters are too short to be typos of anything in particular
+	if len(input) < 2 || len(similar) >= maxSuggestions {
+		return similar
+	}
+
+	type candidate struct {
+		code     string
+		distance int
+	}
+	var near []candidate
+	lower := strings.ToLower(input)
+	for code, name := range supportedLangs {
+		if slices.Contains(similar, code) {
+			continue
+		}
+
+		distance := -1
+		for _, target := range []string{code, strings.ToLower(name)} {
+			d := levenshtein(lower, target)
+			// Allow one edit for short codes and two for names, so every 2-letter input is not "close"
+			if d <= min(2, len(target)/2) && (distance < 0 || d < distance) {
+				distance = d
 			}
 		}
+		if distance >= 0 {
+			near = append(near, candidate{code, distance})
+		}
+	}
+
+	sort.Slice(near, func(i, j int) bool {
+		if near[i].distance != near[j].distance {
+			return near[i].distance < near[j].distance
+		}
+		return near[i].code < near[j].code
+	})
+
+	for _, c := range near {
+		if len(similar) >= maxSuggestions {
+			break
+		}
+		similar = append(similar, c.code)
 	}
 
 	return similar
 }
 
-// getSimilarLanguageCodesWithMap finds similar language codes in a provided map
-func getSimilarLanguageCodesWithMap(input string, supportedLangs map[string]string) []string {
-	var similar []string
-	for code := range supportedLangs {
-		if len(code) >= len(input) && code[:len(input)] == input {
-			similar = append(similar, code)
-		}
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
 	}
 
-	// Simple sort
-	for i := 0; i < len(similar); i++ {
-		for j := i + 1; j < len(similar); j++ {
-			if similar[i] > similar[j] {
-				similar[i], similar[j] = similar[j], similar[i]
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
 			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
 		}
+		prev, curr = curr, prev
 	}
 
-	return similar
+	return prev[len(rb)]
 }
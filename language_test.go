@@ -1,6 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"slices"
 	"testing"
 )
 
@@ -18,6 +22,10 @@ func TestValidateLanguageCode(t *testing.T) {
 		{"Empty code", "", true},
 		{"Case sensitive", "JA", true},
 		{"Long code", "japanese", true},
+		{"Valid Brazilian Portuguese", "pt-BR", false},
+		{"Valid Traditional Chinese", "zh-TW", false},
+		{"Unknown region", "pt-XX", true},
+		{"Region case sensitive", "pt-br", true},
 	}
 
 	for _, tt := range tests {
@@ -32,7 +40,7 @@ func TestValidateLanguageCode(t *testing.T) {
 
 func TestSupportedLanguagesMap(t *testing.T) {
 	// Test that common language codes exist
-	requiredCodes := []string{"ja", "en", "ru", "zh", "es", "fr", "de"}
+	requiredCodes := []string{"ja", "en", "ru", "zh", "es", "fr", "de", "uk", "bn", "ta", "ur", "fa", "ca"}
 
 	for _, code := range requiredCodes {
 		if _, exists := supportedLanguages[code]; !exists {
@@ -51,6 +59,135 @@ func TestSupportedLanguagesMap(t *testing.T) {
 	}
 }
 
+func TestRegionalLanguages(t *testing.T) {
+	for code, name := range regionalLanguages {
+		if _, exists := supportedLanguages[baseLanguageCode(code)]; !exists {
+			t.Errorf("Regional code %s has no supported base language", code)
+		}
+		if name == "" {
+			t.Errorf("Regional code %s has empty name", code)
+		}
+	}
+
+	if name := languageName("pt-BR"); name != "Brazilian Portuguese" {
+		t.Errorf("languageName(pt-BR) = %q, want Brazilian Portuguese", name)
+	}
+	if name := languageName("pt"); name != "Portuguese" {
+		t.Errorf("languageName(pt) = %q, want Portuguese", name)
+	}
+
+	variants := regionalVariants("zh")
+	expected := []string{"zh-CN", "zh-HK", "zh-TW"}
+	if len(variants) != len(expected) {
+		t.Fatalf("regionalVariants(zh) = %v, want %v", variants, expected)
+	}
+	for i := range expected {
+		if variants[i] != expected[i] {
+			t.Errorf("regionalVariants(zh) = %v, want %v", variants, expected)
+			break
+		}
+	}
+}
+
+func TestWriteLanguagesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLanguagesJSON(&buf); err != nil {
+		t.Fatalf("writeLanguagesJSON() error: %v", err)
+	}
+
+	var languages map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &languages); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if languages["ja"] != "Japanese" || languages["pt-BR"] != regionalLanguages["pt-BR"] {
+		t.Errorf("Expected base and regional languages, got ja=%q pt-BR=%q", languages["ja"], languages["pt-BR"])
+	}
+	if len(languages) != len(supportedLanguages)+len(regionalLanguages) {
+		t.Errorf("Got %d languages, want %d", len(languages), len(supportedLanguages)+len(regionalLanguages))
+	}
+}
+
+func TestSortedLanguageCodes(t *testing.T) {
+	byCode := sortedLanguageCodes(false)
+	if !slices.IsSorted(byCode) {
+		t.Errorf("Expected codes sorted by code, got %v", byCode)
+	}
+
+	byName := sortedLanguageCodes(true)
+	for i := 1; i < len(byName); i++ {
+		if supportedLanguages[byName[i-1]] > supportedLanguages[byName[i]] {
+			t.Errorf("%s (%s) sorted before %s (%s)", byName[i-1], supportedLanguages[byName[i-1]],
+				byName[i], supportedLanguages[byName[i]])
+		}
+	}
+	if len(byName) != len(supportedLanguages) {
+		t.Errorf("Got %d codes, want %d", len(byName), len(supportedLanguages))
+	}
+}
+
+func TestLanguageGroups(t *testing.T) {
+	for code := range supportedLanguages {
+		if group := languageGroup(code); !slices.Contains(languageGroupOrder, group) {
+			t.Errorf("Language %s has no --list --group group (got %q)", code, group)
+		}
+	}
+}
+
+func TestResolveLanguageCode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"ja", "ja", true},
+		{"JA", "ja", true},
+		{"Japanese", "ja", true},
+		{"french", "fr", true},
+		{"jp", "ja", true},
+		{"pt-br", "pt-BR", true},
+		{"pt_BR", "pt-BR", true},
+		{"Brazilian Portuguese", "pt-BR", true},
+		{"zh-Hant", "zh-TW", true},
+		{"enlish", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code, ok := resolveLanguageCode(tt.input)
+			if code != tt.expected || ok != tt.ok {
+				t.Errorf("resolveLanguageCode(%q) = %q, %v; want %q, %v", tt.input, code, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+
+	for alias, code := range languageAliases {
+		if validateLanguageCode(code) != nil {
+			t.Errorf("Alias %q maps to unsupported code %q", alias, code)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"ja", "ja", 0},
+		{"jp", "ja", 1},
+		{"enlish", "english", 1},
+		{"kitten", "sitting", 3},
+		{"日本", "日本語", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
 func TestGetSimilarLanguageCodes(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -59,7 +196,11 @@ func TestGetSimilarLanguageCodes(t *testing.T) {
 		{"j", []string{"ja"}},
 		{"e", []string{"el", "en", "es", "et"}},
 		{"xyz", []string{}},
-		{"", []string{"am", "ar", "bg", "cs", "da", "de", "el", "en", "es", "et", "fi", "fr", "he", "hi", "hr", "hu", "id", "it", "ja", "ko", "lt", "lv", "ms", "mt", "nl", "no", "pl", "pt", "ro", "ru", "sk", "sl", "sv", "sw", "th", "tl", "tr", "vi", "zh"}},
+		{"jp", []string{"ja"}},
+		{"enlish", []string{"en", "pl"}},
+		// Unrelated abbreviations do not produce noisy suggestions
+		{"gmn", []string{}},
+		{"", []string{"am", "ar", "bg", "bn", "ca", "cs", "da", "de", "el", "en", "es", "et", "fa", "fi", "fr", "he", "hi", "hr", "hu", "id", "is", "it", "ja", "ko", "lt", "lv", "ms", "mt", "nl", "no", "pl", "pt", "ro", "ru", "sk", "sl", "sr", "sv", "sw", "ta", "te", "th", "tl", "tr", "uk", "ur", "vi", "zh"}},
 	}
 
 	for _, tt := range tests {
@@ -78,3 +219,42 @@ func TestGetSimilarLanguageCodes(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterCustomLanguages(t *testing.T) {
+	t.Cleanup(func() {
+		for code := range customLanguageCodes {
+			delete(supportedLanguages, code)
+			delete(customLanguageCodes, code)
+		}
+	})
+
+	originalStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = originalStderr }()
+
+	registerCustomLanguages(map[string]string{
+		"TLH":  "Klingon",
+		"qya":  "Quenya",
+		"ja":   "Not Japanese",
+		"x":    "Too short",
+		"none": "",
+	})
+
+	if err := validateLanguageCode("tlh"); err != nil {
+		t.Errorf("Expected custom language tlh to be valid: %v", err)
+	}
+	if code, ok := resolveLanguageCode("Quenya"); !ok || code != "qya" {
+		t.Errorf("resolveLanguageCode(Quenya) = %q, %v; want qya", code, ok)
+	}
+	if supportedLanguages["ja"] != "Japanese" {
+		t.Errorf("Expected built-in ja to keep its name, got %q", supportedLanguages["ja"])
+	}
+	for _, code := range []string{"x", "none"} {
+		if _, exists := supportedLanguages[code]; exists {
+			t.Errorf("Expected invalid custom language %q to be ignored", code)
+		}
+	}
+	if !customLanguageCodes["tlh"] || customLanguageCodes["ja"] {
+		t.Errorf("customLanguageCodes = %v, want tlh and qya only", customLanguageCodes)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// linkDestinationPattern matches the destination of inline links and images: ](dest) or ](dest "title")
+	linkDestinationPattern = regexp.MustCompile(`(\]\(\s*)(<[^>\n]*>|[^)\s]+)`)
+	// linkReferencePattern matches the destination of link reference definitions: [id]: dest
+	linkReferencePattern = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>\n]*>|\S+)`)
+	// urlSchemePattern matches destinations with a scheme such as https: or mailto:
+	urlSchemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// rewriteRelativeLinks rewrites relative link and image destinations in content, which are relative
+// to sourceDir, so that they resolve from outputDir. URLs, absolute paths and anchor-only links are
+// kept, as is everything inside code.
+func rewriteRelativeLinks(content, sourceDir, outputDir string) string {
+	// Both directories must be absolute for filepath.Rel to relate them
+	if abs, err := filepath.Abs(sourceDir); err == nil {
+		sourceDir = abs
+	}
+	if abs, err := filepath.Abs(outputDir); err == nil {
+		outputDir = abs
+	}
+
+	rewrite := func(pattern *regexp.Regexp, text string) string {
+		return pattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			return groups[1] + rewriteLinkDestination(groups[2], sourceDir, outputDir)
+		})
+	}
+
+	lines := strings.Split(content, "\n")
+	var fence codeFenceTracker
+	for i, line := range lines {
+		if fence.inCode(line) {
+			continue
+		}
+
+		// Only rewrite the text between inline code spans
+		var sb strings.Builder
+		last := 0
+		for _, span := range inlineCodePattern.FindAllStringIndex(line, -1) {
+			sb.WriteString(rewrite(linkDestinationPattern, line[last:span[0]]))
+			sb.WriteString(line[span[0]:span[1]])
+			last = span[1]
+		}
+		sb.WriteString(rewrite(linkDestinationPattern, line[last:]))
+		lines[i] = rewrite(linkReferencePattern, sb.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// rewriteLinkDestination returns dest, relative to sourceDir, as a path relative to outputDir
+func rewriteLinkDestination(dest, sourceDir, outputDir string) string {
+	angled := strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">")
+	path := strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+
+	if path == "" || strings.HasPrefix(path, "#") || strings.HasPrefix(path, "/") ||
+		filepath.IsAbs(path) || urlSchemePattern.MatchString(path) {
+		return dest
+	}
+
+	// Keep the query and fragment as they are
+	suffix := ""
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, suffix = path[:i], path[i:]
+	}
+
+	target := filepath.Join(sourceDir, filepath.FromSlash(path))
+	rewritten, err := filepath.Rel(outputDir, target)
+	if err != nil {
+		rewritten = target
+	}
+	rewritten = filepath.ToSlash(rewritten) + suffix
+
+	if angled {
+		return "<" + rewritten + ">"
+	}
+	return rewritten
+}
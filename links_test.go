@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteRelativeLinks(t *testing.T) {
+	root := t.TempDir()
+	sourceDir := filepath.Join(root, "docs", "guide")
+	outputDir := root
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Image", "![logo](./img/logo.png)", "![logo](docs/guide/img/logo.png)"},
+		{"Parent directory", "[api](../api.md#usage)", "[api](docs/api.md#usage)"},
+		{"Title kept", `[intro](intro.md "Intro")`, `[intro](docs/guide/intro.md "Intro")`},
+		{"Angle brackets", "[spaced](<my file.md>)", "[spaced](<docs/guide/my file.md>)"},
+		{"Reference definition", "[logo]: img/logo.png", "[logo]: docs/guide/img/logo.png"},
+		{"Absolute URL", "[site](https://example.com/a.md)", "[site](https://example.com/a.md)"},
+		{"Mail link", "[mail](mailto:me@example.com)", "[mail](mailto:me@example.com)"},
+		{"Anchor only", "[below](#section)", "[below](#section)"},
+		{"Absolute path", "[root](/docs/a.md)", "[root](/docs/a.md)"},
+		{"Inline code", "`[x](a.md)` and [y](b.md)", "`[x](a.md)` and [y](docs/guide/b.md)"},
+		{"Code fence", "```\n[x](a.md)\n```", "```\n[x](a.md)\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rewriteRelativeLinks(tt.input, sourceDir, outputDir)
+			if result != tt.expected {
+				t.Errorf("rewriteRelativeLinks(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -3,7 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/bigdra50/doc/internal/config"
 )
@@ -12,12 +16,24 @@ func main() {
 	// Parse command line arguments
 	cliArgs, err := parseArgs()
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
 		showUsage()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
-	// Set global verbose flag
+	// Set global verbose and quiet flags
 	verbose = cliArgs.Verbose
+	quiet = cliArgs.Quiet
+
+	// Select the config file and profile before anything loads the config; --set creates missing profiles
+	selectConfig(cliArgs)
+	if cliArgs.Profile != "" {
+		if _, err := os.Stat(config.GetProfilePath(cliArgs.Profile)); err != nil && len(cliArgs.SetConfig) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: profile '%s' not found at %s\n", cliArgs.Profile, config.GetProfilePath(cliArgs.Profile))
+			fmt.Fprintf(os.Stderr, "Create it with: doc --profile %s --set key=value\n", cliArgs.Profile)
+			os.Exit(exitConfig)
+		}
+	}
 
 	// Handle special commands
 	if handleSpecialCommands(cliArgs) {
@@ -28,20 +44,46 @@ func main() {
 	if cliArgs.IsMergeCommand {
 		if err := runMerge(cliArgs); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	// Handle translate-dir command
+	if cliArgs.IsTranslateDirCommand {
+		if err := runTranslateDir(cliArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
 		}
 		return
 	}
 
 	// Run translation
 	if err := runTranslation(cliArgs); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if cliArgs.JSON {
+			_ = writeJSONResult(jsonTranslationResult{
+				TranslationResponse: TranslationResponse{Status: "error", Message: err.Error()},
+				TargetLanguage:      cliArgs.TargetLanguage,
+			})
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
 // handleSpecialCommands handles configuration and listing commands
 func handleSpecialCommands(cliArgs *CLIArgs) bool {
+	if cliArgs.ShowHelp {
+		printUsage(os.Stdout)
+		return true
+	}
+
+	if cliArgs.ShowVersion {
+		fmt.Println(versionString())
+		return true
+	}
+
 	// Handle config commands
 	if cliArgs.ShowConfig {
 		showCurrentConfig()
@@ -58,17 +100,60 @@ func handleSpecialCommands(cliArgs *CLIArgs) bool {
 		return true
 	}
 
+	if len(cliArgs.UnsetConfig) > 0 {
+		unsetConfigValues(cliArgs.UnsetConfig)
+		return true
+	}
+
+	if cliArgs.ClearCache {
+		clearCache()
+		return true
+	}
+
+	if cliArgs.CompletionShell != "" {
+		if err := writeCompletion(os.Stdout, cliArgs.CompletionShell); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		return true
+	}
+
 	// Handle list commands
 	if cliArgs.ShowList {
-		showSupportedLanguages()
+		LoadConfig() // Registers the custom languages
+		if cliArgs.ListJSON {
+			if err := writeLanguagesJSON(os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitFailure)
+			}
+			return true
+		}
+		showSupportedLanguages(cliArgs.ListByName, cliArgs.ListGrouped)
+		return true
+	}
+
+	if cliArgs.ShowListProviders {
+		showProviders()
+		return true
+	}
+
+	if cliArgs.ShowListProfiles {
+		showProfiles()
 		return true
 	}
 
 	if cliArgs.ShowListModels {
+		if cliArgs.ListJSON {
+			if err := writeModelsJSON(os.Stdout, cliArgs.ListModelsProvider, cliArgs.ListModelsSortBy, cliArgs.ListModelsTier); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitUsage)
+			}
+			return true
+		}
 		if cliArgs.ListModelsProvider != "" {
-			showModelsForProvider(cliArgs.ListModelsProvider)
+			showModelsForProvider(cliArgs.ListModelsProvider, cliArgs.ListModelsSortBy, cliArgs.ListModelsTier)
 		} else {
-			showAllModels()
+			showAllModels(cliArgs.ListModelsSortBy, cliArgs.ListModelsTier)
 		}
 		return true
 	}
@@ -78,6 +163,8 @@ func handleSpecialCommands(cliArgs *CLIArgs) bool {
 
 // runTranslation performs the main translation operation
 func runTranslation(cliArgs *CLIArgs) error {
+	start := time.Now()
+
 	// Load configuration
 	config := LoadConfig()
 	config.Verbose = verbose
@@ -89,44 +176,321 @@ func runTranslation(cliArgs *CLIArgs) error {
 			maskAPIKey(config.AnthropicAPIKey))
 	}
 
+	if err := applyProviderOverrides(cliArgs, &config); err != nil {
+		return err
+	}
+
+	// --auto-model sizes the model to the document, so the document is read before the provider is set up
+	var content string
+	if cliArgs.AutoModel {
+		var err error
+		if content, err = readTranslationInput(cliArgs); err != nil {
+			return err
+		}
+		if err := applyAutoModel(&config, content); err != nil {
+			return err
+		}
+	}
+
+	// --estimate only reads the document, so it works without provider credentials
+	if cliArgs.Estimate {
+		if content == "" {
+			var err error
+			if content, err = readTranslationInput(cliArgs); err != nil {
+				return err
+			}
+		}
+		model := configuredModel(config, config.ProviderType)
+		if model == "" {
+			model = GetDefaultModel(config.ProviderType)
+		}
+		writeCostEstimate(os.Stderr, config.ProviderType, model, content)
+		return nil
+	}
+
+	// --print-prompt only builds the prompts, so it works without provider credentials too
+	if cliArgs.PrintPrompt {
+		return printPrompt(cliArgs, config, content)
+	}
+
 	// Create LLM provider
 	provider, err := NewLLMProvider(config)
 	if err != nil {
 		showProviderHelp(config.ProviderType)
-		return fmt.Errorf("failed to initialize %s provider: %w", config.ProviderType, err)
+		return withExitCode(exitConfig, fmt.Errorf("failed to initialize %s provider: %w", config.ProviderType, err))
 	}
 
 	log("Using provider: %s", provider.GetProviderName())
 
-	// Validate language code
-	if err := validateLanguage(cliArgs.TargetLanguage, provider); err != nil {
-		return err
+	var streamer StreamingProvider
+	if cliArgs.Stream {
+		s, ok := provider.(StreamingProvider)
+		if !ok {
+			return withExitCode(exitUsage, fmt.Errorf("--stream is not supported by the %s provider", provider.GetProviderName()))
+		}
+		streamer = s
 	}
 
-	log("Target language: %s", cliArgs.TargetLanguage)
+	// Validate language code; transform keeps the document's own language
+	if cliArgs.IsTransformCommand {
+		log("Transforming without translation")
+	} else {
+		cliArgs.TargetLanguage, err = validateLanguage(cliArgs.TargetLanguage, provider)
+		if err != nil {
+			return err
+		}
+		log("Target language: %s", cliArgs.TargetLanguage)
+	}
 	if cliArgs.TransformInstruction != "" {
 		log("Custom instruction: %s", cliArgs.TransformInstruction)
 	}
 
-	// Read document from stdin
-	content, err := readDocument()
+	// outputTranslation writes the result to the output file or stdout, wrapped in JSON with --json
+	outputTranslation := func(result, status, message string) error {
+		if !cliArgs.JSON {
+			return writeTranslationOutput(cliArgs.OutputFile, result)
+		}
+		if cliArgs.OutputFile != "" {
+			if err := writeTranslationOutput(cliArgs.OutputFile, result); err != nil {
+				return err
+			}
+		}
+
+		model := configuredModel(config, config.ProviderType)
+		if model == "" {
+			model = GetDefaultModel(config.ProviderType)
+		}
+		return writeJSONResult(jsonTranslationResult{
+			TranslationResponse: TranslationResponse{Content: result, Status: status, Message: message},
+			TargetLanguage:      cliArgs.TargetLanguage,
+			Provider:            config.ProviderType,
+			Model:               model,
+			OutputFile:          cliArgs.OutputFile,
+			DurationMS:          time.Since(start).Milliseconds(),
+		})
+	}
+
+	// Refuse to clobber an existing output file before spending a translation on it
+	if err := checkOutputFile(cliArgs.OutputFile, cliArgs.Force); err != nil {
+		return err
+	}
+
+	// Read document from the input file or stdin
+	if content == "" {
+		if content, err = readTranslationInput(cliArgs); err != nil {
+			return err
+		}
+	}
+
+	options, err := newTranslationOptions(cliArgs, config)
 	if err != nil {
 		return err
 	}
 
+	// Large documents are translated in chunks that fit the model's context window
+	maxChunkTokens := chunkTokenBudget(cliArgs, config)
+	log("Maximum chunk size: ~%d tokens", maxChunkTokens)
+
+	// Translate only the added lines of a unified diff
+	if cliArgs.DiffMode {
+		result, err := translatePatch(content, func(text string) (string, error) {
+			return translateChunked(provider, text, options, cliArgs.MaxRepairs, maxChunkTokens)
+		})
+		if err != nil {
+			return withExitCode(exitProvider, fmt.Errorf("translation failed: %w", err))
+		}
+		return outputTranslation(result+"\n", "success", "")
+	}
+
+	// Skip the round trip when the document is already in the target language.
+	// Only base languages are detected, so regional targets (pt-BR) are always translated.
+	if cliArgs.SkipIfTranslated {
+		if detected := detectLanguage(content); detected == cliArgs.TargetLanguage {
+			log("Document is already in %s; skipping translation", supportedLanguages[detected])
+			return outputTranslation(content, "skipped", fmt.Sprintf("document is already in %s", supportedLanguages[detected]))
+		} else if detected != "" {
+			log("Detected source language: %s", supportedLanguages[detected])
+		} else {
+			log("Could not detect the source language; translating")
+		}
+	}
+
+	// Stream the translation to stdout as it arrives; printed text cannot be repaired or post-processed
+	if streamer != nil {
+		if cliArgs.MaxRepairs > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: --auto-repair is ignored with --stream\n")
+		}
+		if config.Typography[cliArgs.TargetLanguage] {
+			fmt.Fprintf(os.Stderr, "Warning: %s typography post-processing is skipped with --stream\n", cliArgs.TargetLanguage)
+		}
+		if err := translateStreaming(streamer, content, options, maxChunkTokens, os.Stdout); err != nil {
+			return withExitCode(exitProvider, fmt.Errorf("translation failed: %w", err))
+		}
+		return nil
+	}
+
 	// Perform translation
-	result, err := performTranslation(provider, content, cliArgs.TargetLanguage, cliArgs.TransformInstruction)
+	result, err := translateChunked(provider, content, options, cliArgs.MaxRepairs, maxChunkTokens)
 	if err != nil {
-		return fmt.Errorf("translation failed: %w", err)
+		return withExitCode(exitProvider, fmt.Errorf("translation failed: %w", err))
+	}
+
+	// Apply opt-in typographic post-processing for the target language
+	if config.Typography[cliArgs.TargetLanguage] {
+		log("Applying %s typography post-processing", cliArgs.TargetLanguage)
+		result = applyTypography(cliArgs.TargetLanguage, result)
 	}
 
 	// Output the translation result
-	fmt.Print(result)
+	return outputTranslation(result, "success", "")
+}
+
+// printPrompt writes the prompts that translating the input would send to stdout; content is the
+// document if it has already been read, or "" to read it
+func printPrompt(cliArgs *CLIArgs, config ProviderConfig, content string) error {
+	provider, err := newPromptProvider(config)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+
+	if !cliArgs.IsTransformCommand {
+		if cliArgs.TargetLanguage, err = validateLanguage(cliArgs.TargetLanguage, provider); err != nil {
+			return err
+		}
+	}
+
+	if content == "" {
+		if content, err = readTranslationInput(cliArgs); err != nil {
+			return err
+		}
+	}
+
+	cliArgs.NoCache = true // Nothing is translated, so there is nothing to look up
+	options, err := newTranslationOptions(cliArgs, config)
+	if err != nil {
+		return err
+	}
+
+	return writePromptPreview(os.Stdout, provider, content, options, chunkTokenBudget(cliArgs, config))
+}
+
+// newTranslationOptions builds the translation options shared by every document of a run
+func newTranslationOptions(cliArgs *CLIArgs, config ProviderConfig) (TranslationOptions, error) {
+	options := TranslationOptions{
+		TargetLanguage:    cliArgs.TargetLanguage,
+		CustomInstruction: cliArgs.TransformInstruction,
+		Transform:         cliArgs.IsTransformCommand,
+		PreserveFormat:    true,
+		Verbose:           verbose,
+		Protect:           !cliArgs.NoProtect,
+	}
+
+	if !cliArgs.NoCache {
+		options.Cache = newTranslationCache(config)
+	}
+
+	if cliArgs.GlossaryFile != "" {
+		glossary, err := loadGlossary(cliArgs.GlossaryFile)
+		if err != nil {
+			return options, err
+		}
+		options.GlossaryFile = cliArgs.GlossaryFile
+		options.Glossary = glossary
+	}
+
+	if cliArgs.Temperature != nil {
+		if err := validateTemperature(config.ProviderType, *cliArgs.Temperature); err != nil {
+			return options, withExitCode(exitUsage, err)
+		}
+		options.Temperature = cliArgs.Temperature
+	}
+
+	if cliArgs.DebugDumpDir != "" {
+		if config.ProviderType != ProviderTypeClaude {
+			return options, withExitCode(exitUsage, fmt.Errorf("--debug-dump is only supported by the %s provider", ProviderTypeClaude))
+		}
+		if info, err := os.Stat(cliArgs.DebugDumpDir); err != nil || !info.IsDir() {
+			return options, withExitCode(exitUsage, fmt.Errorf("--debug-dump directory does not exist: %s", cliArgs.DebugDumpDir))
+		}
+		options.DebugDumpDir = cliArgs.DebugDumpDir
+	}
+
+	if cliArgs.MaxTokens > 0 {
+		if config.ProviderType == ProviderTypeClaude {
+			return options, withExitCode(exitUsage, fmt.Errorf("--max-tokens is not supported by the %s provider", config.ProviderType))
+		}
+		options.MaxTokens = cliArgs.MaxTokens
+	}
+
+	return options, nil
+}
+
+// applyProviderOverrides applies the provider and model given with --provider and --model
+func applyProviderOverrides(cliArgs *CLIArgs, cfg *ProviderConfig) error {
+	if cliArgs.Provider != "" {
+		cfg.ProviderType = cliArgs.Provider
+		log("Using provider from --provider: %s", cliArgs.Provider)
+	}
+
+	if cliArgs.Model == "" {
+		return nil
+	}
+	if err := validateModel(*cfg, cfg.ProviderType, cliArgs.Model); err != nil {
+		return withExitCode(exitUsage, err)
+	}
+
+	setConfiguredModel(cfg, cfg.ProviderType, cliArgs.Model)
+	log("Using model from --model: %s", cliArgs.Model)
+	return nil
+}
+
+// applyAutoModel selects the cheapest catalog model of the configured provider whose context window
+// fits content, keeping the configured model if none does
+func applyAutoModel(cfg *ProviderConfig, content string) error {
+	if cfg.ProviderType != ProviderTypeOpenAI && cfg.ProviderType != ProviderTypeAnthropic {
+		return withExitCode(exitUsage, fmt.Errorf("--auto-model requires the openai or anthropic provider, not %s", cfg.ProviderType))
+	}
+	// Gateways and Azure deployments serve models outside the catalog
+	if cfg.ProviderType == ProviderTypeOpenAI && (cfg.OpenAIBaseURL != config.DefaultOpenAIBaseURL || usesAzureOpenAI(*cfg)) {
+		return withExitCode(exitUsage, fmt.Errorf("--auto-model is not supported with a custom openai_base_url or Azure OpenAI"))
+	}
+
+	model := RecommendModel(cfg.ProviderType, utf8.RuneCountInString(content), "")
+	if model == nil {
+		fmt.Fprintf(os.Stderr, "Warning: no %s model fits the whole document; keeping %s and translating in chunks\n",
+			cfg.ProviderType, configuredModel(*cfg, cfg.ProviderType))
+		return nil
+	}
+
+	setConfiguredModel(cfg, cfg.ProviderType, model.ID)
+	log("Auto-selected model %s (%s, %d token context, $%.2f/$%.2f per 1M tokens)",
+		model.ID, model.Tier, model.ContextWindow, model.InputCostPer1M, model.OutputCostPer1M)
 	return nil
 }
 
-// validateLanguage validates the target language code
-func validateLanguage(targetLang string, provider LLMProvider) error {
+// chunkTokenBudget returns the --max-chunk-tokens override or the default chunk size for the configured model
+func chunkTokenBudget(cliArgs *CLIArgs, config ProviderConfig) int {
+	if cliArgs.MaxChunkTokens > 0 {
+		return cliArgs.MaxChunkTokens
+	}
+
+	model := configuredModel(config, config.ProviderType)
+	if model == "" {
+		model = GetDefaultModel(config.ProviderType)
+	}
+	return defaultChunkTokens(config.ProviderType, model)
+}
+
+// validateLanguage resolves aliases and validates the target language code, returning the canonical code
+func validateLanguage(targetLang string, provider LLMProvider) (string, error) {
+	// Accept language names and aliases such as "Japanese", "JA" or "jp"
+	if code, ok := resolveLanguageCode(targetLang); ok {
+		if code != targetLang {
+			log("Resolved language %q to %s", targetLang, code)
+		}
+		targetLang = code
+	}
+
 	supportedLangs := provider.GetSupportedLanguages()
 	if err := validateLanguageCodeWithMap(targetLang, supportedLangs); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -141,9 +505,9 @@ func validateLanguage(targetLang string, provider LLMProvider) error {
 		}
 
 		fmt.Fprintf(os.Stderr, "\nUse 'doc --list' to see all supported language codes.\n")
-		return err
+		return "", withExitCode(exitUsage, err)
 	}
-	return nil
+	return targetLang, nil
 }
 
 // showCurrentConfig displays the current configuration
@@ -151,14 +515,64 @@ func showCurrentConfig() {
 	cfg := LoadConfig()
 	fmt.Printf("Current Configuration:\n")
 	fmt.Printf("Config file: %s\n", config.GetConfigPath())
+	if profile := config.Profile(); profile != "" {
+		fmt.Printf("Profile: %s (%s)\n", profile, config.GetProfilePath(profile))
+	}
 	fmt.Printf("\n")
 	fmt.Printf("provider = \"%s\"\n", cfg.ProviderType)
 	fmt.Printf("claude_code_path = \"%s\"\n", cfg.ClaudeCodePath)
 	fmt.Printf("openai_model = \"%s\"\n", cfg.OpenAIModel)
 	fmt.Printf("anthropic_model = \"%s\"\n", cfg.AnthropicModel)
 	fmt.Printf("claude_model = \"%s\"\n", cfg.ClaudeModel)
+	fmt.Printf("ollama_model = \"%s\"\n", cfg.OllamaModel)
 	fmt.Printf("openai_api_key = \"%s\"\n", maskAPIKey(cfg.OpenAIAPIKey))
 	fmt.Printf("anthropic_api_key = \"%s\"\n", maskAPIKey(cfg.AnthropicAPIKey))
+	fmt.Printf("openai_api_key_file = \"%s\"\n", cfg.OpenAIAPIKeyFile)
+	fmt.Printf("anthropic_api_key_file = \"%s\"\n", cfg.AnthropicAPIKeyFile)
+	fmt.Printf("openai_base_url = \"%s\"\n", cfg.OpenAIBaseURL)
+	fmt.Printf("azure_endpoint = \"%s\"\n", cfg.AzureEndpoint)
+	fmt.Printf("azure_deployment = \"%s\"\n", cfg.AzureDeployment)
+	fmt.Printf("azure_api_version = \"%s\"\n", cfg.AzureAPIVersion)
+	fmt.Printf("ollama_base_url = \"%s\"\n", cfg.OllamaBaseURL)
+	fmt.Printf("openai_max_retries = %d\n", cfg.OpenAIMaxRetries)
+	fmt.Printf("http_timeout_seconds = %d\n", cfg.HTTPTimeoutSeconds)
+}
+
+// showProfiles lists the available config profiles
+func showProfiles() {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	if len(profiles) == 0 {
+		fmt.Printf("No profiles found in %s\n", config.GetConfigDir())
+		fmt.Printf("Create one with: doc --profile NAME --set key=value\n")
+		return
+	}
+
+	fmt.Printf("Profiles in %s:\n", config.GetConfigDir())
+	for _, profile := range profiles {
+		fmt.Printf("  %s\n", profile)
+	}
+}
+
+// clearCache removes all cached translations
+func clearCache() {
+	dir := getCacheDir()
+	if dir == "" {
+		fmt.Fprintf(os.Stderr, "Error: could not determine cache directory\n")
+		os.Exit(exitFailure)
+	}
+
+	removed, err := clearTranslationCache(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	fmt.Printf("Removed %d cached translation(s) from %s\n", removed, dir)
 }
 
 // initConfigFile creates a default configuration file
@@ -173,17 +587,9 @@ func initConfigFile() {
 	}
 
 	// Create default config
-	defaultConfig := config.Config{
-		ProviderType:   config.ProviderTypeClaude,
-		ClaudeCodePath: "claude",
-		OpenAIModel:    config.GetDefaultModel(config.ProviderTypeOpenAI),
-		AnthropicModel: config.GetDefaultModel(config.ProviderTypeAnthropic),
-		ClaudeModel:    config.GetDefaultModel(config.ProviderTypeClaude),
-	}
-
-	if err := config.SaveConfig(defaultConfig); err != nil {
+	if err := config.SaveConfig(config.Defaults()); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating config file: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 
 	fmt.Printf("Created default configuration file at: %s\n", configPath)
@@ -195,29 +601,35 @@ func initConfigFile() {
 func setConfigValues(keyValuePairs []string) {
 	// Load current config
 	currentConfig := LoadConfig()
+	updates := map[string]any{}
 
 	// Parse and apply changes
 	for _, pair := range keyValuePairs {
 		parts := strings.SplitN(pair, "=", 2)
 		if len(parts) != 2 {
 			fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Use key=value format.\n", pair)
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
+		var typedValue any = value
 
 		switch key {
 		case "provider":
-			if value != config.ProviderTypeClaude && value != config.ProviderTypeOpenAI && value != config.ProviderTypeAnthropic {
-				fmt.Fprintf(os.Stderr, "Error: Invalid provider '%s'. Must be one of: claude-code, openai, anthropic\n", value)
-				os.Exit(1)
+			if !slices.Contains(providerTypes, value) {
+				fmt.Fprintf(os.Stderr, "Error: Invalid provider '%s'. Must be one of: %s\n", value, strings.Join(providerTypes, ", "))
+				os.Exit(exitUsage)
 			}
 			currentConfig.ProviderType = value
 		case "openai_api_key":
 			currentConfig.OpenAIAPIKey = value
 		case "anthropic_api_key":
 			currentConfig.AnthropicAPIKey = value
+		case "openai_api_key_file":
+			currentConfig.OpenAIAPIKeyFile = value
+		case "anthropic_api_key_file":
+			currentConfig.AnthropicAPIKeyFile = value
 		case "claude_code_path":
 			currentConfig.ClaudeCodePath = value
 		case "openai_model":
@@ -226,27 +638,151 @@ func setConfigValues(keyValuePairs []string) {
 			currentConfig.AnthropicModel = value
 		case "claude_model":
 			currentConfig.ClaudeModel = value
+		case "ollama_model":
+			currentConfig.OllamaModel = value
+		case "ollama_base_url":
+			if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+				fmt.Fprintf(os.Stderr, "Error: Invalid ollama_base_url '%s'. Must start with http:// or https://\n", value)
+				os.Exit(exitUsage)
+			}
+			currentConfig.OllamaBaseURL = value
+		case "openai_base_url":
+			if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+				fmt.Fprintf(os.Stderr, "Error: Invalid openai_base_url '%s'. Must start with http:// or https://\n", value)
+				os.Exit(exitUsage)
+			}
+			currentConfig.OpenAIBaseURL = value
+		case "azure_endpoint":
+			if !strings.HasPrefix(value, "https://") {
+				fmt.Fprintf(os.Stderr, "Error: Invalid azure_endpoint '%s'. Must start with https://\n", value)
+				os.Exit(exitUsage)
+			}
+			currentConfig.AzureEndpoint = value
+		case "azure_deployment":
+			currentConfig.AzureDeployment = value
+		case "azure_api_version":
+			currentConfig.AzureAPIVersion = value
+		case "openai_max_retries":
+			retries, err := strconv.Atoi(value)
+			if err != nil || retries < 0 {
+				fmt.Fprintf(os.Stderr, "Error: Invalid openai_max_retries '%s'. Must be a non-negative integer\n", value)
+				os.Exit(exitUsage)
+			}
+			currentConfig.OpenAIMaxRetries = retries
+			typedValue = retries
+		case "http_timeout_seconds":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				fmt.Fprintf(os.Stderr, "Error: Invalid http_timeout_seconds '%s'. Must be a positive integer\n", value)
+				os.Exit(exitUsage)
+			}
+			currentConfig.HTTPTimeoutSeconds = seconds
+			typedValue = seconds
 		default:
 			fmt.Fprintf(os.Stderr, "Error: Unknown configuration key '%s'\n", key)
-			fmt.Fprintf(os.Stderr, "Valid keys: provider, openai_api_key, anthropic_api_key, claude_code_path, openai_model, anthropic_model, claude_model\n")
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "Valid keys: %s\n", strings.Join(configKeys, ", "))
+			os.Exit(exitUsage)
 		}
 
+		updates[key] = typedValue
 		fmt.Printf("Set %s = %s\n", key, maskConfigValue(key, value))
 	}
 
-	// Save updated config
-	if err := config.SaveConfig(currentConfig); err != nil {
+	// A profile only stores the keys set in it, so the rest keep following the base config
+	var err error
+	if profile := config.Profile(); profile != "" {
+		err = config.UpdateProfile(profile, updates, nil)
+	} else {
+		err = config.SaveConfig(currentConfig)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
+		os.Exit(exitConfig)
+	}
+
+	fmt.Printf("Configuration updated successfully\n")
+}
+
+// configKeys lists the keys accepted by --set and --unset
+var configKeys = []string{
+	"provider", "openai_api_key", "anthropic_api_key", "openai_api_key_file", "anthropic_api_key_file",
+	"claude_code_path", "openai_model", "anthropic_model", "claude_model", "ollama_model",
+	"openai_base_url", "azure_endpoint", "azure_deployment", "azure_api_version", "ollama_base_url",
+	"openai_max_retries", "http_timeout_seconds",
+}
+
+// unsetConfigValues resets configuration keys to their default values
+func unsetConfigValues(keys []string) {
+	currentConfig := LoadConfig()
+	defaults := config.Defaults()
+
+	for _, key := range keys {
+		var old string
+		switch key {
+		case "provider":
+			old, currentConfig.ProviderType = currentConfig.ProviderType, defaults.ProviderType
+		case "openai_api_key":
+			old, currentConfig.OpenAIAPIKey = currentConfig.OpenAIAPIKey, defaults.OpenAIAPIKey
+		case "anthropic_api_key":
+			old, currentConfig.AnthropicAPIKey = currentConfig.AnthropicAPIKey, defaults.AnthropicAPIKey
+		case "openai_api_key_file":
+			old, currentConfig.OpenAIAPIKeyFile = currentConfig.OpenAIAPIKeyFile, defaults.OpenAIAPIKeyFile
+		case "anthropic_api_key_file":
+			old, currentConfig.AnthropicAPIKeyFile = currentConfig.AnthropicAPIKeyFile, defaults.AnthropicAPIKeyFile
+		case "claude_code_path":
+			old, currentConfig.ClaudeCodePath = currentConfig.ClaudeCodePath, defaults.ClaudeCodePath
+		case "openai_model":
+			old, currentConfig.OpenAIModel = currentConfig.OpenAIModel, defaults.OpenAIModel
+		case "anthropic_model":
+			old, currentConfig.AnthropicModel = currentConfig.AnthropicModel, defaults.AnthropicModel
+		case "claude_model":
+			old, currentConfig.ClaudeModel = currentConfig.ClaudeModel, defaults.ClaudeModel
+		case "ollama_model":
+			old, currentConfig.OllamaModel = currentConfig.OllamaModel, defaults.OllamaModel
+		case "openai_base_url":
+			old, currentConfig.OpenAIBaseURL = currentConfig.OpenAIBaseURL, defaults.OpenAIBaseURL
+		case "azure_endpoint":
+			old, currentConfig.AzureEndpoint = currentConfig.AzureEndpoint, defaults.AzureEndpoint
+		case "azure_deployment":
+			old, currentConfig.AzureDeployment = currentConfig.AzureDeployment, defaults.AzureDeployment
+		case "azure_api_version":
+			old, currentConfig.AzureAPIVersion = currentConfig.AzureAPIVersion, defaults.AzureAPIVersion
+		case "ollama_base_url":
+			old, currentConfig.OllamaBaseURL = currentConfig.OllamaBaseURL, defaults.OllamaBaseURL
+		case "openai_max_retries":
+			old = strconv.Itoa(currentConfig.OpenAIMaxRetries)
+			currentConfig.OpenAIMaxRetries = defaults.OpenAIMaxRetries
+		case "http_timeout_seconds":
+			old = strconv.Itoa(currentConfig.HTTPTimeoutSeconds)
+			currentConfig.HTTPTimeoutSeconds = defaults.HTTPTimeoutSeconds
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown configuration key '%s'\n", key)
+			fmt.Fprintf(os.Stderr, "Valid keys: %s\n", strings.Join(configKeys, ", "))
+			os.Exit(exitUsage)
+		}
+
+		fmt.Printf("Unset %s (was %s)\n", key, maskConfigValue(key, old))
+	}
+
+	// Removing a key from a profile falls back to the base config rather than the default
+	var err error
+	if profile := config.Profile(); profile != "" {
+		err = config.UpdateProfile(profile, nil, keys)
+	} else {
+		err = config.SaveConfig(currentConfig)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 
 	fmt.Printf("Configuration updated successfully\n")
 }
 
-// maskConfigValue masks sensitive configuration values for display
+// maskConfigValue masks sensitive configuration values for display. Every value of an API key,
+// such as openai_api_key, is masked whatever its length; key file paths are shown as they are.
 func maskConfigValue(key, value string) string {
-	if strings.Contains(key, "api_key") && value != "" {
+	if strings.HasSuffix(key, "_api_key") {
 		return maskAPIKey(value)
 	}
 	return value
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -65,3 +67,17 @@ func TestGetSimilarLanguageCodesWithMap(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionString(t *testing.T) {
+	originalVersion, originalCommit, originalDate := version, commit, date
+	defer func() { version, commit, date = originalVersion, originalCommit, originalDate }()
+
+	version, commit, date = "1.2.3", "abc1234", "2024-06-01T00:00:00Z"
+	output := versionString()
+
+	for _, want := range []string{"doc version 1.2.3", "commit: abc1234", "built: 2024-06-01T00:00:00Z", runtime.Version()} {
+		if !strings.Contains(output, want) {
+			t.Errorf("versionString() = %q, missing %q", output, want)
+		}
+	}
+}
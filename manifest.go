@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// mergeManifest describes a merged document, as written by --manifest
+type mergeManifest struct {
+	Output           string         `json:"output"`
+	GeneratedAt      time.Time      `json:"generated_at"`
+	Files            []manifestFile `json:"files"`
+	TotalFiles       int            `json:"total_files"`
+	TotalSourceBytes int64          `json:"total_source_bytes"`
+	OutputBytes      int            `json:"output_bytes"`
+}
+
+// manifestFile is one merged source file and where its content begins in the output
+type manifestFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Offset   int       `json:"offset"` // Byte offset of the file's content in the output
+}
+
+// writeMergeManifest writes the --manifest JSON for files, whose content starts at the given byte
+// offsets of an output of outputBytes bytes
+func writeMergeManifest(cliArgs *CLIArgs, files []MarkdownFile, offsets []int, outputBytes int) error {
+	manifest := mergeManifest{
+		Output:      filepath.ToSlash(cliArgs.MergeOutputFile),
+		GeneratedAt: time.Now().Truncate(time.Second),
+		Files:       make([]manifestFile, 0, len(files)),
+		TotalFiles:  len(files),
+		OutputBytes: outputBytes,
+	}
+	for i, file := range files {
+		manifest.Files = append(manifest.Files, manifestFile{
+			Path:     filepath.ToSlash(sourcePath(cliArgs, file)),
+			Size:     file.Size,
+			Modified: file.ModTime.Truncate(time.Second),
+			Offset:   offsets[i],
+		})
+		manifest.TotalSourceBytes += file.Size
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return os.WriteFile(cliArgs.MergeManifest, buf.Bytes(), 0644)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMergeManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{"01-intro.md": "# Intro\n\nWelcome.\n", "02-usage.md": "# Usage\n\nRun it.\n"}
+	for name, content := range contents {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tempDir, "book.md")
+	manifestPath := filepath.Join(tempDir, "manifest.json")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2, MergeAdjustHeaders: true},
+		[]string{docs, output, "--include-meta", "--manifest", manifestPath, "-q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest mergeManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Invalid manifest JSON: %v\n%s", err, data)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.TotalFiles != 2 || manifest.OutputBytes != len(merged) {
+		t.Errorf("Expected 2 files and %d output bytes, got %d and %d", len(merged), manifest.TotalFiles, manifest.OutputBytes)
+	}
+	// Each offset points at the file's content, after its source comment
+	for i, want := range []string{"## Intro", "## Usage"} {
+		file := manifest.Files[i]
+		if !strings.HasPrefix(string(merged[file.Offset:]), want) {
+			t.Errorf("Content of %s at offset %d = %q, want prefix %q", file.Path, file.Offset, merged[file.Offset:], want)
+		}
+		if file.Size != int64(len(contents[file.Path])) {
+			t.Errorf("Size of %s = %d, want %d", file.Path, file.Size, len(contents[file.Path]))
+		}
+	}
+}
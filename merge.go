@@ -1,184 +1,766 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // runMerge executes the merge command
 func runMerge(cliArgs *CLIArgs) error {
 	if cliArgs.Verbose {
 		log("Starting merge operation")
-		log("Directory: %s", cliArgs.MergeDirectory)
+		log("Directories: %s", strings.Join(cliArgs.MergeDirectories, ", "))
 		log("Output file: %s", cliArgs.MergeOutputFile)
 		log("Order: %s", cliArgs.MergeOrder)
 		log("Recursive: %v", cliArgs.MergeRecursive)
 	}
 
-	// Create file scanner
-	scanner := &FileScanner{
-		Directory:       cliArgs.MergeDirectory,
-		Recursive:       cliArgs.MergeRecursive,
-		IncludePatterns: cliArgs.MergeIncludePatterns,
-		ExcludePatterns: cliArgs.MergeExcludePatterns,
+	if cliArgs.MergeSeparatorFile != "" {
+		separator, err := os.ReadFile(cliArgs.MergeSeparatorFile)
+		if err != nil {
+			return fmt.Errorf("failed to read separator file: %w", err)
+		}
+		cliArgs.MergeSeparator = string(separator)
+		if !cliArgs.MergeKeepCRLF {
+			cliArgs.MergeSeparator = normalizeLineEndings(cliArgs.MergeSeparator)
+		}
 	}
 
-	// Scan for markdown files
-	log("Scanning directory: %s", cliArgs.MergeDirectory)
-	files, err := scanner.ScanMarkdownFiles()
-	if err != nil {
-		return fmt.Errorf("failed to scan directory: %w", err)
+	// Directories are concatenated in argument order, each sorted on its own
+	var sortedFiles, oversized []MarkdownFile
+	if cliArgs.MergeFromStdin {
+		if !isStdinPiped() {
+			return fmt.Errorf("--from-stdin requires a list of files on stdin")
+		}
+		files, err := listedMergeFiles(cliArgs, os.Stdin)
+		if err != nil {
+			return err
+		}
+		sortedFiles = files
+	}
+	for _, dir := range cliArgs.MergeDirectories {
+		files, skipped, err := scanMergeDirectory(cliArgs, dir)
+		if err != nil {
+			return err
+		}
+		sortedFiles = append(sortedFiles, files...)
+		oversized = append(oversized, skipped...)
 	}
 
-	if len(files) == 0 {
-		return fmt.Errorf("no markdown files found in directory: %s", cliArgs.MergeDirectory)
+	// The title, TOC and merge passes all need the content, so read every file once up front
+	if !cliArgs.MergeDryRun {
+		loadFileContents(sortedFiles)
 	}
 
-	log("Found %d markdown files", len(files))
+	// A previous merge into the scanned tree must not be merged into the next one, and a binary
+	// file with a markdown extension would write garbage into the output
+	sortedFiles = slices.DeleteFunc(sortedFiles, func(file MarkdownFile) bool {
+		if isMergeOutput(cliArgs, file) {
+			log("Skipping %s (output of a previous doc merge)", sourcePath(cliArgs, file))
+			return true
+		}
+		if !file.isText() {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s (binary or not valid UTF-8)\n", sourcePath(cliArgs, file))
+			return true
+		}
+		return false
+	})
 
-	// Sort files
-	sortedFiles := SortMarkdownFiles(files, cliArgs.MergeOrder)
+	if len(sortedFiles) == 0 {
+		if cliArgs.MergeFromStdin {
+			return fmt.Errorf("no files listed on stdin")
+		}
+		return fmt.Errorf("no markdown files found in directory: %s", strings.Join(cliArgs.MergeDirectories, ", "))
+	}
+
+	log("Found %d markdown files", len(sortedFiles))
+
+	// Only the headers are needed, so the merged document is never rendered
+	if cliArgs.MergeTOCOnly && !cliArgs.MergeDryRun {
+		return runTOCOnly(cliArgs, sortedFiles)
+	}
+
+	if cliArgs.MergeDedupeAnchors {
+		renamed := dedupeAnchors(cliArgs, sortedFiles)
+		log("Renamed %d heading(s) whose anchors collided across files", renamed)
+	}
 
 	if cliArgs.Verbose {
 		log("Files to merge (in order):")
 		for i, file := range sortedFiles {
-			relPath, _ := filepath.Rel(cliArgs.MergeDirectory, file.Path)
-			log("  %d. %s (%d bytes)", i+1, relPath, file.Size)
+			log("  %d. %s (%d bytes)", i+1, sourcePath(cliArgs, file), file.Size)
 		}
 	}
 
 	// Dry run mode
 	if cliArgs.MergeDryRun {
-		return runDryMode(cliArgs, sortedFiles)
+		return runDryMode(cliArgs, sortedFiles, oversized)
+	}
+
+	// Check mode
+	if cliArgs.MergeCheck {
+		return runCheckMode(cliArgs, sortedFiles)
+	}
+
+	if err := checkMergeOverwrite(cliArgs); err != nil {
+		return err
 	}
 
 	// Merge files
 	return mergeFiles(cliArgs, sortedFiles)
 }
 
+// scanMergeDirectory returns the markdown files of one input directory in merge order, and the
+// files skipped for exceeding --max-file-size
+func scanMergeDirectory(cliArgs *CLIArgs, dir string) ([]MarkdownFile, []MarkdownFile, error) {
+	scanner := &FileScanner{
+		Directory:       dir,
+		Recursive:       cliArgs.MergeRecursive,
+		IncludePatterns: cliArgs.MergeIncludePatterns,
+		ExcludePatterns: cliArgs.MergeExcludePatterns,
+		ExcludeDirs:     cliArgs.MergeExcludeDirs,
+		// Recursive scans of a repository would otherwise pick up generated and vendored files
+		RespectGitignore: (cliArgs.MergeRecursive || cliArgs.MergeRespectGitignore) && !cliArgs.MergeNoGitignore,
+		Extensions:       cliArgs.MergeExtensions,
+		MaxFileSize:      cliArgs.MergeMaxFileSize,
+	}
+
+	log("Scanning directory: %s", dir)
+	files, err := scanner.ScanMarkdownFiles()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+	for _, file := range scanner.Oversized {
+		log("Warning: skipping %s (%s exceeds --max-file-size %s)",
+			sourcePath(cliArgs, file), formatFileSize(file.Size), formatFileSize(cliArgs.MergeMaxFileSize))
+	}
+
+	sortedFiles := SortMarkdownFiles(files, cliArgs.MergeOrder, cliArgs.MergeReverse)
+	if cliArgs.MergeOrder == "custom" {
+		if cliArgs.MergeReverse {
+			log("Warning: --reverse is ignored with --order custom")
+		}
+		order, err := ReadDocOrder(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		sortedFiles = ApplyDocOrder(sortedFiles, dir, order)
+	}
+
+	return sortedFiles, scanner.Oversized, nil
+}
+
+// generatedMarkerScanBytes bounds how much of an existing output file is read to find mergeMarker
+const generatedMarkerScanBytes = 64 * 1024
+
+// mergeMarker starts the provenance comment on the first line of every file doc merge writes
+const mergeMarker = "<!-- Generated by doc merge"
+
+// mergeProvenance is the first line of every file doc merge writes; --include-meta adds the time
+const mergeProvenance = mergeMarker + " -->\n"
+
+// isMergeOutput reports whether file was written by doc merge: it is this run's output or TOC
+// file, or it starts with the provenance comment
+func isMergeOutput(cliArgs *CLIArgs, file MarkdownFile) bool {
+	for _, output := range []string{cliArgs.MergeOutputFile, cliArgs.MergeTOCFile} {
+		if output != "" && absPath(output) == absPath(file.Path) {
+			return true
+		}
+	}
+
+	content, err := file.readContent()
+	return err == nil && isMergeDocument(content)
+}
+
+// hasMergeMarker reports whether the file at path starts with the provenance comment
+func hasMergeMarker(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+
+	head, err := io.ReadAll(io.LimitReader(f, generatedMarkerScanBytes))
+	return err == nil && isMergeDocument(head)
+}
+
+// isMergeDocument reports whether content starts with mergeMarker. Only the first non-blank line
+// counts, or the line after a leading H1 where older versions wrote it with --include-meta, so
+// documents quoting the marker, e.g. in a code block, are not taken for merge output.
+func isMergeDocument(content []byte) bool {
+	lines := 0
+	for line := range strings.Lines(string(content)) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, mergeMarker) {
+			return true
+		}
+		if lines++; lines == 2 || atxHeadingLevel(trimmed) != 1 {
+			return false
+		}
+	}
+	return false
+}
+
+// checkMergeOverwrite guards an existing output file that doc merge did not write, such as a
+// hand-edited copy: on a terminal it asks before overwriting, elsewhere it requires --force
+func checkMergeOverwrite(cliArgs *CLIArgs) error {
+	output := cliArgs.MergeOutputFile
+	if cliArgs.MergeForce || hasMergeMarker(output) {
+		return nil
+	}
+	if _, err := os.Stat(output); err != nil {
+		return nil
+	}
+
+	if isStdinPiped() || !isTerminal() {
+		return fmt.Errorf("output file already exists and was not written by doc merge: %s (use --force to overwrite)", output)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s already exists and was not written by doc merge. Overwrite it? [y/N] ", output)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("not overwriting %s", output)
+	}
+	return nil
+}
+
+// listedMergeFiles reads the paths of the files to merge from r, one per line, and returns the
+// files in merge order. With --order custom the listed order is kept.
+func listedMergeFiles(cliArgs *CLIArgs, r io.Reader) ([]MarkdownFile, error) {
+	var files []MarkdownFile
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("listed file does not exist: %s", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read listed file %s: %w", path, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("listed path is a directory: %s", path)
+		}
+
+		files = append(files, MarkdownFile{
+			Path:    path,
+			Name:    info.Name(),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+			Root:    ".",
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file list: %w", err)
+	}
+
+	log("Read %d file paths from stdin", len(files))
+	if cliArgs.MergeOrder == "custom" {
+		return files, nil
+	}
+	return SortMarkdownFiles(files, cliArgs.MergeOrder, cliArgs.MergeReverse), nil
+}
+
+// sourcePath returns the path of a merged file as shown in listings and comments: relative to
+// its source directory, and prefixed with that directory when merging several
+func sourcePath(cliArgs *CLIArgs, file MarkdownFile) string {
+	relPath, err := filepath.Rel(file.Root, file.Path)
+	if err != nil {
+		return file.Path
+	}
+	if len(cliArgs.MergeDirectories) > 1 {
+		return filepath.Join(file.Root, relPath)
+	}
+	return relPath
+}
+
 // runDryMode shows what would be merged without actually doing it
-func runDryMode(cliArgs *CLIArgs, files []MarkdownFile) error {
+func runDryMode(cliArgs *CLIArgs, files, oversized []MarkdownFile) error {
 	fmt.Printf("[DRY RUN] Would process the following files:\n")
-	
+
 	totalSize := int64(0)
 	for i, file := range files {
-		relPath, _ := filepath.Rel(cliArgs.MergeDirectory, file.Path)
 		size := formatFileSize(file.Size)
-		fmt.Printf("  %d. %s (%s)\n", i+1, relPath, size)
+		fmt.Printf("  %d. %s (%s)\n", i+1, sourcePath(cliArgs, file), size)
 		totalSize += file.Size
 	}
-	
-	fmt.Printf("[DRY RUN] Output file: %s\n", cliArgs.MergeOutputFile)
+
+	output := cliArgs.MergeOutputFile
+	if output == "" {
+		output = "stdout" // --toc-only without an output file
+	}
+	fmt.Printf("[DRY RUN] Output file: %s%s\n", output, dryRunOutputStatus(cliArgs))
+	if cliArgs.MergeTOCFile != "" {
+		fmt.Printf("[DRY RUN] TOC file: %s\n", cliArgs.MergeTOCFile)
+	}
 	fmt.Printf("[DRY RUN] Total size: %s\n", formatFileSize(totalSize))
-	
+	for _, file := range oversized {
+		fmt.Printf("[DRY RUN] Skipped (over %s): %s (%s)\n",
+			formatFileSize(cliArgs.MergeMaxFileSize), sourcePath(cliArgs, file), formatFileSize(file.Size))
+	}
+
 	return nil
 }
 
-// mergeFiles merges the markdown files into a single output file
-func mergeFiles(cliArgs *CLIArgs, files []MarkdownFile) error {
-	// Create output file
-	outputFile, err := os.Create(cliArgs.MergeOutputFile)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+// dryRunOutputStatus notes whether the output file exists and what a merge would do with it
+func dryRunOutputStatus(cliArgs *CLIArgs) string {
+	if cliArgs.MergeOutputFile == "" {
+		return ""
 	}
-	defer outputFile.Close()
+	if _, err := os.Stat(cliArgs.MergeOutputFile); err != nil {
+		return " (new file)"
+	}
+	if cliArgs.MergeForce || hasMergeMarker(cliArgs.MergeOutputFile) {
+		return " (exists, will be overwritten)"
+	}
+	return " (exists, not written by doc merge; will ask before overwriting or need --force)"
+}
 
+// mergeFiles merges the markdown files into a single output file
+func mergeFiles(cliArgs *CLIArgs, files []MarkdownFile) error {
 	// Start progress indication
 	spinner := NewSpinner(fmt.Sprintf("Merging files... (0/%d)", len(files)))
 	spinner.Start()
 
-	// Write document title and metadata
-	if err := writeDocumentHeader(outputFile, cliArgs, files); err != nil {
+	var buf bytes.Buffer
+	offsets := make([]int, len(files))
+	err := renderMerge(&buf, cliArgs, files, func(i int, file MarkdownFile) {
+		spinner.Update(fmt.Sprintf("Processing files... (%d/%d) - %s", i+1, len(files), file.Name))
+		offsets[i] = buf.Len() + len(sourceComment(cliArgs, file))
+	})
+	if err != nil {
 		spinner.Stop("Merge failed")
-		return fmt.Errorf("failed to write document header: %w", err)
+		return err
 	}
 
-	// Write table of contents if requested
-	if cliArgs.MergeGenerateTOC {
-		if err := writeTOC(outputFile, cliArgs, files); err != nil {
+	// Write output file
+	output := formatMergeOutput(cliArgs, files, buf.String())
+	if err := os.WriteFile(cliArgs.MergeOutputFile, []byte(output), 0644); err != nil {
+		spinner.Stop("Merge failed")
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	// Write table of contents to a separate file if requested
+	if cliArgs.MergeTOCFile != "" {
+		if err := writeTOCFile(cliArgs, files); err != nil {
 			spinner.Stop("Merge failed")
+			return fmt.Errorf("failed to write table of contents file: %w", err)
+		}
+		log("Table of contents written to %s", cliArgs.MergeTOCFile)
+	}
+
+	if cliArgs.MergeManifest != "" {
+		if err := writeMergeManifest(cliArgs, files, offsets, len(output)); err != nil {
+			spinner.Stop("Merge failed")
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+		log("Manifest written to %s", cliArgs.MergeManifest)
+	}
+
+	finalMessage := fmt.Sprintf("Merge completed - Output: %s (%s)", cliArgs.MergeOutputFile, formatFileSize(int64(len(output))))
+	spinner.Stop(finalMessage)
+
+	return nil
+}
+
+// runCheckMode merges in memory and compares the result with the existing output file.
+// Like gofmt -l, it writes nothing and reports a diff when the output is out of date.
+func runCheckMode(cliArgs *CLIArgs, files []MarkdownFile) error {
+	var buf bytes.Buffer
+	if err := renderMerge(&buf, cliArgs, files, nil); err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(cliArgs.MergeOutputFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	current := normalizeMergeOutput(string(existing))
+	expected := normalizeMergeOutput(formatMergeOutput(cliArgs, files, buf.String()))
+	if current == expected {
+		log("Output file %s is up to date", cliArgs.MergeOutputFile)
+		return nil
+	}
+
+	fmt.Print(unifiedDiff(cliArgs.MergeOutputFile, cliArgs.MergeOutputFile+" (merged)", current, expected))
+	return fmt.Errorf("merged output %s is out of date", cliArgs.MergeOutputFile)
+}
+
+// formatMergeOutput converts the merged markdown to the --format output
+func formatMergeOutput(cliArgs *CLIArgs, files []MarkdownFile, markdown string) string {
+	if cliArgs.MergeFormat != "html" {
+		return markdown
+	}
+	// The provenance comment stays on the first line, ahead of the doctype
+	provenance, body, _ := strings.Cut(markdown, "\n")
+	title, _ := resolveDocumentTitle(cliArgs, files)
+	return provenance + "\n" + htmlDocument(title, markdownToHTML(body))
+}
+
+// generatedAtPattern matches the generation timestamp in merge metadata
+var generatedAtPattern = regexp.MustCompile(`<!-- Generated by doc merge at [^>]* -->`)
+
+// normalizeMergeOutput removes volatile parts of merge output before comparison
+func normalizeMergeOutput(content string) string {
+	return generatedAtPattern.ReplaceAllString(content, "<!-- Generated by doc merge -->")
+}
+
+// renderMerge writes the merged document to w.
+// onFile, when set, is called before each file is merged.
+func renderMerge(w io.Writer, cliArgs *CLIArgs, files []MarkdownFile, onFile func(i int, file MarkdownFile)) error {
+	// Resolve the document title, possibly reusing the first file's H1
+	title, titleFromFile := resolveDocumentTitle(cliArgs, files)
+	if titleFromFile {
+		log("Using leading H1 of %s as document title", files[0].Name)
+	}
+
+	// Write document title and metadata
+	if err := writeDocumentHeader(w, cliArgs, files, title); err != nil {
+		return fmt.Errorf("failed to write document header: %w", err)
+	}
+
+	// Write table of contents if requested (unless it goes to a separate file)
+	if cliArgs.MergeGenerateTOC && cliArgs.MergeTOCFile == "" {
+		if err := writeTOC(w, cliArgs, files, titleFromFile); err != nil {
 			return fmt.Errorf("failed to write table of contents: %w", err)
 		}
 	}
 
 	// Merge files
 	for i, file := range files {
-		spinner.Stop("")
-		spinner = NewSpinner(fmt.Sprintf("Processing files... (%d/%d) - %s", i+1, len(files), file.Name))
-		spinner.Start()
+		if onFile != nil {
+			onFile(i, file)
+		}
 
-		if err := mergeFile(outputFile, file, cliArgs); err != nil {
-			spinner.Stop("Merge failed")
+		if err := mergeFile(w, file, cliArgs, i == 0 && titleFromFile); err != nil {
 			return fmt.Errorf("failed to merge file %s: %w", file.Name, err)
 		}
 
 		// Add separator between files (except for the last one)
 		if i < len(files)-1 {
-			if _, err := outputFile.WriteString(cliArgs.MergeSeparator); err != nil {
-				spinner.Stop("Merge failed")
+			if _, err := io.WriteString(w, fileSeparator(cliArgs)); err != nil {
 				return fmt.Errorf("failed to write separator: %w", err)
 			}
 		}
 	}
-	
-	// Calculate total size
-	stat, err := os.Stat(cliArgs.MergeOutputFile)
-	if err != nil {
-		spinner.Stop("Merge failed")
-		return fmt.Errorf("failed to get output file stats: %w", err)
+
+	// Append sources section if requested
+	if cliArgs.MergeAppendSources {
+		if err := writeSourcesSection(w, cliArgs, files); err != nil {
+			return fmt.Errorf("failed to write sources section: %w", err)
+		}
 	}
 
-	finalMessage := fmt.Sprintf("Merge completed - Output: %s (%s)", cliArgs.MergeOutputFile, formatFileSize(stat.Size()))
-	spinner.Stop(finalMessage)
-	
 	return nil
 }
 
-// writeDocumentHeader writes the document title and optional metadata
-func writeDocumentHeader(file *os.File, cliArgs *CLIArgs, files []MarkdownFile) error {
-	// Generate document title from output filename
-	title := generateDocumentTitle(cliArgs.MergeOutputFile)
-	
-	// Write document title (H1)
-	if _, err := file.WriteString(fmt.Sprintf("# %s\n\n", title)); err != nil {
+// defaultPageBreakMarker starts a new page in HTML-based PDF exports
+const defaultPageBreakMarker = `<div style="page-break-after: always;"></div>`
+
+// fileSeparator returns the text written between merged files; with --page-breaks a page-break
+// marker replaces the separator
+func fileSeparator(cliArgs *CLIArgs) string {
+	if !cliArgs.MergePageBreaks {
+		return cliArgs.MergeSeparator
+	}
+	marker := cliArgs.MergePageBreakMarker
+	if marker == "" {
+		marker = defaultPageBreakMarker
+	}
+	return "\n\n" + marker + "\n\n"
+}
+
+// sourcesHeading returns the heading text for the appended sources section
+func sourcesHeading(cliArgs *CLIArgs) string {
+	if cliArgs.MergeSourcesHeading == "" {
+		return "Sources"
+	}
+	return cliArgs.MergeSourcesHeading
+}
+
+// writeSourcesSection writes a visible section listing every merged file for provenance
+func writeSourcesSection(w io.Writer, cliArgs *CLIArgs, files []MarkdownFile) error {
+	heading := strings.Repeat("#", cliArgs.MergeBaseLevel)
+	if _, err := fmt.Fprintf(w, "\n%s %s\n\n", heading, sourcesHeading(cliArgs)); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		_, err := fmt.Fprintf(w, "- `%s` (%s, modified %s)\n",
+			filepath.ToSlash(sourcePath(cliArgs, file)), formatFileSize(file.Size), file.ModTime.Format("2006-01-02 15:04:05"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDocumentHeader writes the provenance comment, the document title and optional metadata
+func writeDocumentHeader(w io.Writer, cliArgs *CLIArgs, files []MarkdownFile, title string) error {
+	// Mark the document as merge output, so that later merges skip it and overwrite it without asking
+	provenance := mergeProvenance
+	if cliArgs.MergeIncludeMeta {
+		provenance = fmt.Sprintf("%s at %s -->\n", mergeMarker, time.Now().Format("2006-01-02 15:04:05"))
+	}
+	if _, err := io.WriteString(w, provenance); err != nil {
 		return err
 	}
-	
+
+	// Write document title (H1)
+	if !cliArgs.MergeNoTitle {
+		if _, err := fmt.Fprintf(w, "# %s\n\n", title); err != nil {
+			return err
+		}
+	}
+
+	words := 0
+	if cliArgs.MergeIncludeMeta || cliArgs.MergeReadingTime {
+		words = mergedWordCount(cliArgs, files)
+	}
+
+	if cliArgs.MergeReadingTime {
+		if _, err := fmt.Fprintf(w, "*%d words, ~%d min read*\n\n", words, readingMinutes(words)); err != nil {
+			return err
+		}
+	}
+
 	// Write metadata if requested
 	if cliArgs.MergeIncludeMeta {
-		header := fmt.Sprintf(`<!-- Generated by doc merge at %s -->
-<!-- Source directory: %s -->
+		sources, command := strings.Join(cliArgs.MergeDirectories, ", "), strings.Join(cliArgs.MergeDirectories, " ")
+		if cliArgs.MergeFromStdin {
+			sources, command = "stdin", "--from-stdin"
+		}
+		header := fmt.Sprintf(`<!-- Source directory: %s -->
 <!-- Files merged: %d -->
+<!-- Words: %d -->
+<!-- Reading time: ~%d min -->
 <!-- Command: doc merge %s -->
 
-`, time.Now().Format("2006-01-02 15:04:05"), cliArgs.MergeDirectory, len(files), cliArgs.MergeDirectory)
-		
-		if _, err := file.WriteString(header); err != nil {
+`, sources, len(files), words, readingMinutes(words), command)
+
+		if _, err := io.WriteString(w, header); err != nil {
 			return err
 		}
 	}
-	
+
 	return nil
 }
 
+// wordsPerMinute is the reading speed used to estimate reading time
+const wordsPerMinute = 200
+
+// mergedWordCount returns the number of words in the merged files, excluding front matter and code
+func mergedWordCount(cliArgs *CLIArgs, files []MarkdownFile) int {
+	words := 0
+	for _, file := range files {
+		content, err := file.readText(cliArgs)
+		if err != nil {
+			continue
+		}
+		_, body := separateFrontMatter(cliArgs, content)
+		words += countWords(body)
+	}
+	return words
+}
+
+// countWords counts the whitespace-separated words of content outside fenced code blocks and
+// inline code spans
+func countWords(content string) int {
+	words := 0
+	var fence codeFenceTracker
+	for _, line := range strings.Split(content, "\n") {
+		if fence.inCode(line) {
+			continue
+		}
+		words += len(strings.Fields(inlineCodePattern.ReplaceAllString(line, " ")))
+	}
+	return words
+}
+
+// readingMinutes estimates the reading time of words in whole minutes, at least one
+func readingMinutes(words int) int {
+	return max((words+wordsPerMinute-1)/wordsPerMinute, 1)
+}
+
+// resolveDocumentTitle returns the document title and whether it comes from the first file.
+// An explicit --title wins; with --smart-title, a leading H1 in the first file replaces the
+// generated title.
+func resolveDocumentTitle(cliArgs *CLIArgs, files []MarkdownFile) (string, bool) {
+	if cliArgs.MergeTitle != "" {
+		return cliArgs.MergeTitle, false
+	}
+	if cliArgs.MergeSmartTitle && len(files) > 0 {
+		if content, err := files[0].readText(cliArgs); err == nil {
+			_, body := separateFrontMatter(cliArgs, content)
+			if title, _, ok := splitLeadingH1(body); ok {
+				return title, true
+			}
+		}
+	}
+	return generateDocumentTitle(cliArgs.MergeOutputFile), false
+}
+
+// splitLeadingH1 splits a leading H1, ATX or Setext (after optional blank lines), from the rest of the content
+func splitLeadingH1(content string) (string, string, bool) {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if setextLevel(lines, i) == 1 {
+			rest := strings.TrimLeft(strings.Join(lines[i+2:], "\n"), "\n")
+			return trimmed, rest, true
+		}
+		if !strings.HasPrefix(trimmed, "# ") {
+			return "", content, false
+		}
+		title := strings.TrimSpace(strings.TrimPrefix(trimmed, "# "))
+		rest := strings.TrimLeft(strings.Join(lines[i+1:], "\n"), "\n")
+		return title, rest, true
+	}
+	return "", content, false
+}
+
+// mergeFileContent prepares a file's content for merging: it handles front matter, removes a leading
+// H1 already used as the document title, adds a heading for the file with --file-headings, and
+// applies the heading renames made by --dedupe-anchors.
+// It returns the front matter to keep, the body, and the base level for the body's headers.
+func mergeFileContent(cliArgs *CLIArgs, file MarkdownFile, content string, stripTitle bool) (string, string, int) {
+	frontMatter, body := separateFrontMatter(cliArgs, content)
+
+	baseLevel := cliArgs.MergeBaseLevel
+	if stripTitle {
+		_, body, _ = splitLeadingH1(body)
+		baseLevel = titleFileBaseLevel(baseLevel)
+	} else if cliArgs.MergeFileHeadings {
+		// A leading H1 already heads the file; otherwise one is derived from the filename
+		if _, _, ok := splitLeadingH1(body); !ok {
+			body = "# " + fileTitle(file.Name) + "\n\n" + body
+		}
+	}
+
+	body = applyHeadingRenames(body, file.HeadingRenames)
+
+	return frontMatter, body, baseLevel
+}
+
+// separateFrontMatter splits a file's leading YAML front matter from its body according to
+// --front-matter. The returned front matter is only non-empty in keep mode; in heading mode the
+// front matter's title becomes an H1 at the start of the body.
+func separateFrontMatter(cliArgs *CLIArgs, content string) (string, string) {
+	frontMatter, body, ok := splitFrontMatter(content)
+	if !ok {
+		return "", content
+	}
+
+	switch cliArgs.MergeFrontMatter {
+	case "keep":
+		return frontMatter, body
+	case "heading":
+		if title := frontMatterTitle(frontMatter); title != "" {
+			return "", "# " + title + "\n\n" + body
+		}
+	}
+	return "", body
+}
+
+// splitFrontMatter splits YAML front matter delimited by --- lines from the start of content.
+// The opening --- must be the first line, so a later thematic break is never mistaken for it.
+func splitFrontMatter(content string) (string, string, bool) {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 || strings.TrimRight(lines[0], " \t\r") != "---" {
+		return "", content, false
+	}
+
+	for i := 1; i < len(lines); i++ {
+		delimiter := strings.TrimRight(lines[i], " \t\r")
+		if delimiter == "---" || delimiter == "..." {
+			frontMatter := strings.Join(lines[:i+1], "\n") + "\n"
+			rest := strings.TrimLeft(strings.Join(lines[i+1:], "\n"), "\r\n")
+			return frontMatter, rest, true
+		}
+	}
+	return "", content, false
+}
+
+// frontMatterTitle returns the top-level title field of a front matter block, or ""
+func frontMatterTitle(frontMatter string) string {
+	for _, line := range strings.Split(frontMatter, "\n") {
+		value, ok := strings.CutPrefix(line, "title:")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		return value
+	}
+	return ""
+}
+
+// titleFileBaseLevel returns the base level for the file whose H1 became the document title.
+// Its H2 sections are promoted so they sit directly below the title.
+func titleFileBaseLevel(baseLevel int) int {
+	if baseLevel > 1 {
+		return baseLevel - 1
+	}
+	return baseLevel
+}
+
 // generateDocumentTitle creates a document title from the output filename
 func generateDocumentTitle(outputFile string) string {
 	// Extract filename without extension
 	base := filepath.Base(outputFile)
 	name := strings.TrimSuffix(base, filepath.Ext(base))
-	
+
 	// Convert to title case
 	if name == "merged" {
 		return "Document"
 	}
-	
+
+	return titleCase(name)
+}
+
+// fileTitle returns a section title for a file without a heading, e.g. 01-getting_started.md -> Getting Started
+func fileTitle(filename string) string {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	// Drop ordering prefixes such as "01-" or "2_"
+	if trimmed := strings.TrimLeft(strings.TrimLeft(name, "0123456789"), "-_. "); trimmed != "" && trimmed != name {
+		name = trimmed
+	}
+	return titleCase(name)
+}
+
+// titleCase turns a file name into a title, treating underscores and hyphens as spaces
+func titleCase(name string) string {
 	// Replace underscores and hyphens with spaces, then title case
 	name = strings.ReplaceAll(name, "_", " ")
 	name = strings.ReplaceAll(name, "-", " ")
-	
+
 	// Simple title case conversion
 	words := strings.Fields(name)
 	for i, word := range words {
@@ -186,93 +768,246 @@ func generateDocumentTitle(outputFile string) string {
 			words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
 		}
 	}
-	
+
 	return strings.Join(words, " ")
 }
 
-// writeTOC writes the table of contents to the output file
-func writeTOC(file *os.File, cliArgs *CLIArgs, files []MarkdownFile) error {
-	_, err := file.WriteString("## Table of Contents\n\n")
+// tocTopLevel returns the header level listed without indentation in the table of contents: the
+// level below the document title, or 1 when --no-title leaves the files' headers at level 1
+func tocTopLevel(cliArgs *CLIArgs) int {
+	if cliArgs.MergeNoTitle {
+		return min(cliArgs.MergeBaseLevel, 2)
+	}
+	return 2
+}
+
+// writeTOC writes the inline table of contents to the output file. For HTML output it is
+// wrapped in a nav element.
+func writeTOC(w io.Writer, cliArgs *CLIArgs, files []MarkdownFile, skipFirstTitle bool) error {
+	if cliArgs.MergeFormat == "html" {
+		if _, err := io.WriteString(w, "<nav class=\"toc\">\n\n"); err != nil {
+			return err
+		}
+	}
+
+	heading := strings.Repeat("#", tocTopLevel(cliArgs))
+	if _, err := fmt.Fprintf(w, "%s Table of Contents\n\n", heading); err != nil {
+		return err
+	}
+
+	if err := writeTOCEntries(w, cliArgs, files, skipFirstTitle, ""); err != nil {
+		return err
+	}
+
+	if cliArgs.MergeFormat == "html" {
+		_, err := io.WriteString(w, "\n</nav>\n\n")
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeTOCFile writes the table of contents to a separate file with links into the main output
+func writeTOCFile(cliArgs *CLIArgs, files []MarkdownFile) error {
+	target, err := filepath.Rel(filepath.Dir(cliArgs.MergeTOCFile), cliArgs.MergeOutputFile)
 	if err != nil {
+		target = cliArgs.MergeOutputFile
+	}
+
+	_, titleFromFile := resolveDocumentTitle(cliArgs, files)
+
+	var buf bytes.Buffer
+	buf.WriteString(mergeProvenance + "# Table of Contents\n\n")
+	if err := writeTOCEntries(&buf, cliArgs, files, titleFromFile, filepath.ToSlash(target)); err != nil {
+		return err
+	}
+
+	return os.WriteFile(cliArgs.MergeTOCFile, buf.Bytes(), 0644)
+}
+
+// runTOCOnly writes a table of contents linking to the headers of the source files to the output
+// file, or to stdout when no output file was given
+func runTOCOnly(cliArgs *CLIArgs, files []MarkdownFile) error {
+	if cliArgs.MergeOutputFile == "" {
+		return writeSourceTOC(os.Stdout, cliArgs, files, ".")
+	}
+
+	if err := checkMergeOverwrite(cliArgs); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(mergeProvenance)
+	if err := writeSourceTOC(&buf, cliArgs, files, filepath.Dir(cliArgs.MergeOutputFile)); err != nil {
 		return err
 	}
+	if err := os.WriteFile(cliArgs.MergeOutputFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	progress("Table of contents written to %s", cliArgs.MergeOutputFile)
+	return nil
+}
+
+// writeSourceTOC writes a table of contents whose entries link to the headers in the source files,
+// relative to outputDir. Header levels are taken from the files as they are, up to --toc-depth.
+func writeSourceTOC(w io.Writer, cliArgs *CLIArgs, files []MarkdownFile, outputDir string) error {
+	if _, err := io.WriteString(w, "# Table of Contents\n\n"); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := file.readText(cliArgs)
+		if err != nil {
+			continue
+		}
+
+		target, err := filepath.Rel(outputDir, file.Path)
+		if err != nil {
+			target = file.Path
+		}
+		target = filepath.ToSlash(target)
+
+		// Anchors are per file, so every header of the file takes part in duplicate numbering
+		_, body, _ := splitFrontMatter(content)
+		seen := make(map[string]int)
+		for _, header := range extractHeaders(body, 6) {
+			link := slugify(header.Text, seen)
+			if header.Level > cliArgs.MergeTOCDepth {
+				continue
+			}
+
+			indent := strings.Repeat("  ", header.Level-1)
+			if _, err := fmt.Fprintf(w, "%s- [%s](%s#%s)\n", indent, header.Text, target, link); err != nil {
+				return err
+			}
+		}
+	}
 
-	for _, markdownFile := range files {
+	return nil
+}
+
+// writeTOCEntries writes one list entry per header, linking to target (empty for the same document).
+// When skipFirstTitle is set, the first file's leading H1 is the document title and is omitted.
+func writeTOCEntries(w io.Writer, cliArgs *CLIArgs, files []MarkdownFile, skipFirstTitle bool, target string) error {
+	// Headers that precede the merged files in the output also claim their slugs
+	seen := make(map[string]int)
+	if !cliArgs.MergeNoTitle {
+		title, _ := resolveDocumentTitle(cliArgs, files)
+		slugify(title, seen)
+	}
+	if target == "" {
+		slugify("Table of Contents", seen)
+	}
+
+	topLevel := tocTopLevel(cliArgs)
+	for i, markdownFile := range files {
 		// Read file to extract headers
-		content, err := os.ReadFile(markdownFile.Path)
+		content, err := markdownFile.readText(cliArgs)
 		if err != nil {
 			continue
 		}
 
-		headers := extractHeaders(string(content), cliArgs.MergeTOCDepth)
+		_, fileContent, baseLevel := mergeFileContent(cliArgs, markdownFile, content, i == 0 && skipFirstTitle)
+
+		// Every header takes part in duplicate numbering, even those too deep for the TOC
+		headers := extractHeaders(fileContent, 6)
 		for _, header := range headers {
+			link := slugify(header.Text, seen)
+
 			// Adjust header level for TOC (since file headers will be adjusted)
-			adjustedLevel := header.Level + cliArgs.MergeBaseLevel - 1
-			if adjustedLevel > cliArgs.MergeTOCDepth + 1 { // +1 for the document title level
+			adjustedLevel := header.Level + baseLevel - 1
+			if header.Level > cliArgs.MergeTOCDepth || adjustedLevel > cliArgs.MergeTOCDepth+topLevel-1 {
 				continue
 			}
-			
-			indent := strings.Repeat("  ", adjustedLevel-2) // -2 because TOC starts at level 2
-			link := strings.ToLower(strings.ReplaceAll(header.Text, " ", "-"))
-			// Remove non-alphanumeric characters from link
-			link = strings.Map(func(r rune) rune {
-				if r == ' ' || r == '-' {
-					return '-'
-				}
-				if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
-					return r
-				}
-				return -1
-			}, link)
-			
-			_, err := file.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", indent, header.Text, link))
+
+			indent := strings.Repeat("  ", max(adjustedLevel-topLevel, 0))
+
+			_, err := fmt.Fprintf(w, "%s- [%s](%s#%s)\n", indent, header.Text, target, link)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	_, err = file.WriteString("\n")
-	return err
+	// Link the appended sources section
+	if cliArgs.MergeAppendSources {
+		title := sourcesHeading(cliArgs)
+		indent := strings.Repeat("  ", max(cliArgs.MergeBaseLevel-topLevel, 0))
+		if _, err := fmt.Fprintf(w, "%s- [%s](%s#%s)\n", indent, title, target, slugify(title, seen)); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// mergeFile merges a single markdown file into the output
-func mergeFile(outputFile *os.File, file MarkdownFile, cliArgs *CLIArgs) error {
-	// Write file source comment if metadata is enabled
-	if cliArgs.MergeIncludeMeta {
-		relPath, _ := filepath.Rel(cliArgs.MergeDirectory, file.Path)
-		comment := fmt.Sprintf("<!-- Source: %s -->\n", relPath)
-		if _, err := outputFile.WriteString(comment); err != nil {
-			return err
+// inlineLinkPattern matches inline markdown links, whose anchor uses only the link text
+var inlineLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// slugify converts header text into a GitHub-compatible anchor.
+// It lowercases the text, drops punctuation, symbols and emoji, and turns spaces into hyphens.
+// seen counts slugs already used in the document; repeats get a -1, -2, ... suffix.
+func slugify(text string, seen map[string]int) string {
+	text = inlineLinkPattern.ReplaceAllString(strings.TrimSpace(text), "$1")
+
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_':
+			return r
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			return unicode.ToLower(r)
+		default:
+			return -1
 		}
+	}, text)
+
+	count := seen[slug]
+	seen[slug] = count + 1
+	if count > 0 {
+		return fmt.Sprintf("%s-%d", slug, count)
+	}
+	return slug
+}
+
+// mergeFile merges a single markdown file into the output.
+// When stripTitle is set, the file's leading H1 has already been used as the document title.
+func mergeFile(w io.Writer, file MarkdownFile, cliArgs *CLIArgs, stripTitle bool) error {
+	// Write file source comment if metadata is enabled
+	if _, err := io.WriteString(w, sourceComment(cliArgs, file)); err != nil {
+		return err
 	}
 
 	// Read the file content
-	content, err := os.ReadFile(file.Path)
+	content, err := file.readText(cliArgs)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	fileContent := string(content)
+	frontMatter, fileContent, baseLevel := mergeFileContent(cliArgs, file, content, stripTitle)
 
 	// Adjust header levels if requested
 	if cliArgs.MergeAdjustHeaders {
-		fileContent = adjustHeaderLevels(fileContent, cliArgs.MergeBaseLevel)
+		fileContent = adjustHeaderLevels(fileContent, baseLevel)
 	}
 
-	// Write the content
-	if _, err := outputFile.WriteString(fileContent); err != nil {
-		return err
+	// Relative links must resolve from the output file instead of the source file
+	if cliArgs.MergeRewriteLinks {
+		fileContent = rewriteRelativeLinks(fileContent, filepath.Dir(file.Path), filepath.Dir(cliArgs.MergeOutputFile))
 	}
 
-	// Ensure content ends with newline
-	if !strings.HasSuffix(fileContent, "\n") {
-		if _, err := outputFile.WriteString("\n"); err != nil {
-			return err
-		}
+	// End the content with exactly one line break so the separator is spaced the same after every
+	// file; trailing blank lines or spaces could otherwise break the rendering of a --- rule
+	lineEnding := "\n"
+	if cliArgs.MergeKeepCRLF && strings.Contains(fileContent, "\r\n") {
+		lineEnding = "\r\n"
 	}
+	fileContent = strings.TrimRight(fileContent, " \t\r\n") + lineEnding
 
-	return nil
+	_, err = io.WriteString(w, frontMatter+fileContent)
+	return err
 }
 
 // Header represents a markdown header
@@ -281,12 +1016,28 @@ type Header struct {
 	Text  string
 }
 
-// extractHeaders extracts headers from markdown content up to maxDepth
+// extractHeaders extracts headers from markdown content up to maxDepth.
+// Both ATX (#) and Setext (underlined) headers are recognized; lines inside
+// fenced code blocks are ignored.
 func extractHeaders(content string, maxDepth int) []Header {
 	var headers []Header
 	lines := strings.Split(content, "\n")
+	var fence codeFenceTracker
+
+	for n := 0; n < len(lines); n++ {
+		line := lines[n]
+		if fence.inCode(line) {
+			continue
+		}
+
+		if level := setextLevel(lines, n); level > 0 {
+			if level <= maxDepth {
+				headers = append(headers, Header{Level: level, Text: strings.TrimSpace(line)})
+			}
+			n++ // skip the underline
+			continue
+		}
 
-	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "#") {
 			level := 0
@@ -307,11 +1058,28 @@ func extractHeaders(content string, maxDepth int) []Header {
 	return headers
 }
 
-// adjustHeaderLevels adjusts header levels in markdown content
+// adjustHeaderLevels adjusts header levels in markdown content.
+// Setext headers are converted to ATX style at their new level.
+// Lines inside fenced code blocks are left untouched.
 func adjustHeaderLevels(content string, baseLevel int) string {
 	lines := strings.Split(content, "\n")
-	
-	for i, line := range lines {
+	out := make([]string, 0, len(lines))
+	var fence codeFenceTracker
+
+	for n := 0; n < len(lines); n++ {
+		line := lines[n]
+		if fence.inCode(line) {
+			out = append(out, line)
+			continue
+		}
+
+		if level := setextLevel(lines, n); level > 0 {
+			newLevel := min(baseLevel+level-1, 6)
+			out = append(out, strings.Repeat("#", newLevel)+" "+strings.TrimSpace(line))
+			n++ // drop the underline
+			continue
+		}
+
 		if strings.HasPrefix(strings.TrimSpace(line), "#") {
 			// Count existing header level
 			level := 0
@@ -322,23 +1090,180 @@ func adjustHeaderLevels(content string, baseLevel int) string {
 					break
 				}
 			}
-			
+
 			if level > 0 {
 				// Calculate new level
 				newLevel := baseLevel + level - 1
 				if newLevel > 6 {
 					newLevel = 6 // Markdown only supports up to 6 levels
 				}
-				
+
 				// Replace with new header level
 				headerPrefix := strings.Repeat("#", newLevel)
 				headerText := strings.TrimSpace(line[level:])
-				lines[i] = headerPrefix + " " + headerText
+				line = headerPrefix + " " + headerText
 			}
 		}
+		out = append(out, line)
 	}
-	
-	return strings.Join(lines, "\n")
+
+	return strings.Join(out, "\n")
+}
+
+// setextLevel returns 1 or 2 if lines[n] is the text of a Setext header underlined by
+// lines[n+1] with = (H1) or - (H2), and 0 otherwise. Only a single text line preceded by
+// a blank line (or the start of the content) qualifies, so --- after a paragraph continuation,
+// list item or other block is still treated as a horizontal rule.
+func setextLevel(lines []string, n int) int {
+	if n+1 >= len(lines) || (n > 0 && strings.TrimSpace(lines[n-1]) != "") {
+		return 0
+	}
+
+	text := lines[n]
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.HasPrefix(text, "    ") || strings.HasPrefix(text, "\t") {
+		return 0
+	}
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ">") ||
+		listItemPattern.MatchString(text) || isThematicBreak(trimmed) {
+		return 0
+	}
+
+	underline := strings.TrimSpace(lines[n+1])
+	switch {
+	case underline != "" && strings.Trim(underline, "=") == "":
+		return 1
+	case len(underline) >= 2 && strings.Trim(underline, "-") == "":
+		return 2
+	default:
+		return 0
+	}
+}
+
+// isThematicBreak reports whether a trimmed line is a horizontal rule such as ---, *** or ___
+func isThematicBreak(trimmed string) bool {
+	compact := strings.ReplaceAll(trimmed, " ", "")
+	if len(compact) < 3 {
+		return false
+	}
+	return strings.Trim(compact, "-") == "" || strings.Trim(compact, "*") == "" || strings.Trim(compact, "_") == ""
+}
+
+// codeFenceTracker tracks whether a line-by-line walk is inside a fenced code block
+type codeFenceTracker struct {
+	marker string // "```" or "~~~" of the open fence, empty outside code blocks
+}
+
+// inCode reports whether line is a fence line or lies inside a fenced code block.
+// A block opened with ``` is only closed by ```, and likewise for ~~~.
+func (f *codeFenceTracker) inCode(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, marker := range []string{"```", "~~~"} {
+		if !strings.HasPrefix(trimmed, marker) {
+			continue
+		}
+		if f.marker == "" {
+			f.marker = marker
+		} else if f.marker == marker {
+			f.marker = ""
+		}
+		return true
+	}
+	return f.marker != ""
+}
+
+// readFileWorkers bounds the number of files read concurrently by loadFileContents
+const readFileWorkers = 8
+
+// loadFileContents reads the content of every file into memory using a bounded pool of workers.
+// A file that cannot be read is left unloaded; reading it again when it is merged reports the error.
+func loadFileContents(files []MarkdownFile) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for range min(readFileWorkers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if content, err := os.ReadFile(files[i].Path); err == nil {
+					files[i].Content = content
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// isText reports whether the file is valid UTF-8 without NUL bytes. A file that cannot be read
+// counts as text, so that merging it reports the read error.
+func (f MarkdownFile) isText() bool {
+	content, err := f.readContent()
+	if err != nil {
+		return true
+	}
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
+
+// readContent returns the file's content, from memory if it has been loaded
+func (f MarkdownFile) readContent() ([]byte, error) {
+	if f.Content != nil {
+		return f.Content, nil
+	}
+	return os.ReadFile(f.Path)
+}
+
+// readText returns the file's content as text, with CRLF line endings normalized unless
+// --keep-crlf is set, and without HTML comments with --strip-comments
+func (f MarkdownFile) readText(cliArgs *CLIArgs) (string, error) {
+	content, err := f.readContent()
+	if err != nil {
+		return "", err
+	}
+	text := string(content)
+	if !cliArgs.MergeKeepCRLF {
+		text = normalizeLineEndings(text)
+	}
+	if cliArgs.MergeStripComments {
+		text = stripHTMLComments(text)
+	}
+	return text, nil
+}
+
+// sourceComment returns the comment naming a merged file's source, written with --include-meta
+func sourceComment(cliArgs *CLIArgs, file MarkdownFile) string {
+	if !cliArgs.MergeIncludeMeta {
+		return ""
+	}
+	return fmt.Sprintf("<!-- Source: %s -->\n", sourcePath(cliArgs, file))
+}
+
+// parseFileSize parses a human-readable size such as "500", "512KB", "5MB" or "1.5 GB", the
+// inverse of formatFileSize. Units are powers of 1024 and case-insensitive; the B is optional.
+func parseFileSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(value, "B")
+
+	multiplier := int64(1)
+	if n := len(value); n > 0 {
+		if exp := strings.IndexByte("KMGTPE", value[n-1]); exp >= 0 {
+			for range exp + 1 {
+				multiplier *= 1024
+			}
+			value = value[:n-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size '%s' (use e.g. 500KB or 5MB)", s)
+	}
+	return int64(number * float64(multiplier)), nil
 }
 
 // formatFileSize formats file size in human-readable format
@@ -353,4 +1278,4 @@ func formatFileSize(size int64) string {
 		exp++
 	}
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
-}
\ No newline at end of file
+}
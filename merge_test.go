@@ -0,0 +1,1162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestAdjustHeaderLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		baseLevel int
+		expected  string
+	}{
+		{
+			name:      "Shift headers",
+			content:   "# Title\n\n## Section\n\nText",
+			baseLevel: 2,
+			expected:  "## Title\n\n### Section\n\nText",
+		},
+		{
+			name:      "Cap at level 6",
+			content:   "##### Deep",
+			baseLevel: 3,
+			expected:  "###### Deep",
+		},
+		{
+			name:      "Skip comments in backtick fences",
+			content:   "# Setup\n\n```bash\n# install deps\nnpm install\n## not a header\n```\n\n## Usage",
+			baseLevel: 2,
+			expected:  "## Setup\n\n```bash\n# install deps\nnpm install\n## not a header\n```\n\n### Usage",
+		},
+		{
+			name:      "Convert Setext headers to ATX",
+			content:   "Title\n=====\n\nSection\n-------\n\nText",
+			baseLevel: 2,
+			expected:  "## Title\n\n### Section\n\nText",
+		},
+		{
+			name:      "Keep horizontal rules",
+			content:   "Paragraph line one\nline two\n---\n\n---\n\n- item\n---",
+			baseLevel: 2,
+			expected:  "Paragraph line one\nline two\n---\n\n---\n\n- item\n---",
+		},
+		{
+			name:      "Skip comments in tilde fences",
+			content:   "~~~python\n# comment\n```\n# still code\n~~~\n# After",
+			baseLevel: 2,
+			expected:  "~~~python\n# comment\n```\n# still code\n~~~\n## After",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := adjustHeaderLevels(tt.content, tt.baseLevel)
+			if result != tt.expected {
+				t.Errorf("adjustHeaderLevels() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		maxDepth int
+		expected []Header
+	}{
+		{
+			name:     "Headers up to max depth",
+			content:  "# Title\n## Section\n### Detail\n#### Too deep",
+			maxDepth: 3,
+			expected: []Header{{1, "Title"}, {2, "Section"}, {3, "Detail"}},
+		},
+		{
+			name:     "Ignore comments in fenced blocks",
+			content:  "# Build\n\n```sh\n# build\nmake\n```\n\n~~~\n## phantom\n~~~\n\n## Test",
+			maxDepth: 3,
+			expected: []Header{{1, "Build"}, {2, "Test"}},
+		},
+		{
+			name:     "Setext headers",
+			content:  "Guide\n=====\n\nInstall\n---\n\nText\n\n---\n\n## After",
+			maxDepth: 3,
+			expected: []Header{{1, "Guide"}, {2, "Install"}, {2, "After"}},
+		},
+		{
+			name:     "Unclosed fence hides the rest",
+			content:  "# Intro\n```\n# comment",
+			maxDepth: 3,
+			expected: []Header{{1, "Intro"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractHeaders(tt.content, tt.maxDepth)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("extractHeaders() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	seen := make(map[string]int)
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Getting Started", "getting-started"},
+		{"What's new in v2.0?", "whats-new-in-v20"},
+		{"The `doc merge` command", "the-doc-merge-command"},
+		{"🚀 Quick Start", "-quick-start"},
+		{"See [the guide](guide.md)", "see-the-guide"},
+		{"snake_case & kebab-case", "snake_case--kebab-case"},
+		{"概要", "概要"},
+		{"Overview", "overview"},
+		{"Overview", "overview-1"},
+		{"overview", "overview-2"},
+	}
+
+	for _, tt := range tests {
+		if result := slugify(tt.text, seen); result != tt.expected {
+			t.Errorf("slugify(%q) = %q, want %q", tt.text, result, tt.expected)
+		}
+	}
+}
+
+func TestRunMergeMultipleDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+	guide := filepath.Join(tempDir, "guide")
+	reference := filepath.Join(tempDir, "reference")
+	files := map[string]string{
+		filepath.Join(guide, "b.md"):     "Guide B\n",
+		filepath.Join(guide, "a.md"):     "Guide A\n",
+		filepath.Join(reference, "a.md"): "Reference A\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tempDir, "book.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2}, []string{guide, reference, output, "--no-toc", "--include-meta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cliArgs.MergeDirectories, []string{guide, reference}) || cliArgs.MergeOutputFile != output {
+		t.Fatalf("Expected two directories and output %s, got %v and %s", output, cliArgs.MergeDirectories, cliArgs.MergeOutputFile)
+	}
+
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Directory order is kept and files are sorted within each directory
+	content := string(merged)
+	order := []string{
+		"<!-- Source: " + filepath.Join(guide, "a.md") + " -->", "Guide A",
+		"<!-- Source: " + filepath.Join(guide, "b.md") + " -->", "Guide B",
+		"<!-- Source: " + filepath.Join(reference, "a.md") + " -->", "Reference A",
+	}
+	last := -1
+	for _, want := range order {
+		i := strings.Index(content, want)
+		if i <= last {
+			t.Fatalf("Expected %q after previous entries in:\n%s", want, content)
+		}
+		last = i
+	}
+}
+
+func TestRunMergeSkipsPriorOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"guide.md":         "# Guide\n",
+		"merged.md":        "# Old Merge\n",
+		"archive/book.md":  "# Book\n\n<!-- Generated by doc merge at 2024-01-01 00:00:00 -->\n",
+		"archive/notes.md": "# Notes\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tempDir, "merged.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2}, []string{tempDir, output, "-r", "--include-meta", "--force"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Merging twice gives the same files: neither the output nor a marked merge is picked up
+	for run := 1; run <= 2; run++ {
+		if err := runMerge(cliArgs); err != nil {
+			t.Fatal(err)
+		}
+		merged, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content := string(merged)
+		for _, unwanted := range []string{"Old Merge", "# Book", "Source: merged.md"} {
+			if strings.Contains(content, unwanted) {
+				t.Errorf("Run %d: expected %q to be skipped, got:\n%s", run, unwanted, content)
+			}
+		}
+		for _, want := range []string{"Guide", "Notes", "<!-- Files merged: 2 -->"} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Run %d: expected %q in output, got:\n%s", run, want, content)
+			}
+		}
+	}
+}
+
+func TestIsMergeDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"Provenance on the first line", mergeProvenance + "# Book\n", true},
+		{"Timestamped provenance", "<!-- Generated by doc merge at 2024-01-01 00:00:00 -->\n# Book\n", true},
+		{"Leading blank lines", "\n\n" + mergeProvenance, true},
+		{"Older --include-meta layout under the title", "# Book\n\n<!-- Generated by doc merge at 2024-01-01 00:00:00 -->\n", true},
+		{"HTML output", mergeProvenance + "<!DOCTYPE html>\n", true},
+		{"Marker quoted in a code block", "# Merging\n\n```markdown\n" + mergeProvenance + "```\n", false},
+		{"Marker later in the document", "# Guide\n\nText\n\n" + mergeProvenance, false},
+		{"Marker after a paragraph", "Intro\n" + mergeProvenance, false},
+		{"No marker", "# Guide\n", false},
+		{"Empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isMergeDocument([]byte(tt.content)); result != tt.expected {
+				t.Errorf("isMergeDocument(%q) = %v, want %v", tt.content, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunMergeSkipsDefaultOutputButNotQuotedMarker(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"guide.md":   "# Guide\n",
+		"merging.md": "# Merging\n\nMerged files start with:\n\n```markdown\n" + mergeProvenance + "```\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An earlier merge with default flags, saved under another name inside the input tree
+	merge := func(output string) string {
+		cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeSeparator: "\n\n---\n\n"}, []string{docs, output})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := runMerge(cliArgs); err != nil {
+			t.Fatal(err)
+		}
+		merged, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(merged)
+	}
+	merge(filepath.Join(docs, "book-v1.md"))
+	content := merge(filepath.Join(tempDir, "book-v2.md"))
+
+	if !strings.HasPrefix(content, mergeMarker) {
+		t.Errorf("Expected the output to start with the provenance comment, got:\n%s", content)
+	}
+	if strings.Contains(content, "Book V1") || strings.Count(content, "# Guide") != 1 {
+		t.Errorf("Expected the earlier merge to be skipped, got:\n%s", content)
+	}
+	if !strings.Contains(content, "Merged files start with:") {
+		t.Errorf("Expected the file quoting the marker in a code block to be merged, got:\n%s", content)
+	}
+}
+
+func TestRunMergeSeparatorFile(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.md": "First\n", "b.md": "Second\n"} {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	divider := "\n<div class=\"divider\">\n  <hr>\n</div>\n\n"
+	separatorFile := filepath.Join(tempDir, "divider.html")
+	if err := os.WriteFile(separatorFile, []byte(divider), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tempDir, "book.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeSeparator: "\n\n---\n\n"}, []string{docs, output, "--no-toc", "--separator-file", separatorFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(merged)
+	if !strings.Contains(content, "First\n"+divider+"Second") {
+		t.Errorf("Expected the files to be joined by the separator file's content, got:\n%q", content)
+	}
+	if strings.Contains(content, "---") {
+		t.Errorf("Expected the default separator to be replaced, got:\n%q", content)
+	}
+
+	cliArgs.MergeSeparatorFile = filepath.Join(tempDir, "missing.md")
+	if err := runMerge(cliArgs); err == nil || !strings.Contains(err.Error(), "separator file") {
+		t.Errorf("Expected an error for a missing separator file, got %v", err)
+	}
+}
+
+func TestRunMergeTrailingWhitespace(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.md": "First",
+		"b.md": "Second\n\n\n\n",
+		"c.md": "Third  \n\t\n \n",
+		"d.md": "Fourth\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tempDir, "book.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeSeparator: "\n\n---\n\n"}, []string{docs, output, "--no-toc", "--no-title"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(merged)
+	for _, text := range []string{"First", "Second", "Third"} {
+		if !strings.Contains(content, text+"\n\n\n---\n\n") {
+			t.Errorf("Expected %s to be followed by the separator with uniform spacing, got:\n%q", text, content)
+		}
+	}
+	if !strings.HasSuffix(content, "Fourth\n") {
+		t.Errorf("Expected the output to end with a single line break, got:\n%q", content)
+	}
+
+	// Every separator must render as a rule, never as a setext heading underline
+	rendered := markdownToHTML(content)
+	if rules := strings.Count(rendered, "<hr>"); rules != 3 {
+		t.Errorf("Expected 3 rules, got %d:\n%s", rules, rendered)
+	}
+	if strings.Contains(rendered, "<h2") {
+		t.Errorf("Expected no setext headings, got:\n%s", rendered)
+	}
+}
+
+func TestRunMergeSkipsBinaryFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"a.md":       []byte("# Guide\n\nSome text\n"),
+		"invalid.md": []byte("Caf\xe9 \xff\xfe\n"),
+		"nul.md":     []byte("PNG\x00\x00\x01\n"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tempDir, "book.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeSeparator: "\n\n---\n\n"}, []string{docs, output, "--no-toc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !utf8.Valid(merged) || bytes.IndexByte(merged, 0) >= 0 {
+		t.Errorf("Expected valid UTF-8 output without NUL bytes, got:\n%q", merged)
+	}
+	if !strings.Contains(string(merged), "Some text") {
+		t.Errorf("Expected the text file to be merged, got:\n%q", merged)
+	}
+	if strings.Contains(string(merged), "Caf") || strings.Contains(string(merged), "PNG") {
+		t.Errorf("Expected the binary files to be skipped, got:\n%q", merged)
+	}
+
+	// Nothing is left to merge when every file is binary
+	if err := os.Remove(filepath.Join(docs, "a.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err == nil {
+		t.Error("Expected an error when only binary files remain")
+	}
+}
+
+func TestRunMergeOverwriteGuard(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "guide.md"), []byte("# Guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(tempDir, "book.md")
+	if err := os.WriteFile(output, []byte("Hand-edited book\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	merge := func(extra ...string) error {
+		cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2}, append([]string{docs, output}, extra...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runMerge(cliArgs)
+	}
+	readOutput := func() string {
+		content, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	// Tests are not interactive, so a file doc merge did not write needs --force
+	if err := merge(); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected an error asking for --force, got %v", err)
+	}
+	if content := readOutput(); content != "Hand-edited book\n" {
+		t.Errorf("Expected the hand-edited file to be kept, got:\n%s", content)
+	}
+
+	if err := merge("--include-meta", "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if content := readOutput(); !strings.Contains(content, "Guide") {
+		t.Errorf("Expected --force to overwrite the file, got:\n%s", content)
+	}
+
+	// The output of an earlier merge is recognized and overwritten without --force
+	if err := merge(); err != nil {
+		t.Errorf("Expected a prior merge to be overwritten, got %v", err)
+	}
+	if err := merge(); err != nil {
+		t.Errorf("Expected a second merge with default flags to overwrite the first, got %v", err)
+	}
+	if err := merge("--toc-only"); err != nil {
+		t.Errorf("Expected --toc-only to overwrite a prior merge, got %v", err)
+	}
+	if err := merge("--format", "html"); err != nil {
+		t.Errorf("Expected an HTML merge to overwrite a prior merge, got %v", err)
+	}
+	if err := merge(); err != nil {
+		t.Errorf("Expected a prior HTML merge to be overwritten, got %v", err)
+	}
+}
+
+func TestRunMergeTwiceWithoutForce(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "guide.md"), []byte("# Guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(tempDir, "book.md")
+
+	for run := 1; run <= 2; run++ {
+		cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2}, []string{docs, output})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := runMerge(cliArgs); err != nil {
+			t.Fatalf("Merge run %d failed: %v", run, err)
+		}
+	}
+}
+
+func TestRunMergeStripComments(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "# Guide\n\n<!-- TODO: rewrite -->\nSome text\n\n<!--\n## Draft\n-->\n\n```html\n<!-- kept -->\n```\n"
+	if err := os.WriteFile(filepath.Join(docs, "guide.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tempDir, "book.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeSeparator: "\n\n---\n\n", MergeBaseLevel: 2, MergeGenerateTOC: true, MergeTOCDepth: 3}, []string{docs, output, "--strip-comments", "--include-meta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := string(merged)
+	for _, unwanted := range []string{"TODO", "Draft"} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("Expected %q to be stripped, including from the TOC, got:\n%s", unwanted, result)
+		}
+	}
+	for _, want := range []string{"<!-- kept -->", mergeMarker, "<!-- Source: guide.md -->", "Some text"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestRunMergeCRLF(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "guide.md"), []byte("# Guide\r\n\r\nSome text\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keepCRLF := range []bool{false, true} {
+		output := filepath.Join(tempDir, fmt.Sprintf("book-%v.md", keepCRLF))
+		args := []string{docs, output}
+		if keepCRLF {
+			args = append(args, "--keep-crlf")
+		}
+		cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2, MergeGenerateTOC: true, MergeTOCDepth: 3, MergeAdjustHeaders: true}, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := runMerge(cliArgs); err != nil {
+			t.Fatal(err)
+		}
+		merged, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content := string(merged)
+		if hasCR := strings.Contains(content, "\r"); hasCR != keepCRLF {
+			t.Errorf("keepCRLF=%v: output contains \\r = %v:\n%q", keepCRLF, hasCR, content)
+		}
+		if !keepCRLF && !strings.Contains(content, "## Guide\n\nSome text\n") {
+			t.Errorf("Expected the adjusted header and text with LF line endings, got:\n%q", content)
+		}
+	}
+}
+
+func TestRunMergeTOCOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"01-intro.md": "---\ntitle: Intro\n---\n# Intro\n\n## Setup\n\n### Details\n",
+		"02-usage.md": "# Usage\n\n## Setup\n\n## Setup\n",
+		"notes.txt":   "# Not markdown\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tempDir, "index.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeTOCDepth: 2}, []string{docs, output, "--toc-only"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	toc, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Links point into the source files, anchors are numbered per file and --toc-depth applies
+	expected := mergeProvenance + "# Table of Contents\n\n" +
+		"- [Intro](docs/01-intro.md#intro)\n" +
+		"  - [Setup](docs/01-intro.md#setup)\n" +
+		"- [Usage](docs/02-usage.md#usage)\n" +
+		"  - [Setup](docs/02-usage.md#setup)\n" +
+		"  - [Setup](docs/02-usage.md#setup-1)\n"
+	if string(toc) != expected {
+		t.Errorf("TOC = %q, want %q", toc, expected)
+	}
+}
+
+func TestRunMergeTOCFile(t *testing.T) {
+	tempDir := t.TempDir()
+	docs := filepath.Join(tempDir, "docs")
+	site := filepath.Join(tempDir, "site")
+	for _, dir := range []string{docs, site} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"01-intro.md": "# Intro\n\n## Setup\n",
+		"02-usage.md": "# Usage\n\n## Setup\n\n## Setup\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(site, "book.md")
+	tocFile := filepath.Join(tempDir, "index.md")
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2, MergeGenerateTOC: true, MergeTOCDepth: 3, MergeAdjustHeaders: true},
+		[]string{docs, output, "--toc-file", tocFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runMerge(cliArgs); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toc, err := os.ReadFile(tocFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(merged), "Table of Contents") {
+		t.Errorf("Expected no inline TOC in the main output, got:\n%s", merged)
+	}
+
+	// Links are relative to the TOC file and use the slugs of the merged headings, numbered in order
+	expected := mergeProvenance + "# Table of Contents\n\n" +
+		"- [Intro](site/book.md#intro)\n" +
+		"  - [Setup](site/book.md#setup)\n" +
+		"- [Usage](site/book.md#usage)\n" +
+		"  - [Setup](site/book.md#setup-1)\n" +
+		"  - [Setup](site/book.md#setup-2)\n"
+	if string(toc) != expected {
+		t.Errorf("TOC file = %q, want %q", toc, expected)
+	}
+
+	seen := make(map[string]int)
+	var anchors []string
+	for _, header := range extractHeaders(string(merged), 6) {
+		if slug := slugify(header.Text, seen); header.Level > 1 {
+			anchors = append(anchors, "site/book.md#"+slug)
+		}
+	}
+	var links []string
+	for _, match := range regexp.MustCompile(`\]\(([^)]*)\)`).FindAllStringSubmatch(string(toc), -1) {
+		links = append(links, match[1])
+	}
+	if !reflect.DeepEqual(links, anchors) {
+		t.Errorf("TOC links %v do not match the merged headings' anchors %v", links, anchors)
+	}
+}
+
+func TestSeparateFrontMatter(t *testing.T) {
+	content := "---\ntitle: \"Getting Started\"\ntags: [intro]\n---\n\n## Install\n\nText\n"
+
+	tests := []struct {
+		mode                string
+		expectedFrontMatter string
+		expectedBody        string
+	}{
+		{"", "", "## Install\n\nText\n"},
+		{"strip", "", "## Install\n\nText\n"},
+		{"heading", "", "# Getting Started\n\n## Install\n\nText\n"},
+		{"keep", "---\ntitle: \"Getting Started\"\ntags: [intro]\n---\n", "## Install\n\nText\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode_"+tt.mode, func(t *testing.T) {
+			frontMatter, body := separateFrontMatter(&CLIArgs{MergeFrontMatter: tt.mode}, content)
+			if frontMatter != tt.expectedFrontMatter || body != tt.expectedBody {
+				t.Errorf("separateFrontMatter() = %q, %q; want %q, %q", frontMatter, body, tt.expectedFrontMatter, tt.expectedBody)
+			}
+		})
+	}
+
+	// A thematic break later in the file is not front matter
+	plain := "# Title\n\n---\n\nMore\n"
+	if frontMatter, body := separateFrontMatter(&CLIArgs{}, plain); frontMatter != "" || body != plain {
+		t.Errorf("Expected content without front matter unchanged, got %q, %q", frontMatter, body)
+	}
+
+	// Front matter without a title falls back to stripping in heading mode
+	untitled := "---\nauthor: me\n---\nBody\n"
+	if _, body := separateFrontMatter(&CLIArgs{MergeFrontMatter: "heading"}, untitled); body != "Body\n" {
+		t.Errorf("Expected untitled front matter to be stripped, got %q", body)
+	}
+}
+
+func TestMergeFileContentFileHeadings(t *testing.T) {
+	cliArgs := &CLIArgs{MergeBaseLevel: 2, MergeFileHeadings: true}
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"Leading H1 kept", "intro.md", "# Welcome\n\nText\n", "# Welcome\n\nText\n"},
+		{"Heading from filename", "02-getting_started.md", "## Install\n", "# Getting Started\n\n## Install\n"},
+		{"Heading from front matter title", "a.md", "---\ntitle: Setup\n---\nText\n", "# Setup\n\nText\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := *cliArgs
+			if strings.HasPrefix(tt.content, "---") {
+				args.MergeFrontMatter = "heading"
+			}
+			_, body, baseLevel := mergeFileContent(&args, MarkdownFile{Name: tt.file}, tt.content, false)
+			if body != tt.expected || baseLevel != 2 {
+				t.Errorf("mergeFileContent() = %q, %d; want %q, 2", body, baseLevel, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileTitle(t *testing.T) {
+	tests := map[string]string{
+		"getting-started.md": "Getting Started",
+		"01_intro.md":        "Intro",
+		"2024.md":            "2024",
+		"README.md":          "Readme",
+	}
+	for input, expected := range tests {
+		if got := fileTitle(input); got != expected {
+			t.Errorf("fileTitle(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestFileSeparator(t *testing.T) {
+	tests := []struct {
+		name     string
+		cliArgs  CLIArgs
+		expected string
+	}{
+		{"Separator", CLIArgs{MergeSeparator: "\n\n---\n\n"}, "\n\n---\n\n"},
+		{"Default page break", CLIArgs{MergeSeparator: "\n\n---\n\n", MergePageBreaks: true}, "\n\n" + defaultPageBreakMarker + "\n\n"},
+		{"Custom page break", CLIArgs{MergePageBreaks: true, MergePageBreakMarker: `\newpage`}, "\n\n\\newpage\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileSeparator(&tt.cliArgs); got != tt.expected {
+				t.Errorf("fileSeparator() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{"Plain text", "# Title\n\nOne two three.", 5},
+		{"Fenced code skipped", "Before\n\n```go\nfunc main() {}\n```\n\nAfter", 2},
+		{"Inline code skipped", "Run `doc merge` now", 2},
+		{"Empty", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countWords(tt.input); got != tt.expected {
+				t.Errorf("countWords(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := map[int]int{0: 1, 1: 1, 200: 1, 201: 2, 1000: 5}
+	for words, expected := range tests {
+		if got := readingMinutes(words); got != expected {
+			t.Errorf("readingMinutes(%d) = %d, want %d", words, got, expected)
+		}
+	}
+}
+
+func TestRenderMergeNoTitle(t *testing.T) {
+	tempDir := t.TempDir()
+	contents := map[string]string{"01-intro.md": "# Intro\n\n## Setup\n", "02-usage.md": "# Usage\n"}
+	var files []MarkdownFile
+	for _, name := range []string{"01-intro.md", "02-usage.md"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(contents[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, MarkdownFile{Path: path, Name: name})
+	}
+
+	cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeGenerateTOC: true, MergeTOCDepth: 2},
+		[]string{tempDir, "--no-title", "--base-level", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := renderMerge(&buf, cliArgs, files, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the document title the files' H1s are the top level of the TOC
+	expected := mergeProvenance + "# Table of Contents\n\n" +
+		"- [Intro](#intro)\n" +
+		"  - [Setup](#setup)\n" +
+		"- [Usage](#usage)\n\n"
+	if got := buf.String(); !strings.HasPrefix(got, expected) {
+		t.Errorf("Merged output = %q, want prefix %q", got, expected)
+	}
+}
+
+func TestRenderMergeSmartTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		extra    []string
+		expected string
+		contains []string
+	}{
+		{
+			name:  "First file with an H1",
+			first: "# Handbook\n\n## Setup\n\nText\n",
+			extra: []string{"--smart-title"},
+			// The H1 becomes the title and the file's H2 sections sit at the top of the TOC beside the other files
+			expected: mergeProvenance + "# Handbook\n\n## Table of Contents\n\n" +
+				"- [Setup](#setup)\n" +
+				"- [Usage](#usage)\n" +
+				"  - [Options](#options)\n\n" +
+				"## Setup\n\nText\n\n\n---\n\n" +
+				"## Usage\n\n### Options\n",
+		},
+		{
+			name:     "First file without an H1",
+			first:    "Intro text\n\n## Setup\n",
+			extra:    []string{"--smart-title"},
+			contains: []string{mergeProvenance + "# Book\n\n", "\n\nIntro text\n\n### Setup\n", "\n- [Usage](#usage)\n  - [Options](#options)\n"},
+		},
+		{
+			name:  "Explicit --title wins",
+			first: "# Handbook\n\n## Setup\n\nText\n",
+			extra: []string{"--smart-title", "--title", "Manual"},
+			expected: mergeProvenance + "# Manual\n\n## Table of Contents\n\n" +
+				"- [Handbook](#handbook)\n" +
+				"  - [Setup](#setup)\n" +
+				"- [Usage](#usage)\n" +
+				"  - [Options](#options)\n\n" +
+				"## Handbook\n\n### Setup\n\nText\n\n\n---\n\n" +
+				"## Usage\n\n### Options\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			contents := map[string]string{"01-intro.md": tt.first, "02-usage.md": "# Usage\n\n## Options\n"}
+			var files []MarkdownFile
+			for _, name := range []string{"01-intro.md", "02-usage.md"} {
+				path := filepath.Join(tempDir, name)
+				if err := os.WriteFile(path, []byte(contents[name]), 0644); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, MarkdownFile{Path: path, Name: name})
+			}
+
+			cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeSeparator: "\n\n---\n\n", MergeBaseLevel: 2, MergeGenerateTOC: true, MergeTOCDepth: 3, MergeAdjustHeaders: true},
+				append([]string{tempDir, filepath.Join(tempDir, "book.md")}, tt.extra...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := renderMerge(&buf, cliArgs, files, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			got := buf.String()
+			if tt.expected != "" && got != tt.expected {
+				t.Errorf("Merged output = %q, want %q", got, tt.expected)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Expected %q in merged output, got %q", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitLeadingH1(t *testing.T) {
+	tests := []struct {
+		content string
+		title   string
+		rest    string
+		ok      bool
+	}{
+		{"# Guide\n\nText\n", "Guide", "Text\n", true},
+		{"\n\nGuide\n=====\n\nText\n", "Guide", "Text\n", true},
+		{"## Setup\n", "", "## Setup\n", false},
+		{"Text\n\n# Guide\n", "", "Text\n\n# Guide\n", false},
+	}
+
+	for _, tt := range tests {
+		title, rest, ok := splitLeadingH1(tt.content)
+		if title != tt.title || rest != tt.rest || ok != tt.ok {
+			t.Errorf("splitLeadingH1(%q) = %q, %q, %v, want %q, %q, %v", tt.content, title, rest, ok, tt.title, tt.rest, tt.ok)
+		}
+	}
+}
+
+func TestTitleFileBaseLevel(t *testing.T) {
+	for baseLevel, expected := range map[int]int{1: 1, 2: 1, 3: 2} {
+		if result := titleFileBaseLevel(baseLevel); result != expected {
+			t.Errorf("titleFileBaseLevel(%d) = %d, want %d", baseLevel, result, expected)
+		}
+	}
+}
+
+func TestRenderMergeAppendSources(t *testing.T) {
+	tempDir := t.TempDir()
+	guide := filepath.Join(tempDir, "guide")
+	reference := filepath.Join(tempDir, "reference")
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	files := []MarkdownFile{
+		{Path: filepath.Join(guide, "setup", "install.md"), Name: "install.md", Root: guide, Size: 2048, ModTime: modTime},
+		{Path: filepath.Join(reference, "api.md"), Name: "api.md", Root: reference, Size: 12, ModTime: modTime},
+	}
+	contents := []string{"# Install\n\n## Sources\n\nDownloads\n", "# API\n"}
+	for i, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file.Path, []byte(contents[i]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	render := func(args ...string) string {
+		cliArgs, err := parseMergeArgs(&CLIArgs{MergeOrder: "filename", MergeBaseLevel: 2, MergeGenerateTOC: true, MergeTOCDepth: 2, MergeAdjustHeaders: true},
+			append(args, "--append-sources", "--base-level", "3"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := renderMerge(&buf, cliArgs, files, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	// A single input directory lists paths relative to it; the heading follows --base-level
+	single := render(guide, filepath.Join(tempDir, "book.md"))
+	expected := "\n### Sources\n\n" +
+		"- `setup/install.md` (2.0 KB, modified 2024-01-02 03:04:05)\n" +
+		"- `api.md` (12 B, modified 2024-01-02 03:04:05)\n"
+	if !strings.HasSuffix(single, expected) {
+		t.Errorf("Expected the output to end with %q, got:\n%s", expected, single)
+	}
+
+	// The TOC links the appended section with the slug its heading gets after every earlier heading;
+	// the file's own Sources heading comes first, so it is sources-1
+	seen := make(map[string]int)
+	for _, heading := range []string{"Book", "Table of Contents", "Install", "Sources", "API"} {
+		slugify(heading, seen)
+	}
+	entry := fmt.Sprintf("  - [Sources](#%s)\n\n", slugify("Sources", seen))
+	if !strings.Contains(single, "- [API](#api)\n"+entry) {
+		t.Errorf("Expected the TOC to end with %q, got:\n%s", entry, single)
+	}
+
+	// With several input directories each path keeps its directory
+	multiple := render(guide, reference, filepath.Join(tempDir, "book.md"))
+	for _, path := range []string{filepath.Join(guide, "setup", "install.md"), filepath.Join(reference, "api.md")} {
+		if !strings.Contains(multiple, "- `"+filepath.ToSlash(path)+"` (") {
+			t.Errorf("Expected %s in the sources section, got:\n%s", path, multiple)
+		}
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"500", 500, false},
+		{"500B", 500, false},
+		{"10KB", 10 * 1024, false},
+		{"5MB", 5 * 1024 * 1024, false},
+		{"5m", 5 * 1024 * 1024, false},
+		{"1.5 GB", 3 * 512 * 1024 * 1024, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-1MB", 0, true},
+		{"five", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseFileSize(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFileSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseFileSize(%q) = %d, want %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestListedMergeFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"b.md", "a.md"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := filepath.Join(tempDir, "b.md") + "\n\n" + filepath.Join(tempDir, "a.md") + "\r\n"
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{"filename", []string{"a.md", "b.md"}},
+		{"custom", []string{"b.md", "a.md"}}, // The listed order
+	}
+	for _, tt := range tests {
+		files, err := listedMergeFiles(&CLIArgs{MergeOrder: tt.order}, strings.NewReader(list))
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, len(files))
+		for i, file := range files {
+			names[i] = file.Name
+		}
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("listedMergeFiles(order %s) = %v, want %v", tt.order, names, tt.expected)
+		}
+	}
+
+	missing := filepath.Join(tempDir, "missing.md")
+	if _, err := listedMergeFiles(&CLIArgs{MergeOrder: "filename"}, strings.NewReader(missing+"\n")); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("Expected an error naming %s, got %v", missing, err)
+	}
+}
+
+// BenchmarkRenderMerge compares merging files read from disk by every pass with loading each
+// file once up front, as runMerge does
+func BenchmarkRenderMerge(b *testing.B) {
+	tempDir := b.TempDir()
+	section := strings.Repeat("Some text for the section body.\n\n", 20)
+	var files []MarkdownFile
+	for i := range 200 {
+		name := fmt.Sprintf("%03d-chapter.md", i)
+		content := fmt.Sprintf("# Chapter %d\n\n## Setup\n\n%s## Usage\n\n%s", i, section, section)
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+		files = append(files, MarkdownFile{Path: path, Name: name, Size: int64(len(content))})
+	}
+	cliArgs := &CLIArgs{
+		MergeOutputFile:    filepath.Join(tempDir, "merged.md"),
+		MergeGenerateTOC:   true,
+		MergeTOCDepth:      3,
+		MergeBaseLevel:     2,
+		MergeAdjustHeaders: true,
+		MergeSeparator:     "\n\n---\n\n",
+		MergeSmartTitle:    true,
+		MergeReadingTime:   true,
+	}
+
+	b.Run("ReadPerPass", func(b *testing.B) {
+		for b.Loop() {
+			if err := renderMerge(io.Discard, cliArgs, files, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("LoadOnce", func(b *testing.B) {
+		for b.Loop() {
+			loaded := slices.Clone(files)
+			loadFileContents(loaded)
+			if err := renderMerge(io.Discard, cliArgs, loaded, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
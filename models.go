@@ -1,6 +1,21 @@
 package main
 
-import "github.com/bigdra50/doc/internal/config"
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bigdra50/doc/internal/config"
+)
+
+// claudeCodeContextWindow is the context window shared by the Claude Code CLI model aliases
+const claudeCodeContextWindow = 200000
+
+// defaultOutputTokenReserve is the number of tokens kept free for the model's response
+const defaultOutputTokenReserve = 4000
 
 // Model represents an LLM model with its characteristics
 type Model struct {
@@ -9,6 +24,7 @@ type Model struct {
 	InputCostPer1M  float64  `json:"input_cost_per_1m"`
 	OutputCostPer1M float64  `json:"output_cost_per_1m"`
 	ContextWindow   int      `json:"context_window"`
+	MaxOutputTokens int      `json:"max_output_tokens"` // Largest max_tokens the API accepts
 	Tier            string   `json:"tier"`
 	RecommendedFor  []string `json:"recommended_for"`
 }
@@ -29,6 +45,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  30.00,
 				OutputCostPer1M: 60.00,
 				ContextWindow:   8192,
+				MaxOutputTokens: 8192,
 				Tier:            "premium",
 				RecommendedFor:  []string{"complex_reasoning", "code_generation"},
 			},
@@ -38,6 +55,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  10.00,
 				OutputCostPer1M: 30.00,
 				ContextWindow:   128000,
+				MaxOutputTokens: 4096,
 				Tier:            "balanced",
 				RecommendedFor:  []string{"general_translation", "balanced_performance"},
 			},
@@ -47,6 +65,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  2.50,
 				OutputCostPer1M: 10.00,
 				ContextWindow:   128000,
+				MaxOutputTokens: 16384,
 				Tier:            "balanced",
 				RecommendedFor:  []string{"document_with_images", "complex_formatting"},
 			},
@@ -56,6 +75,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  0.15,
 				OutputCostPer1M: 0.60,
 				ContextWindow:   128000,
+				MaxOutputTokens: 16384,
 				Tier:            "economy",
 				RecommendedFor:  []string{"simple_translation", "high_volume"},
 			},
@@ -65,9 +85,100 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  0.50,
 				OutputCostPer1M: 1.50,
 				ContextWindow:   16000,
+				MaxOutputTokens: 4096,
 				Tier:            "economy",
 				RecommendedFor:  []string{"budget_translation"},
 			},
+			{
+				ID:              "gpt-4o-2024-08-06",
+				Name:            "GPT-4o (2024-08-06)",
+				InputCostPer1M:  2.50,
+				OutputCostPer1M: 10.00,
+				ContextWindow:   128000,
+				MaxOutputTokens: 16384,
+				Tier:            "balanced",
+				RecommendedFor:  []string{"pinned_version", "complex_formatting"},
+			},
+			{
+				ID:              "gpt-4o-2024-11-20",
+				Name:            "GPT-4o (2024-11-20)",
+				InputCostPer1M:  2.50,
+				OutputCostPer1M: 10.00,
+				ContextWindow:   128000,
+				MaxOutputTokens: 16384,
+				Tier:            "balanced",
+				RecommendedFor:  []string{"pinned_version", "general_translation"},
+			},
+			{
+				ID:              "gpt-4.1",
+				Name:            "GPT-4.1",
+				InputCostPer1M:  2.00,
+				OutputCostPer1M: 8.00,
+				ContextWindow:   1047576,
+				MaxOutputTokens: 32768,
+				Tier:            "balanced",
+				RecommendedFor:  []string{"long_documents", "general_translation"},
+			},
+			{
+				ID:              "gpt-4.1-mini",
+				Name:            "GPT-4.1 Mini",
+				InputCostPer1M:  0.40,
+				OutputCostPer1M: 1.60,
+				ContextWindow:   1047576,
+				MaxOutputTokens: 32768,
+				Tier:            "economy",
+				RecommendedFor:  []string{"long_documents", "high_volume"},
+			},
+			{
+				ID:              "gpt-4.1-nano",
+				Name:            "GPT-4.1 Nano",
+				InputCostPer1M:  0.10,
+				OutputCostPer1M: 0.40,
+				ContextWindow:   1047576,
+				MaxOutputTokens: 32768,
+				Tier:            "economy",
+				RecommendedFor:  []string{"budget_translation", "high_volume"},
+			},
+			{
+				ID:              "o1",
+				Name:            "o1",
+				InputCostPer1M:  15.00,
+				OutputCostPer1M: 60.00,
+				ContextWindow:   200000,
+				MaxOutputTokens: 100000,
+				Tier:            "premium",
+				RecommendedFor:  []string{"complex_reasoning", "technical_documents"},
+			},
+			{
+				ID:              "o3",
+				Name:            "o3",
+				InputCostPer1M:  2.00,
+				OutputCostPer1M: 8.00,
+				ContextWindow:   200000,
+				MaxOutputTokens: 100000,
+				Tier:            "premium",
+				RecommendedFor:  []string{"complex_reasoning", "technical_documents"},
+			},
+			{
+				ID:              "o3-mini",
+				Name:            "o3-mini",
+				InputCostPer1M:  1.10,
+				OutputCostPer1M: 4.40,
+				ContextWindow:   200000,
+				MaxOutputTokens: 100000,
+				Tier:            "balanced",
+				RecommendedFor:  []string{"technical_documents", "code_generation"},
+			},
+			{
+				ID:              "o4-mini",
+				Name:            "o4-mini",
+				InputCostPer1M:  1.10,
+				OutputCostPer1M: 4.40,
+				ContextWindow:   200000,
+				MaxOutputTokens: 100000,
+				Tier:            "balanced",
+				RecommendedFor:  []string{"technical_documents", "code_generation"},
+			},
 		},
 		Anthropic: []Model{
 			{
@@ -76,6 +187,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  15.00,
 				OutputCostPer1M: 75.00,
 				ContextWindow:   200000,
+				MaxOutputTokens: 4096,
 				Tier:            "premium",
 				RecommendedFor:  []string{"complex_reasoning", "code_generation"},
 			},
@@ -85,6 +197,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  3.00,
 				OutputCostPer1M: 15.00,
 				ContextWindow:   200000,
+				MaxOutputTokens: 4096,
 				Tier:            "balanced",
 				RecommendedFor:  []string{"general_translation", "balanced_performance"},
 			},
@@ -94,6 +207,17 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  3.00,
 				OutputCostPer1M: 15.00,
 				ContextWindow:   200000,
+				MaxOutputTokens: 8192,
+				Tier:            "balanced",
+				RecommendedFor:  []string{"general_translation", "advanced_reasoning"},
+			},
+			{
+				ID:              "claude-3-7-sonnet-20250219",
+				Name:            "Claude 3.7 Sonnet",
+				InputCostPer1M:  3.00,
+				OutputCostPer1M: 15.00,
+				ContextWindow:   200000,
+				MaxOutputTokens: 64000,
 				Tier:            "balanced",
 				RecommendedFor:  []string{"general_translation", "advanced_reasoning"},
 			},
@@ -103,6 +227,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  0.25,
 				OutputCostPer1M: 1.25,
 				ContextWindow:   200000,
+				MaxOutputTokens: 4096,
 				Tier:            "economy",
 				RecommendedFor:  []string{"simple_translation", "high_volume"},
 			},
@@ -112,6 +237,7 @@ func GetModelCatalog() ModelCatalog {
 				InputCostPer1M:  0.80,
 				OutputCostPer1M: 4.00,
 				ContextWindow:   200000,
+				MaxOutputTokens: 8192,
 				Tier:            "economy",
 				RecommendedFor:  []string{"simple_translation", "high_volume"},
 			},
@@ -143,6 +269,28 @@ func FindModel(provider, modelID string) *Model {
 	return nil
 }
 
+// warnUnknownModels warns about configured API models that are missing from the model catalog,
+// since cost estimates and context window checks are unavailable for them
+func warnUnknownModels(cfg ProviderConfig) {
+	models := []struct {
+		provider, key, model string
+	}{
+		{ProviderTypeOpenAI, "openai_model", cfg.OpenAIModel},
+		{ProviderTypeAnthropic, "anthropic_model", cfg.AnthropicModel},
+	}
+
+	for _, m := range models {
+		// OpenAI-compatible gateways and Azure deployments serve their own models
+		if m.provider == ProviderTypeOpenAI && (cfg.OpenAIBaseURL != config.DefaultOpenAIBaseURL || usesAzureOpenAI(cfg)) {
+			continue
+		}
+		if m.model != "" && FindModel(m.provider, m.model) == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s %q is not a known %s model (see doc --list-models %s)\n",
+				m.key, m.model, m.provider, m.provider)
+		}
+	}
+}
+
 // GetDefaultModel returns the default model for a provider
 // Delegates to config package to avoid duplication
 func GetDefaultModel(provider string) string {
@@ -152,7 +300,34 @@ func GetDefaultModel(provider string) string {
 
 // GetModelsByTier returns models filtered by tier
 func GetModelsByTier(provider, tier string) []Model {
-	models := GetModelsByProvider(provider)
+	return FilterModelsByTier(GetModelsByProvider(provider), tier)
+}
+
+// tierRank orders tiers from cheapest to most capable
+var tierRank = map[string]int{
+	"economy":  0,
+	"balanced": 1,
+	"premium":  2,
+}
+
+// ModelTiers returns the tiers used by the catalog, cheapest first
+func ModelTiers() []string {
+	catalog := GetModelCatalog()
+	var tiers []string
+	for _, model := range append(catalog.OpenAI, catalog.Anthropic...) {
+		if !slices.Contains(tiers, model.Tier) {
+			tiers = append(tiers, model.Tier)
+		}
+	}
+	sort.SliceStable(tiers, func(i, j int) bool { return tierRank[tiers[i]] < tierRank[tiers[j]] })
+	return tiers
+}
+
+// FilterModelsByTier returns models matching tier, or all models if tier is empty
+func FilterModelsByTier(models []Model, tier string) []Model {
+	if tier == "" {
+		return models
+	}
 	var filtered []Model
 	for _, model := range models {
 		if model.Tier == tier {
@@ -162,6 +337,67 @@ func GetModelsByTier(provider, tier string) []Model {
 	return filtered
 }
 
+// SortModels returns a copy of models sorted by cost (cheapest first), context (largest first)
+// or tier (economy first). Unknown or empty keys keep catalog order.
+func SortModels(models []Model, sortBy string) []Model {
+	sorted := make([]Model, len(models))
+	copy(sorted, models)
+
+	switch sortBy {
+	case "cost":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].InputCostPer1M+sorted[i].OutputCostPer1M < sorted[j].InputCostPer1M+sorted[j].OutputCostPer1M
+		})
+	case "context":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].ContextWindow > sorted[j].ContextWindow
+		})
+	case "tier":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return tierRank[sorted[i].Tier] < tierRank[sorted[j].Tier]
+		})
+	}
+
+	return sorted
+}
+
+// RecommendModel returns the cheapest model of provider whose context window holds a document of
+// inputChars characters together with its translation. When prefer names a tier or a
+// RecommendedFor use case, matching models are chosen first. It returns nil if no model fits.
+func RecommendModel(provider string, inputChars int, prefer string) *Model {
+	inputTokens := (inputChars + 3) / 4
+	outputTokens := max(inputTokens*outputGrowthPercent/100, defaultOutputTokenReserve)
+
+	var fitting, preferred []Model
+	for _, model := range GetModelsByProvider(provider) {
+		if model.ContextWindow < inputTokens+outputTokens {
+			continue
+		}
+		fitting = append(fitting, model)
+		if prefer != "" && (model.Tier == prefer || slices.Contains(model.RecommendedFor, prefer)) {
+			preferred = append(preferred, model)
+		}
+	}
+
+	candidates := fitting
+	if len(preferred) > 0 {
+		candidates = preferred
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	inputLength, outputLength := inputChars, inputChars*outputGrowthPercent/100
+	sort.SliceStable(candidates, func(i, j int) bool {
+		costI, costJ := EstimateCost(candidates[i], inputLength, outputLength), EstimateCost(candidates[j], inputLength, outputLength)
+		if costI != costJ {
+			return costI < costJ
+		}
+		return candidates[i].InputCostPer1M+candidates[i].OutputCostPer1M < candidates[j].InputCostPer1M+candidates[j].OutputCostPer1M
+	})
+	return &candidates[0]
+}
+
 // EstimateCost estimates the cost for a translation request
 func EstimateCost(model Model, inputLength, outputLength int) float64 {
 	// Rough estimation: 1 token ≈ 4 characters
@@ -173,3 +409,105 @@ func EstimateCost(model Model, inputLength, outputLength int) float64 {
 
 	return inputCost + outputCost
 }
+
+// TokenCost computes the cost of a request from its token counts
+func TokenCost(model Model, inputTokens, outputTokens int) float64 {
+	inputCost := (float64(inputTokens) / 1000000.0) * model.InputCostPer1M
+	outputCost := (float64(outputTokens) / 1000000.0) * model.OutputCostPer1M
+
+	return inputCost + outputCost
+}
+
+// claudeCodePricingModels maps Claude Code CLI model aliases to the catalog entries used for pricing
+var claudeCodePricingModels = map[string]string{
+	"opus":   "claude-3-opus-20240229",
+	"sonnet": "claude-3-5-sonnet-20241022",
+	"haiku":  "claude-3-5-haiku-20241022",
+}
+
+// pricingModel returns the catalog entry used to price requests to a provider's model, or nil if unknown
+func pricingModel(provider, modelID string) *Model {
+	if provider == ProviderTypeClaude {
+		return FindModel(ProviderTypeAnthropic, claudeCodePricingModels[modelID])
+	}
+	return FindModel(provider, modelID)
+}
+
+// estimateTokens roughly estimates the token count of text (1 token ≈ 4 characters)
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// contextWindowFor returns the context window of a model, or 0 if it is unknown
+func contextWindowFor(provider, modelID string) int {
+	if provider == ProviderTypeClaude {
+		return claudeCodeContextWindow
+	}
+	if model := FindModel(provider, modelID); model != nil {
+		return model.ContextWindow
+	}
+	return 0
+}
+
+// outputGrowthPercent is how long a translation may get relative to its source; translations into
+// languages such as German or Japanese often need more tokens than the English original
+const outputGrowthPercent = 150
+
+// maxOutputTokensFor returns the largest output token limit of a model, or 0 if it is unknown
+func maxOutputTokensFor(provider, modelID string) int {
+	if model := FindModel(provider, modelID); model != nil {
+		return model.MaxOutputTokens
+	}
+	return 0
+}
+
+// outputTokenLimit returns the max_tokens of a request. An override is used as given (checkPromptFits
+// rejects it if it cannot fit); otherwise the limit leaves room for the translation of content to
+// grow, never drops below defaultOutputTokenReserve and is capped at what the context window has
+// left after the prompt and at the model's MaxOutputTokens.
+func outputTokenLimit(provider, modelID, prompt, content string, override int) int {
+	if override > 0 {
+		return override
+	}
+
+	limit := max(estimateTokens(content)*outputGrowthPercent/100, defaultOutputTokenReserve)
+	if contextWindow := contextWindowFor(provider, modelID); contextWindow > 0 {
+		limit = min(limit, max(contextWindow-estimateTokens(prompt), defaultOutputTokenReserve))
+	}
+	if maxOutput := maxOutputTokensFor(provider, modelID); maxOutput > 0 {
+		limit = min(limit, maxOutput)
+	}
+	return limit
+}
+
+// checkPromptFits fails fast when a prompt plus the output reserve exceeds the model's context window.
+// Unknown models are not checked.
+func checkPromptFits(provider, modelID, prompt string, outputReserve int) error {
+	contextWindow := contextWindowFor(provider, modelID)
+	if contextWindow == 0 {
+		return nil
+	}
+
+	promptTokens := estimateTokens(prompt)
+	if promptTokens+outputReserve <= contextWindow {
+		return nil
+	}
+
+	msg := fmt.Sprintf("prompt is too large for %s: ~%d tokens plus %d reserved for output exceeds the %d token context window",
+		modelID, promptTokens, outputReserve, contextWindow)
+
+	// Suggest larger-context models from the catalog
+	var larger []string
+	for _, model := range GetModelsByProvider(provider) {
+		if model.ContextWindow >= promptTokens+outputReserve {
+			larger = append(larger, model.ID)
+		}
+	}
+	if len(larger) > 0 {
+		msg += fmt.Sprintf("; lower --max-chunk-tokens or use a larger-context model (%s)", strings.Join(larger, ", "))
+	} else {
+		msg += "; lower --max-chunk-tokens to split the document into smaller parts"
+	}
+
+	return fmt.Errorf("%s", msg)
+}
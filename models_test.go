@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bigdra50/doc/internal/config"
+)
+
+func TestCheckPromptFits(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		model       string
+		promptChars int
+		wantErr     bool
+	}{
+		{"Small prompt fits", ProviderTypeOpenAI, "gpt-4o-mini", 1000, false},
+		{"Oversized prompt for small window", ProviderTypeOpenAI, "gpt-4", 40000, true},
+		{"Oversized prompt for Claude Code", ProviderTypeClaude, "sonnet", 900000, true},
+		{"Unknown model is not checked", ProviderTypeOpenAI, "my-custom-model", 10000000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := strings.Repeat("a", tt.promptChars)
+			err := checkPromptFits(tt.provider, tt.model, prompt, defaultOutputTokenReserve)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPromptFits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutputTokenLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        string
+		promptChars  int
+		contentChars int
+		override     int
+		expected     int
+	}{
+		{"Short document keeps the reserve", "gpt-4o", 600, 400, 0, defaultOutputTokenReserve},
+		{"Long document grows with the input", "gpt-4o", 40400, 40000, 0, 15000},
+		{"Capped by the context window", "gpt-4", 16400, 16000, 0, 4092},
+		{"Never below the reserve", "gpt-4", 20400, 20000, 0, defaultOutputTokenReserve},
+		{"Capped by the model's max output", "gpt-4o", 60400, 60000, 0, 16384},
+		{"Capped by a small max output", "gpt-4-turbo", 40400, 40000, 0, 4096},
+		{"Unknown model is not capped", "my-custom-model", 40400, 40000, 0, 15000},
+		{"Override", "gpt-4o", 40400, 40000, 123, 123},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := strings.Repeat("a", tt.promptChars)
+			content := strings.Repeat("a", tt.contentChars)
+			if limit := outputTokenLimit(ProviderTypeOpenAI, tt.model, prompt, content, tt.override); limit != tt.expected {
+				t.Errorf("outputTokenLimit() = %d, want %d", limit, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCatalogMaxOutputTokens(t *testing.T) {
+	catalog := GetModelCatalog()
+	for _, model := range append(catalog.OpenAI, catalog.Anthropic...) {
+		if model.MaxOutputTokens <= 0 || model.MaxOutputTokens > model.ContextWindow {
+			t.Errorf("%s: MaxOutputTokens = %d, want between 1 and the %d token context window",
+				model.ID, model.MaxOutputTokens, model.ContextWindow)
+		}
+	}
+}
+
+func TestRecommendModel(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		inputChars int
+		prefer     string
+		expected   string
+	}{
+		{"Cheapest model for a short document", ProviderTypeOpenAI, 4000, "", "gpt-4.1-nano"},
+		{"Preferred tier", ProviderTypeOpenAI, 4000, "premium", "o3"},
+		{"Preferred use case", ProviderTypeOpenAI, 4000, "pinned_version", "gpt-4o-2024-08-06"},
+		{"Unknown preference falls back to the cheapest", ProviderTypeOpenAI, 4000, "nonexistent", "gpt-4.1-nano"},
+		{"Document larger than every context window", ProviderTypeOpenAI, 8000000, "", ""},
+		{"Unknown provider", "unknown", 4000, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := RecommendModel(tt.provider, tt.inputChars, tt.prefer)
+			id := ""
+			if model != nil {
+				id = model.ID
+			}
+			if id != tt.expected {
+				t.Errorf("RecommendModel(%q, %d, %q) = %q, want %q", tt.provider, tt.inputChars, tt.prefer, id, tt.expected)
+			}
+		})
+	}
+
+	// Every recommendation must leave room for the translation in the context window
+	for _, chars := range []int{1000, 100000, 600000, 2000000} {
+		model := RecommendModel(ProviderTypeAnthropic, chars, "")
+		if model == nil {
+			continue
+		}
+		if tokens := (chars + 3) / 4; model.ContextWindow < 2*tokens {
+			t.Errorf("RecommendModel(anthropic, %d) = %s with a %d token context, too small for %d input tokens",
+				chars, model.ID, model.ContextWindow, tokens)
+		}
+	}
+}
+
+func TestModelTiers(t *testing.T) {
+	if tiers := strings.Join(ModelTiers(), ","); tiers != "economy,balanced,premium" {
+		t.Errorf("ModelTiers() = %s, want economy,balanced,premium", tiers)
+	}
+}
+
+func TestSortModels(t *testing.T) {
+	models := []Model{
+		{ID: "premium", Tier: "premium", InputCostPer1M: 30, OutputCostPer1M: 60, ContextWindow: 8000},
+		{ID: "economy", Tier: "economy", InputCostPer1M: 0.1, OutputCostPer1M: 0.4, ContextWindow: 16000},
+		{ID: "balanced", Tier: "balanced", InputCostPer1M: 3, OutputCostPer1M: 15, ContextWindow: 200000},
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"cost", []string{"economy", "balanced", "premium"}},
+		{"context", []string{"balanced", "economy", "premium"}},
+		{"tier", []string{"economy", "balanced", "premium"}},
+		{"", []string{"premium", "economy", "balanced"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("sort_"+tt.sortBy, func(t *testing.T) {
+			sorted := SortModels(models, tt.sortBy)
+			ids := make([]string, len(sorted))
+			for i, model := range sorted {
+				ids[i] = model.ID
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("SortModels(%q) = %v, want %v", tt.sortBy, ids, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteModelsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeModelsJSON(&buf, "", "cost", "economy"); err != nil {
+		t.Fatalf("writeModelsJSON() error: %v", err)
+	}
+	var catalog ModelCatalog
+	if err := json.Unmarshal(buf.Bytes(), &catalog); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(catalog.OpenAI) == 0 || len(catalog.Anthropic) == 0 {
+		t.Fatalf("Expected economy models of both providers, got %+v", catalog)
+	}
+	for _, model := range append(catalog.OpenAI, catalog.Anthropic...) {
+		if model.Tier != "economy" {
+			t.Errorf("Model %s has tier %s, want economy", model.ID, model.Tier)
+		}
+	}
+
+	buf.Reset()
+	if err := writeModelsJSON(&buf, ProviderTypeOllama, "", ""); err != nil {
+		t.Fatalf("writeModelsJSON(ollama) error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("writeModelsJSON(ollama) = %s, want []", got)
+	}
+
+	if err := writeModelsJSON(&buf, "gemini", "", ""); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
+
+func TestFormatUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		usage    TokenUsage
+		contains []string
+	}{
+		{
+			name:     "Reported usage",
+			usage:    TokenUsage{Provider: ProviderTypeOpenAI, Model: "gpt-4o-mini", InputTokens: 1000000, OutputTokens: 1000000},
+			contains: []string{"Token usage: 1000000 input + 1000000 output tokens", "~$0.7500", "gpt-4o-mini"},
+		},
+		{
+			name:     "Estimated Claude Code usage",
+			usage:    TokenUsage{Provider: ProviderTypeClaude, Model: "sonnet", InputTokens: 1000, OutputTokens: 1000, Estimated: true},
+			contains: []string{"Estimated token usage", "claude-3-5-sonnet-20241022"},
+		},
+		{
+			name:     "Unknown model",
+			usage:    TokenUsage{Provider: ProviderTypeOpenAI, Model: "custom-model", InputTokens: 10, OutputTokens: 5},
+			contains: []string{"no pricing data for custom-model"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatUsage(tt.usage)
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("formatUsage() = %q, want it to contain %q", result, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateModel(t *testing.T) {
+	cfg := config.Defaults()
+	gateway := config.Defaults()
+	gateway.OpenAIBaseURL = "https://openrouter.ai/api/v1"
+
+	tests := []struct {
+		name     string
+		cfg      ProviderConfig
+		provider string
+		model    string
+		wantErr  bool
+	}{
+		{"OpenAI catalog model", cfg, ProviderTypeOpenAI, "gpt-4o", false},
+		{"Unknown OpenAI model", cfg, ProviderTypeOpenAI, "gpt-9", true},
+		{"Gateway accepts any model", gateway, ProviderTypeOpenAI, "meta-llama/llama-3-70b", false},
+		{"Anthropic catalog model", cfg, ProviderTypeAnthropic, "claude-3-5-haiku-20241022", false},
+		{"Anthropic model for OpenAI", cfg, ProviderTypeOpenAI, "claude-3-5-haiku-20241022", true},
+		{"Claude Code alias", cfg, ProviderTypeClaude, "opus", false},
+		{"Claude Code unknown alias", cfg, ProviderTypeClaude, "gpt-4o", true},
+		{"Ollama accepts local models", cfg, ProviderTypeOllama, "qwen2.5:7b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateModel(tt.cfg, tt.provider, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateModel(%s, %s) error = %v, wantErr %v", tt.provider, tt.model, err, tt.wantErr)
+			}
+		})
+	}
+
+	err := validateModel(cfg, ProviderTypeClaude, "sonet")
+	if err == nil || !strings.Contains(err.Error(), "haiku, opus, sonnet") {
+		t.Errorf("Expected the valid Claude Code models to be listed, got %v", err)
+	}
+}
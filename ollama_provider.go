@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bigdra50/doc/internal/config"
+)
+
+// OllamaProvider implements LLMProvider for a local Ollama server
+type OllamaProvider struct {
+	config     ProviderConfig
+	httpClient *http.Client
+	apiURL     string
+}
+
+// Ollama API structures
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// NewOllamaProvider creates a new Ollama provider
+func NewOllamaProvider(config ProviderConfig) (*OllamaProvider, error) {
+	provider := &OllamaProvider{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: httpTimeout(config),
+		},
+		apiURL: ollamaChatURL(config.OllamaBaseURL),
+	}
+
+	if err := provider.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("ollama provider configuration invalid: %w", err)
+	}
+
+	return provider, nil
+}
+
+// ollamaChatURL builds the chat endpoint from the Ollama base URL
+func ollamaChatURL(baseURL string) string {
+	if baseURL == "" {
+		baseURL = config.DefaultOllamaBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/api/chat"
+}
+
+// ollamaPingTimeout bounds the reachability check made by --list-providers
+const ollamaPingTimeout = 2 * time.Second
+
+// Ping checks that an Ollama server answers at the configured base URL
+func (p *OllamaProvider) Ping(ctx context.Context) error {
+	baseURL := strings.TrimSuffix(p.apiURL, "/api/chat")
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/version", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("no Ollama server at %s", baseURL)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Ollama server at %s answered with status %d", baseURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidateConfig validates the Ollama provider configuration
+func (p *OllamaProvider) ValidateConfig() error {
+	if !strings.HasPrefix(p.apiURL, "http://") && !strings.HasPrefix(p.apiURL, "https://") {
+		return fmt.Errorf("ollama base URL must start with http:// or https://")
+	}
+
+	// No API key is needed; the server is contacted when the first request is made
+	return nil
+}
+
+// GetProviderName returns the name of the provider
+func (p *OllamaProvider) GetProviderName() string {
+	return "Ollama"
+}
+
+// GetSupportedLanguages returns the list of supported language codes
+func (p *OllamaProvider) GetSupportedLanguages() map[string]string {
+	return supportedLanguages
+}
+
+// BuildPrompt returns the system and user messages sent for content
+func (p *OllamaProvider) BuildPrompt(content string, options TranslationOptions) string {
+	return formatChatPrompt(translationSystemPrompt(options), translationUserPrompt(options, content))
+}
+
+// Translate translates the given content using a local Ollama model
+func (p *OllamaProvider) Translate(ctx context.Context, content string, options TranslationOptions) (*TranslationResponse, error) {
+	if p.config.Verbose {
+		log("Using Ollama provider for translation")
+		log("Target language: %s", options.TargetLanguage)
+		if options.CustomInstruction != "" {
+			log("Custom instruction: %s", options.CustomInstruction)
+		}
+	}
+
+	// Get model from configuration
+	model := p.config.OllamaModel
+	if model == "" {
+		model = GetDefaultModel(ProviderTypeOllama)
+	}
+
+	if p.config.Verbose {
+		log("Using Ollama model: %s", model)
+	}
+
+	req := ollamaRequest{
+		Model: model,
+		Messages: []ollamaMessage{
+			{
+				Role:    "system",
+				Content: translationSystemPrompt(options),
+			},
+			{
+				Role:    "user",
+				Content: translationUserPrompt(options, content),
+			},
+		},
+		Stream:  false,
+		Options: ollamaOptions{Temperature: translationTemperature(options)},
+	}
+
+	var response ollamaResponse
+	if err := p.makeAPIRequest(ctx, req, &response); err != nil {
+		return nil, fmt.Errorf("ollama API request failed: %w", err)
+	}
+
+	if response.Message.Content == "" {
+		return nil, fmt.Errorf("no content received from Ollama")
+	}
+
+	if p.config.Verbose {
+		log("Received translation response of length: %d", len(response.Message.Content))
+	}
+
+	return &TranslationResponse{
+		Content: response.Message.Content,
+		Status:  "success",
+		Message: "Translation completed successfully",
+		Usage: &TokenUsage{
+			Provider:     ProviderTypeOllama,
+			Model:        model,
+			InputTokens:  response.PromptEvalCount,
+			OutputTokens: response.EvalCount,
+		},
+	}, nil
+}
+
+// makeAPIRequest makes an HTTP request to the Ollama chat API
+func (p *OllamaProvider) makeAPIRequest(ctx context.Context, req ollamaRequest, response interface{}) error {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if p.config.Verbose {
+		log("Making Ollama API request to %s...", p.apiURL)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed (is the Ollama server running?): %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiError ollamaResponse
+		if json.Unmarshal(body, &apiError) == nil && apiError.Error != "" {
+			return fmt.Errorf("Ollama API error (%d): %s", resp.StatusCode, apiError.Error)
+		}
+		return fmt.Errorf("Ollama API request failed with status %d", resp.StatusCode)
+	}
+
+	if response != nil {
+		if err := json.Unmarshal(body, response); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaProviderTranslate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Path = %q, want /api/chat", r.URL.Path)
+		}
+
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "qwen2.5" || req.Stream {
+			t.Errorf("Unexpected request: model=%q stream=%v", req.Model, req.Stream)
+		}
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || !strings.Contains(req.Messages[1].Content, "Hello") {
+			t.Errorf("Unexpected messages: %+v", req.Messages)
+		}
+
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"こんにちは"},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(ProviderConfig{
+		ProviderType:  ProviderTypeOllama,
+		OllamaModel:   "qwen2.5",
+		OllamaBaseURL: server.URL + "/",
+	})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error: %v", err)
+	}
+
+	response, err := provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "ja"})
+	if err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+	if response.Content != "こんにちは" {
+		t.Errorf("Content = %q, want %q", response.Content, "こんにちは")
+	}
+	if response.Usage == nil || response.Usage.InputTokens != 12 || response.Usage.OutputTokens != 3 {
+		t.Errorf("Usage = %+v, want 12 input and 3 output tokens", response.Usage)
+	}
+}
+
+func TestOllamaProviderTranslateAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(ProviderConfig{
+		ProviderType:  ProviderTypeOllama,
+		OllamaModel:   "missing",
+		OllamaBaseURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error: %v", err)
+	}
+
+	_, err = provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "ja"})
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	if !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("Error %q should include status code and API message", err)
+	}
+}
+
+func TestOllamaProviderPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":"0.5.7"}`))
+	}))
+	defer server.Close()
+
+	ping := func(baseURL string) error {
+		provider, err := NewOllamaProvider(ProviderConfig{ProviderType: ProviderTypeOllama, OllamaBaseURL: baseURL})
+		if err != nil {
+			t.Fatalf("NewOllamaProvider() error: %v", err)
+		}
+		return provider.Ping(context.Background())
+	}
+
+	if err := ping(server.URL + "/"); err != nil {
+		t.Errorf("Ping() error: %v", err)
+	}
+	if err := ping(server.URL + "/proxy"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the status code in the error, got %v", err)
+	}
+
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+	if err := ping(stopped.URL); err == nil || !strings.Contains(err.Error(), stopped.URL) {
+		t.Errorf("Expected an error naming the unreachable URL, got %v", err)
+	}
+}
@@ -1,20 +1,35 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/bigdra50/doc/internal/config"
 )
 
+// openAIRetryBaseDelay is the first backoff delay; it doubles with each retry
+var openAIRetryBaseDelay = time.Second
+
+// openAIMaxRetryDelay caps backoff and Retry-After delays
+const openAIMaxRetryDelay = time.Minute
+
 // OpenAIProvider implements LLMProvider for OpenAI API
 type OpenAIProvider struct {
 	config     ProviderConfig
 	httpClient *http.Client
 	apiKey     string
+	apiURL     string
+	azure      bool // Azure OpenAI deployment, authenticated with an api-key header
 }
 
 // OpenAI API structures
@@ -23,8 +38,18 @@ type openAIRequest struct {
 	Messages    []openAIMessage `json:"messages"`
 	Tools       []openAITool    `json:"tools,omitempty"`
 	ToolChoice  string          `json:"tool_choice,omitempty"`
-	MaxTokens   int             `json:"max_tokens"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
 	Temperature float64         `json:"temperature"`
+
+	// Reasoning models (o-series) take max_completion_tokens instead of max_tokens
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openAIMessage struct {
@@ -57,13 +82,31 @@ type openAIFunctionCall struct {
 
 type openAIResponse struct {
 	Choices []openAIChoice `json:"choices"`
+	Usage   *openAIUsage   `json:"usage,omitempty"`
 	Error   *openAIError   `json:"error,omitempty"`
 }
 
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
 type openAIChoice struct {
 	Message openAIMessage `json:"message"`
 }
 
+// openAIStreamEvent is one "data:" event of a streamed response.
+// With include_usage, the final event carries usage and no choices.
+type openAIStreamEvent struct {
+	Choices []openAIStreamChoice `json:"choices"`
+	Usage   *openAIUsage         `json:"usage,omitempty"`
+	Error   *openAIError         `json:"error,omitempty"`
+}
+
+type openAIStreamChoice struct {
+	Delta openAIMessage `json:"delta"`
+}
+
 type openAIError struct {
 	Message string `json:"message"`
 	Type    string `json:"type"`
@@ -79,9 +122,14 @@ func NewOpenAIProvider(config ProviderConfig) (*OpenAIProvider, error) {
 	provider := &OpenAIProvider{
 		config: config,
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: httpTimeout(config),
 		},
 		apiKey: config.OpenAIAPIKey,
+		apiURL: openAIChatCompletionsURL(config.OpenAIBaseURL),
+	}
+	if usesAzureOpenAI(config) {
+		provider.azure = true
+		provider.apiURL = azureChatCompletionsURL(config.AzureEndpoint, config.AzureDeployment, config.AzureAPIVersion)
 	}
 
 	if err := provider.ValidateConfig(); err != nil {
@@ -91,11 +139,43 @@ func NewOpenAIProvider(config ProviderConfig) (*OpenAIProvider, error) {
 	return provider, nil
 }
 
+// openAIChatCompletionsURL builds the Chat Completions endpoint from an API base URL,
+// accepting base URLs with or without a trailing slash
+func openAIChatCompletionsURL(baseURL string) string {
+	if baseURL == "" {
+		baseURL = config.DefaultOpenAIBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/chat/completions"
+}
+
+// usesAzureOpenAI reports whether the openai provider is configured for Azure OpenAI
+func usesAzureOpenAI(cfg ProviderConfig) bool {
+	return cfg.AzureEndpoint != ""
+}
+
+// azureChatCompletionsURL builds the Chat Completions endpoint of an Azure OpenAI deployment
+func azureChatCompletionsURL(endpoint, deployment, apiVersion string) string {
+	if apiVersion == "" {
+		apiVersion = config.DefaultAzureAPIVersion
+	}
+	return strings.TrimRight(endpoint, "/") + "/openai/deployments/" + url.PathEscape(deployment) +
+		"/chat/completions?api-version=" + url.QueryEscape(apiVersion)
+}
+
 // ValidateConfig validates the OpenAI provider configuration
 func (p *OpenAIProvider) ValidateConfig() error {
 	if p.apiKey == "" {
 		return fmt.Errorf("OpenAI API key is required")
 	}
+	if p.azure && p.config.AzureDeployment == "" {
+		return fmt.Errorf("azure_deployment is required with azure_endpoint")
+	}
+	// Gateways and Azure issue keys in their own formats
+	if !p.azure && openAIChatCompletionsURL(p.config.OpenAIBaseURL) == openAIChatCompletionsURL("") {
+		if err := validateOpenAIKeyFormat(p.apiKey); err != nil {
+			return err
+		}
+	}
 
 	// Skip API validation for now - we'll validate when actually making requests
 	// This prevents unnecessary API calls during initialization
@@ -103,8 +183,29 @@ func (p *OpenAIProvider) ValidateConfig() error {
 	return nil
 }
 
+// openAIMinKeyLength is the shortest plausible OpenAI API key, "sk-" included
+const openAIMinKeyLength = 20
+
+// validateOpenAIKeyFormat checks that key looks like an OpenAI API key, so that a mistyped or
+// truncated key is reported before the first request instead of as a 401. It makes no request.
+func validateOpenAIKeyFormat(key string) error {
+	hint := "set a valid key with: doc --set openai_api_key=sk-..."
+	switch {
+	case strings.TrimSpace(key) != key:
+		return fmt.Errorf("OpenAI API key has leading or trailing whitespace; %s", hint)
+	case !strings.HasPrefix(key, "sk-"):
+		return fmt.Errorf("OpenAI API key %s does not start with \"sk-\"; %s", maskAPIKey(key), hint)
+	case len(key) < openAIMinKeyLength:
+		return fmt.Errorf("OpenAI API key %s is too short (%d characters); %s", maskAPIKey(key), len(key), hint)
+	}
+	return nil
+}
+
 // GetProviderName returns the name of the provider
 func (p *OpenAIProvider) GetProviderName() string {
+	if p.azure {
+		return "Azure OpenAI"
+	}
 	return "OpenAI API"
 }
 
@@ -113,8 +214,96 @@ func (p *OpenAIProvider) GetSupportedLanguages() map[string]string {
 	return supportedLanguages
 }
 
+// BuildPrompt returns the system and user messages sent for content
+func (p *OpenAIProvider) BuildPrompt(content string, options TranslationOptions) string {
+	return formatChatPrompt(translationSystemPrompt(options), translationUserPrompt(options, content))
+}
+
 // Translate translates the given content using OpenAI API with function calling
 func (p *OpenAIProvider) Translate(ctx context.Context, content string, options TranslationOptions) (*TranslationResponse, error) {
+	req, err := p.buildRequest(content, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var response openAIResponse
+	if err := p.makeAPIRequest(ctx, req, &response); err != nil {
+		return nil, fmt.Errorf("OpenAI API request failed: %w", err)
+	}
+
+	if p.config.Verbose {
+		log("OpenAI API response received with %d choices", len(response.Choices))
+	}
+
+	// Parse the response
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no response choices received from OpenAI")
+	}
+
+	return p.translationResponse(req.Model, response.Choices[0].Message.Content, response.Usage)
+}
+
+// TranslateStream translates the given content using a streamed (SSE) Chat Completions response,
+// writing each piece of translated text to w as it arrives
+func (p *OpenAIProvider) TranslateStream(ctx context.Context, content string, options TranslationOptions, w io.Writer) (*TranslationResponse, error) {
+	req, err := p.buildRequest(content, options)
+	if err != nil {
+		return nil, err
+	}
+	req.Stream = true
+	req.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+
+	resp, err := p.openAPIRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var sb strings.Builder
+	var usage *openAIUsage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Blank separators, comments and other SSE fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var event openAIStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("failed to parse stream event: %w", err)
+		}
+		if event.Error != nil {
+			return nil, fmt.Errorf("OpenAI API stream error: %s", event.Error.Message)
+		}
+		if event.Usage != nil {
+			usage = event.Usage
+		}
+
+		for _, choice := range event.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			sb.WriteString(choice.Delta.Content)
+			if _, err := io.WriteString(w, choice.Delta.Content); err != nil {
+				return nil, fmt.Errorf("failed to write streamed output: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	return p.translationResponse(req.Model, sb.String(), usage)
+}
+
+// buildRequest creates the Chat Completions request for translating content
+func (p *OpenAIProvider) buildRequest(content string, options TranslationOptions) (openAIRequest, error) {
 	if p.config.Verbose {
 		log("Using OpenAI provider for translation")
 		log("Target language: %s", options.TargetLanguage)
@@ -127,8 +316,8 @@ func (p *OpenAIProvider) Translate(ctx context.Context, content string, options
 	// Function calling is not needed for this use case
 
 	// Create the system message and user prompt
-	systemPrompt := p.createSystemPrompt()
-	userPrompt := p.createUserPrompt(options.TargetLanguage, options.CustomInstruction, content)
+	systemPrompt := translationSystemPrompt(options)
+	userPrompt := translationUserPrompt(options, content)
 
 	// Get model from configuration
 	model := p.config.OpenAIModel
@@ -153,115 +342,190 @@ func (p *OpenAIProvider) Translate(ctx context.Context, content string, options
 				Content: userPrompt,
 			},
 		},
-		MaxTokens:   4000,
-		Temperature: 0.1,
+		MaxTokens:   outputTokenLimit(ProviderTypeOpenAI, model, systemPrompt+userPrompt, content, options.MaxTokens),
+		Temperature: translationTemperature(options),
 	}
 
-	var response openAIResponse
-	if err := p.makeAPIRequest(ctx, req, &response); err != nil {
-		return nil, fmt.Errorf("OpenAI API request failed: %w", err)
+	if p.config.Verbose {
+		log("Output token limit: %d", req.MaxTokens)
 	}
 
-	if p.config.Verbose {
-		log("OpenAI API response received with %d choices", len(response.Choices))
+	// Fail fast instead of wasting a round-trip on an oversized prompt
+	if err := checkPromptFits(ProviderTypeOpenAI, model, systemPrompt+userPrompt, req.MaxTokens); err != nil {
+		return openAIRequest{}, err
 	}
 
-	// Parse the response
-	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("no response choices received from OpenAI")
+	// Reasoning models reject max_tokens and only accept the default temperature of 1
+	if isOpenAIReasoningModel(model) {
+		if req.Temperature != 1 {
+			log("%s only supports temperature 1; ignoring %v", model, req.Temperature)
+		}
+		req.MaxCompletionTokens, req.MaxTokens = req.MaxTokens, 0
+		req.Temperature = 1
 	}
 
-	choice := response.Choices[0]
+	return req, nil
+}
+
+// isOpenAIReasoningModel reports whether model is an OpenAI o-series reasoning model, such as o1,
+// o3-mini or o4-mini
+func isOpenAIReasoningModel(model string) bool {
+	return len(model) >= 2 && model[0] == 'o' && model[1] >= '1' && model[1] <= '9'
+}
 
+// translationResponse wraps the translated content and token usage of a completed request
+func (p *OpenAIProvider) translationResponse(model, content string, usage *openAIUsage) (*TranslationResponse, error) {
 	// Use direct content response (no function calling)
-	if choice.Message.Content != "" {
-		if p.config.Verbose {
-			log("Received translation response of length: %d", len(choice.Message.Content))
-		}
+	if content == "" {
+		return nil, fmt.Errorf("no content received from OpenAI")
+	}
 
-		return &TranslationResponse{
-			Content: choice.Message.Content,
-			Status:  "success",
-			Message: "Translation completed successfully",
-		}, nil
+	if p.config.Verbose {
+		log("Received translation response of length: %d", len(content))
 	}
 
-	return nil, fmt.Errorf("no content received from OpenAI")
+	translation := &TranslationResponse{
+		Content: content,
+		Status:  "success",
+		Message: "Translation completed successfully",
+	}
+	if usage != nil {
+		translation.Usage = &TokenUsage{
+			Provider:     ProviderTypeOpenAI,
+			Model:        model,
+			InputTokens:  usage.PromptTokens,
+			OutputTokens: usage.CompletionTokens,
+		}
+	}
+
+	return translation, nil
 }
 
-// createSystemPrompt creates the system prompt for translation
-func (p *OpenAIProvider) createSystemPrompt() string {
-	return `You are a professional document translator. Your task is to translate documents while preserving their original format perfectly.
+// makeAPIRequest makes an HTTP request to the OpenAI API and decodes the response
+func (p *OpenAIProvider) makeAPIRequest(ctx context.Context, req openAIRequest, response interface{}) error {
+	resp, err := p.openAPIRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
 
-CRITICAL RULES:
-1. Preserve ALL original formatting (Markdown, HTML, plain text, etc.) EXACTLY
-2. Maintain ALL syntax, tags, symbols, and document structure
-3. Do NOT translate code blocks, URLs, or technical identifiers
-4. Do NOT change the document structure or format in any way
-5. Output ONLY the translated document - no explanations, prefixes, or additional text
-6. If the document is already in the target language, return it unchanged
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
 
-Respond with the translated document only.`
+	if response != nil {
+		if err := json.Unmarshal(body, response); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+
+	return nil
 }
 
-// createUserPrompt creates the user prompt for translation
-func (p *OpenAIProvider) createUserPrompt(targetLang, customInstruction, content string) string {
-	langName := supportedLanguages[targetLang]
+// openAPIRequest sends a request to the OpenAI API and returns the successful response for the
+// caller to read and close. Rate limits (429) and server errors (5xx) are retried with exponential
+// backoff, honoring Retry-After, up to config.OpenAIMaxRetries times; other errors fail fast.
+func (p *OpenAIProvider) openAPIRequest(ctx context.Context, req openAIRequest) (*http.Response, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
 
-	prompt := fmt.Sprintf(`Translate the following document to %s (%s).`, langName, targetLang)
+	for attempt := 0; ; attempt++ {
+		resp, retryAfter, err := p.doAPIRequest(ctx, jsonData)
+		if err == nil {
+			return resp, nil
+		}
 
-	if customInstruction != "" {
-		prompt += fmt.Sprintf("\n\nAdditional instruction: %s", customInstruction)
-	}
+		var statusErr *openAIStatusError
+		if !errors.As(err, &statusErr) || !statusErr.retryable() || attempt >= p.config.OpenAIMaxRetries {
+			return nil, err
+		}
 
-	prompt += fmt.Sprintf("\n\nDocument to translate:\n%s", content)
+		delay := retryDelay(attempt, retryAfter)
+		progress("%v; retrying in %s (retry %d/%d)", err, delay, attempt+1, p.config.OpenAIMaxRetries)
 
-	return prompt
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-// makeAPIRequest makes an HTTP request to the OpenAI API
-func (p *OpenAIProvider) makeAPIRequest(ctx context.Context, req openAIRequest, response interface{}) error {
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+// openAIStatusError is returned for non-200 responses from the OpenAI API
+type openAIStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *openAIStatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("OpenAI API error (%d): %s", e.StatusCode, e.Message)
 	}
+	return fmt.Sprintf("OpenAI API request failed with status %d", e.StatusCode)
+}
+
+// retryable reports whether the request may succeed if retried
+func (e *openAIStatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+// doAPIRequest performs a single API request and returns the successful response,
+// or an error and any Retry-After header
+func (p *OpenAIProvider) doAPIRequest(ctx context.Context, jsonData []byte) (*http.Response, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewReader(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if p.azure {
+		httpReq.Header.Set("api-key", p.apiKey)
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
 
 	if p.config.Verbose {
-		log("Making OpenAI API request...")
+		log("Making OpenAI API request to %s...", p.apiURL)
 	}
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("HTTP request failed: %w", err)
+		return nil, "", fmt.Errorf("HTTP request failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, "", nil
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		var apiError openAIResponse
-		if json.Unmarshal(body, &apiError) == nil && apiError.Error != nil {
-			return fmt.Errorf("OpenAI API error (%d): %s", resp.StatusCode, apiError.Error.Message)
-		}
-		return fmt.Errorf("OpenAI API request failed with status %d", resp.StatusCode)
+	statusErr := &openAIStatusError{StatusCode: resp.StatusCode}
+	var apiError openAIResponse
+	if json.Unmarshal(body, &apiError) == nil && apiError.Error != nil {
+		statusErr.Message = apiError.Error.Message
 	}
+	return nil, resp.Header.Get("Retry-After"), statusErr
+}
 
-	if response != nil {
-		if err := json.Unmarshal(body, response); err != nil {
-			return fmt.Errorf("failed to unmarshal response: %w", err)
+// retryDelay returns how long to wait before the given retry attempt (0-based).
+// A Retry-After header in seconds or as an HTTP date takes precedence over exponential backoff.
+func retryDelay(attempt int, retryAfter string) time.Duration {
+	delay := openAIRetryBaseDelay << attempt
+
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(retryAfter); err == nil {
+			delay = max(time.Until(date), 0)
 		}
 	}
 
-	return nil
+	return min(delay, openAIMaxRetryDelay)
 }
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestOpenAIProvider(t *testing.T, maxRetries int, handler http.HandlerFunc) *OpenAIProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	originalDelay := openAIRetryBaseDelay
+	openAIRetryBaseDelay = time.Millisecond
+	t.Cleanup(func() { openAIRetryBaseDelay = originalDelay })
+
+	provider, err := NewOpenAIProvider(ProviderConfig{
+		ProviderType:     ProviderTypeOpenAI,
+		OpenAIAPIKey:     "test-key",
+		OpenAIModel:      "gpt-4o-mini",
+		OpenAIBaseURL:    server.URL + "/v1/",
+		OpenAIMaxRetries: maxRetries,
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error: %v", err)
+	}
+	return provider
+}
+
+func TestOpenAIProviderRetriesRateLimits(t *testing.T) {
+	calls := 0
+	provider := newTestOpenAIProvider(t, 3, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Request path = %q, want /v1/chat/completions", r.URL.Path)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"Rate limit reached"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Bonjour"}}]}`))
+	})
+
+	response, err := provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "fr"})
+	if err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+	if response.Content != "Bonjour" {
+		t.Errorf("Content = %q, want %q", response.Content, "Bonjour")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 requests, got %d", calls)
+	}
+}
+
+func TestOpenAIProviderRetryLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		maxRetries int
+		wantCalls  int
+	}{
+		{"client errors fail fast", http.StatusBadRequest, 3, 1},
+		{"server errors stop after max retries", http.StatusBadGateway, 2, 3},
+		{"retries disabled", http.StatusServiceUnavailable, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			provider := newTestOpenAIProvider(t, tt.maxRetries, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"error":{"message":"request failed"}}`))
+			})
+
+			_, err := provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "fr"})
+			if err == nil || !strings.Contains(err.Error(), "request failed") {
+				t.Errorf("Translate() error = %v, want API error message", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d requests, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := retryDelay(2, ""); got != openAIRetryBaseDelay*4 {
+		t.Errorf("retryDelay(2, \"\") = %s, want %s", got, openAIRetryBaseDelay*4)
+	}
+	if got := retryDelay(0, "7"); got != 7*time.Second {
+		t.Errorf("retryDelay(0, \"7\") = %s, want 7s", got)
+	}
+	if got := retryDelay(0, "3600"); got != openAIMaxRetryDelay {
+		t.Errorf("retryDelay(0, \"3600\") = %s, want cap %s", got, openAIMaxRetryDelay)
+	}
+}
+
+func TestOpenAIChatCompletionsURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{"", "https://api.openai.com/v1/chat/completions"},
+		{"http://localhost:4000/v1", "http://localhost:4000/v1/chat/completions"},
+		{"http://localhost:4000/v1/", "http://localhost:4000/v1/chat/completions"},
+	}
+
+	for _, tt := range tests {
+		if got := openAIChatCompletionsURL(tt.baseURL); got != tt.expected {
+			t.Errorf("openAIChatCompletionsURL(%q) = %q, want %q", tt.baseURL, got, tt.expected)
+		}
+	}
+}
+
+func TestOpenAIProviderAzure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o/chat/completions" {
+			t.Errorf("Request path = %q, want the deployment's chat completions", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2024-10-21" {
+			t.Errorf("api-version = %q, want 2024-10-21", got)
+		}
+		if got := r.Header.Get("api-key"); got != "azure-key" {
+			t.Errorf("api-key header = %q, want azure-key", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Unexpected Authorization header %q", got)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Bonjour"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := ProviderConfig{
+		ProviderType:    ProviderTypeOpenAI,
+		OpenAIAPIKey:    "azure-key",
+		AzureEndpoint:   server.URL + "/",
+		AzureDeployment: "gpt-4o",
+		AzureAPIVersion: "2024-10-21",
+	}
+	provider, err := NewOpenAIProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error: %v", err)
+	}
+	if name := provider.GetProviderName(); name != "Azure OpenAI" {
+		t.Errorf("GetProviderName() = %q, want Azure OpenAI", name)
+	}
+
+	response, err := provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "fr"})
+	if err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+	if response.Content != "Bonjour" {
+		t.Errorf("Content = %q, want %q", response.Content, "Bonjour")
+	}
+
+	cfg.AzureDeployment = ""
+	if _, err := NewOpenAIProvider(cfg); err == nil {
+		t.Error("Expected an error for an Azure endpoint without a deployment")
+	}
+}
+
+func TestValidateOpenAIKeyFormat(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{"sk-proj-abcdefghijklmnopqrstuvwxyz", false},
+		{"sk-abcdefghijklmnopqrstu", false},
+		{"pk-abcdefghijklmnopqrstuvwxyz", true},
+		{"sk-short", true},
+		{"sk-abcdefghijklmnopqrstu\n", true},
+	}
+
+	for _, tt := range tests {
+		err := validateOpenAIKeyFormat(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateOpenAIKeyFormat(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "--set openai_api_key=") {
+			t.Errorf("Expected the error to explain how to set the key, got %v", err)
+		}
+	}
+
+	// Gateways accept their own key formats
+	if _, err := NewOpenAIProvider(ProviderConfig{OpenAIAPIKey: "gateway-key", OpenAIBaseURL: "http://localhost:4000/v1"}); err != nil {
+		t.Errorf("Unexpected error for a gateway key: %v", err)
+	}
+	if _, err := NewOpenAIProvider(ProviderConfig{OpenAIAPIKey: "gateway-key"}); err == nil {
+		t.Error("Expected an error for a malformed OpenAI key")
+	}
+}
+
+func TestOpenAIProviderTranslateStream(t *testing.T) {
+	provider := newTestOpenAIProvider(t, 0, func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("Expected a streaming request with usage, got %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": keep-alive\n\n" +
+			`data: {"choices":[{"delta":{"role":"assistant"}}]}` + "\n\n" +
+			`data: {"choices":[{"delta":{"content":"Bon"}}]}` + "\n\n" +
+			`data: {"choices":[{"delta":{"content":"jour"}}]}` + "\n\n" +
+			`data: {"choices":[],"usage":{"prompt_tokens":20,"completion_tokens":2}}` + "\n\n" +
+			"data: [DONE]\n\n"))
+	})
+
+	var out strings.Builder
+	response, err := provider.TranslateStream(context.Background(), "Hello", TranslationOptions{TargetLanguage: "fr"}, &out)
+	if err != nil {
+		t.Fatalf("TranslateStream() error: %v", err)
+	}
+	if out.String() != "Bonjour" || response.Content != "Bonjour" {
+		t.Errorf("Streamed %q, content %q, want %q", out.String(), response.Content, "Bonjour")
+	}
+	if response.Status != "success" {
+		t.Errorf("Status = %q, want success", response.Status)
+	}
+	if response.Usage == nil || response.Usage.InputTokens != 20 || response.Usage.OutputTokens != 2 {
+		t.Errorf("Usage = %+v, want 20 input and 2 output tokens", response.Usage)
+	}
+}
+
+func TestOpenAIProviderReasoningModel(t *testing.T) {
+	high := 1.5
+	provider := newTestOpenAIProvider(t, 0, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if _, ok := body["max_tokens"]; ok {
+			t.Errorf("Expected no max_tokens for a reasoning model, got %v", body["max_tokens"])
+		}
+		if body["max_completion_tokens"] != float64(defaultOutputTokenReserve) {
+			t.Errorf("max_completion_tokens = %v, want %d", body["max_completion_tokens"], defaultOutputTokenReserve)
+		}
+		if body["temperature"] != 1.0 {
+			t.Errorf("temperature = %v, want 1", body["temperature"])
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"こんにちは"}}]}`))
+	})
+	provider.config.OpenAIModel = "o3-mini"
+
+	if _, err := provider.Translate(context.Background(), "Hello", TranslationOptions{TargetLanguage: "ja", Temperature: &high}); err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+
+	for model, want := range map[string]bool{"o1": true, "o3-mini": true, "o4-mini": true, "gpt-4o": false, "gpt-4.1": false, "omni": false} {
+		if got := isOpenAIReasoningModel(model); got != want {
+			t.Errorf("isOpenAIReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestOpenAIProviderTemperature(t *testing.T) {
+	zero, high := 0.0, 1.5
+	tests := []struct {
+		name     string
+		options  TranslationOptions
+		expected float64
+	}{
+		{"Default", TranslationOptions{TargetLanguage: "ja"}, defaultTemperature},
+		{"Override", TranslationOptions{TargetLanguage: "ja", Temperature: &high}, 1.5},
+		{"Zero override", TranslationOptions{TargetLanguage: "ja", Temperature: &zero}, 0},
+		{"Strict repair", TranslationOptions{TargetLanguage: "ja", Temperature: &high, Strict: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestOpenAIProvider(t, 0, func(w http.ResponseWriter, r *http.Request) {
+				var req openAIRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("Failed to decode request: %v", err)
+				}
+				if req.Temperature != tt.expected {
+					t.Errorf("Temperature = %v, want %v", req.Temperature, tt.expected)
+				}
+				_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"こんにちは"}}]}`))
+			})
+
+			if _, err := provider.Translate(context.Background(), "Hello", tt.options); err != nil {
+				t.Fatalf("Translate() error: %v", err)
+			}
+		})
+	}
+}
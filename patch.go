@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeaderPattern matches unified diff hunk headers like "@@ -1,4 +1,5 @@ section"
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+// translatablePatchExtensions lists file extensions whose added lines are translated in diff mode
+var translatablePatchExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".mdown":    true,
+	".txt":      true,
+	".rst":      true,
+	".adoc":     true,
+}
+
+// patchHunk represents a parsed hunk header
+type patchHunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	section            string
+}
+
+// translatePatch translates the added lines of a unified diff.
+// Headers, context lines and removed lines are preserved exactly, and hunks
+// belonging to non-document files (code) are passed through untouched.
+func translatePatch(patch string, translate func(string) (string, error)) (string, error) {
+	lines := strings.Split(patch, "\n")
+	var out []string
+
+	translatable := false
+	shift := 0 // cumulative change in new-file line numbers for the current file
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(line, "+++ ") {
+			path, _, _ := strings.Cut(strings.TrimPrefix(line, "+++ "), "\t")
+			path = strings.TrimPrefix(strings.TrimSpace(path), "b/")
+			translatable = translatablePatchExtensions[strings.ToLower(filepath.Ext(path))]
+			shift = 0
+			out = append(out, line)
+			continue
+		}
+
+		match := hunkHeaderPattern.FindStringSubmatch(line)
+		if match == nil {
+			out = append(out, line)
+			continue
+		}
+
+		hunk := parseHunkHeader(match)
+		body, next := collectHunkBody(lines, i+1, hunk)
+		i = next - 1
+
+		delta := 0
+		if translatable {
+			translated, err := translateHunkBody(body, translate)
+			if err != nil {
+				return "", err
+			}
+			delta = countNewLines(translated) - countNewLines(body)
+			body = translated
+		}
+
+		// Only rewrite the header when line numbers actually moved
+		if shift != 0 || delta != 0 {
+			hunk.newStart += shift
+			hunk.newCount += delta
+			line = formatHunkHeader(hunk)
+		}
+		shift += delta
+
+		out = append(out, line)
+		out = append(out, body...)
+	}
+
+	return strings.Join(out, "\n"), nil
+}
+
+// parseHunkHeader converts a hunk header regexp match into a patchHunk
+func parseHunkHeader(match []string) patchHunk {
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	oldStart, _ := strconv.Atoi(match[1])
+	newStart, _ := strconv.Atoi(match[3])
+	return patchHunk{
+		oldStart: oldStart,
+		oldCount: count(match[2]),
+		newStart: newStart,
+		newCount: count(match[4]),
+		section:  match[5],
+	}
+}
+
+// formatHunkHeader formats a hunk header, always including explicit counts
+func formatHunkHeader(h patchHunk) string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@%s", h.oldStart, h.oldCount, h.newStart, h.newCount, h.section)
+}
+
+// collectHunkBody returns the body lines of a hunk starting at index start and the index after it
+func collectHunkBody(lines []string, start int, hunk patchHunk) ([]string, int) {
+	oldLeft, newLeft := hunk.oldCount, hunk.newCount
+	i := start
+	for ; i < len(lines) && (oldLeft > 0 || newLeft > 0); i++ {
+		switch {
+		case strings.HasPrefix(lines[i], "+"):
+			newLeft--
+		case strings.HasPrefix(lines[i], "-"):
+			oldLeft--
+		case strings.HasPrefix(lines[i], "\\"):
+			// "\ No newline at end of file" does not count toward either side
+		default:
+			oldLeft--
+			newLeft--
+		}
+	}
+
+	// Include a trailing "\ No newline" marker that belongs to the last line
+	if i < len(lines) && strings.HasPrefix(lines[i], "\\") {
+		i++
+	}
+
+	return lines[start:i], i
+}
+
+// translateHunkBody translates each contiguous run of added lines within a hunk body
+func translateHunkBody(body []string, translate func(string) (string, error)) ([]string, error) {
+	var out []string
+	for i := 0; i < len(body); {
+		if !strings.HasPrefix(body[i], "+") {
+			out = append(out, body[i])
+			i++
+			continue
+		}
+
+		// Collect the run of added lines
+		var run []string
+		for i < len(body) && strings.HasPrefix(body[i], "+") {
+			run = append(run, strings.TrimPrefix(body[i], "+"))
+			i++
+		}
+
+		text := strings.Join(run, "\n")
+		if strings.TrimSpace(text) == "" {
+			for _, line := range run {
+				out = append(out, "+"+line)
+			}
+			continue
+		}
+
+		translated, err := translate(text)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(strings.TrimRight(translated, "\n"), "\n") {
+			out = append(out, "+"+line)
+		}
+	}
+
+	return out, nil
+}
+
+// countNewLines counts lines in a hunk body that belong to the new file
+func countNewLines(body []string) int {
+	count := 0
+	for _, line := range body {
+		if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "\\") {
+			count++
+		}
+	}
+	return count
+}
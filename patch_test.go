@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranslatePatch(t *testing.T) {
+	patch := strings.Join([]string{
+		"diff --git a/README.md b/README.md",
+		"--- a/README.md",
+		"+++ b/README.md",
+		"@@ -1,3 +1,4 @@",
+		" # Title",
+		"-old line",
+		"+new line",
+		"+another line",
+		" context",
+		"@@ -10,2 +11,2 @@ Section",
+		" keep",
+		"-gone",
+		"+added",
+		"diff --git a/main.go b/main.go",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -1,1 +1,2 @@",
+		" package main",
+		"+// added comment",
+		"",
+	}, "\n")
+
+	// Translation that collapses each run into one upper-cased line
+	translate := func(text string) (string, error) {
+		return strings.ToUpper(strings.ReplaceAll(text, "\n", " ")), nil
+	}
+
+	expected := strings.Join([]string{
+		"diff --git a/README.md b/README.md",
+		"--- a/README.md",
+		"+++ b/README.md",
+		"@@ -1,3 +1,3 @@",
+		" # Title",
+		"-old line",
+		"+NEW LINE ANOTHER LINE",
+		" context",
+		"@@ -10,2 +10,2 @@ Section",
+		" keep",
+		"-gone",
+		"+ADDED",
+		"diff --git a/main.go b/main.go",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -1,1 +1,2 @@",
+		" package main",
+		"+// added comment",
+		"",
+	}, "\n")
+
+	result, err := translatePatch(patch, translate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != expected {
+		t.Errorf("translatePatch() =\n%s\nwant\n%s", result, expected)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// protectURLPattern matches bare and link URLs, stopping before the closing ) or > of Markdown syntax
+	protectURLPattern = regexp.MustCompile(`https?://[^\s)>\]]+`)
+	// placeholderPattern matches the placeholders inserted by protectSegments
+	placeholderPattern = regexp.MustCompile(`⟦(?:CODE|URL)_\d+⟧`)
+)
+
+// protectedSegment is a piece of the document replaced by a placeholder before translation
+type protectedSegment struct {
+	Placeholder string
+	Text        string
+}
+
+// protectSegments replaces fenced code blocks, inline code and URLs with placeholders such as
+// ⟦CODE_0⟧ so the model cannot alter them, and returns the segments needed to restore them
+func protectSegments(content string) (string, []protectedSegment) {
+	var segments []protectedSegment
+	codeCount, urlCount := 0, 0
+	protect := func(kind, text string) string {
+		n := &codeCount
+		if kind == "URL" {
+			n = &urlCount
+		}
+		placeholder := fmt.Sprintf("⟦%s_%d⟧", kind, *n)
+		*n++
+		segments = append(segments, protectedSegment{Placeholder: placeholder, Text: text})
+		return placeholder
+	}
+
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	var fence codeFenceTracker
+	for i := 0; i < len(lines); i++ {
+		if !fence.inCode(lines[i]) {
+			text := inlineCodePattern.ReplaceAllStringFunc(lines[i], func(code string) string {
+				return protect("CODE", code)
+			})
+			out = append(out, protectURLPattern.ReplaceAllStringFunc(text, func(url string) string {
+				return protect("URL", url)
+			}))
+			continue
+		}
+
+		// Replace the whole block, keeping the opening fence's indentation outside the placeholder
+		block := []string{lines[i]}
+		for i+1 < len(lines) && fence.marker != "" {
+			i++
+			fence.inCode(lines[i])
+			block = append(block, lines[i])
+		}
+		indent := block[0][:len(block[0])-len(strings.TrimLeft(block[0], " \t"))]
+		out = append(out, indent+protect("CODE", strings.TrimPrefix(strings.Join(block, "\n"), indent)))
+	}
+
+	return strings.Join(out, "\n"), segments
+}
+
+// restoreSegments puts the protected segments back in place of their placeholders and returns the
+// number of placeholders missing from the translation
+func restoreSegments(translation string, segments []protectedSegment) (string, int) {
+	missing := 0
+	pairs := make([]string, 0, 2*len(segments))
+	for _, segment := range segments {
+		if !strings.Contains(translation, segment.Placeholder) {
+			missing++
+		}
+		pairs = append(pairs, segment.Placeholder, segment.Text)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(translation), missing
+}
+
+// placeholderInstruction tells the model to keep placeholders, or returns "" if content has none
+func placeholderInstruction(content string) string {
+	if !placeholderPattern.MatchString(content) {
+		return ""
+	}
+	return "Placeholders such as ⟦CODE_0⟧ and ⟦URL_0⟧ stand for code and URLs. Copy every placeholder exactly as written; do not translate, change or remove them."
+}
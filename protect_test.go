@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProtectSegmentsRoundTrip(t *testing.T) {
+	content := strings.Join([]string{
+		"# Install",
+		"",
+		"Run `go install ./...` and see https://example.com/docs.",
+		"",
+		"- Steps:",
+		"  ```bash",
+		"  echo \"do not translate\"",
+		"  ```",
+		"",
+		"~~~",
+		"``` not a closing fence",
+		"~~~",
+		"",
+		"[Guide](https://example.com/guide?lang=en)",
+	}, "\n")
+
+	protected, segments := protectSegments(content)
+	if len(segments) != 5 {
+		t.Fatalf("protectSegments() returned %d segments, want 5: %v", len(segments), segments)
+	}
+	for _, leaked := range []string{"go install", "echo", "example.com", "not a closing fence"} {
+		if strings.Contains(protected, leaked) {
+			t.Errorf("Protected text still contains %q:\n%s", leaked, protected)
+		}
+	}
+	if !strings.Contains(protected, "  ⟦CODE_1⟧") {
+		t.Errorf("Expected the indented code block to keep its indentation:\n%s", protected)
+	}
+
+	// Simulate a translation that only changes the prose around the placeholders
+	translated := strings.ReplaceAll(strings.ReplaceAll(protected, "Install", "インストール"), "Steps", "手順")
+	restored, missing := restoreSegments(translated, segments)
+	if missing != 0 {
+		t.Errorf("restoreSegments() reported %d missing placeholders, want 0", missing)
+	}
+	expected := strings.ReplaceAll(strings.ReplaceAll(content, "Install", "インストール"), "Steps", "手順")
+	if restored != expected {
+		t.Errorf("restoreSegments() = %q, want %q", restored, expected)
+	}
+}
+
+func TestRestoreSegmentsMissing(t *testing.T) {
+	_, segments := protectSegments("Use `a` and `b`")
+	restored, missing := restoreSegments("⟦CODE_1⟧ を使う", segments)
+	if missing != 1 {
+		t.Errorf("restoreSegments() reported %d missing placeholders, want 1", missing)
+	}
+	if restored != "`b` を使う" {
+		t.Errorf("restoreSegments() = %q, want %q", restored, "`b` を使う")
+	}
+}
+
+func TestPlaceholderInstruction(t *testing.T) {
+	if placeholderInstruction("No code here") != "" {
+		t.Error("Expected no instruction for content without placeholders")
+	}
+	if !strings.Contains(translationUserPrompt(TranslationOptions{TargetLanguage: "ja"}, "Run ⟦CODE_0⟧"), "⟦CODE_0⟧ and ⟦URL_0⟧") {
+		t.Error("Expected the prompt to explain placeholders")
+	}
+}
@@ -3,6 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/bigdra50/doc/internal/config"
 )
@@ -13,14 +17,36 @@ type TranslationResponse struct {
 	Status    string `json:"status"`
 	Message   string `json:"message"`
 	ErrorCode string `json:"error_code,omitempty"`
+
+	// Usage reports the tokens consumed by the request, when known
+	Usage *TokenUsage `json:"-"`
+}
+
+// TokenUsage holds the token counts of a single translation request
+type TokenUsage struct {
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Estimated    bool // Counts estimated from character length because the provider reports none
 }
 
 // TranslationOptions holds configuration for translation operations
 type TranslationOptions struct {
 	TargetLanguage    string
 	CustomInstruction string
+	Transform         bool // Apply CustomInstruction in the document's own language instead of translating
 	PreserveFormat    bool
 	Verbose           bool
+	Strict            bool              // Stricter, lower-temperature retry after a structure validation failure
+	Cache             *translationCache // Reuse translations of unchanged content (nil = no caching)
+	GlossaryFile      string
+	Glossary          []GlossaryEntry // Parsed from GlossaryFile
+	Protect           bool            // Replace code and URLs with placeholders while translating
+	Temperature       *float64        // Sampling temperature override (nil = defaultTemperature)
+	MaxTokens         int             // Output token limit override (0 = size to the content)
+	DebugDumpDir      string          // Directory the claude-code provider saves prompts and outputs to
+	Progress          *chunkProgress  // Position in a chunked translation (nil = single request)
 }
 
 // LLMProvider defines the interface for different LLM providers
@@ -36,6 +62,18 @@ type LLMProvider interface {
 
 	// GetSupportedLanguages returns the list of supported language codes
 	GetSupportedLanguages() map[string]string
+
+	// BuildPrompt returns the prompt Translate sends for content, as shown by --print-prompt
+	BuildPrompt(content string, options TranslationOptions) string
+}
+
+// StreamingProvider is implemented by providers that can deliver the translation incrementally
+type StreamingProvider interface {
+	LLMProvider
+
+	// TranslateStream translates content, writing text to w as it arrives.
+	// The returned response holds the complete translation.
+	TranslateStream(ctx context.Context, content string, options TranslationOptions, w io.Writer) (*TranslationResponse, error)
 }
 
 // Use config package types
@@ -46,8 +84,12 @@ const (
 	ProviderTypeClaude    = config.ProviderTypeClaude
 	ProviderTypeOpenAI    = config.ProviderTypeOpenAI
 	ProviderTypeAnthropic = config.ProviderTypeAnthropic
+	ProviderTypeOllama    = config.ProviderTypeOllama
 )
 
+// providerTypes lists all known provider types in display order
+var providerTypes = config.ProviderTypes
+
 // NewLLMProvider creates a new LLM provider based on configuration
 func NewLLMProvider(config ProviderConfig) (LLMProvider, error) {
 	switch config.ProviderType {
@@ -57,14 +99,199 @@ func NewLLMProvider(config ProviderConfig) (LLMProvider, error) {
 		return NewOpenAIProvider(config)
 	case ProviderTypeAnthropic:
 		return NewAnthropicProvider(config)
+	case ProviderTypeOllama:
+		return NewOllamaProvider(config)
+	default:
+		return nil, fmt.Errorf("unsupported provider type: %s", config.ProviderType)
+	}
+}
+
+// newPromptProvider returns a provider of the configured type that is only used to build prompts.
+// It is not validated, so --print-prompt works without credentials or the claude command.
+func newPromptProvider(config ProviderConfig) (LLMProvider, error) {
+	switch config.ProviderType {
+	case ProviderTypeClaude:
+		return &ClaudeCodeProvider{config: config}, nil
+	case ProviderTypeOpenAI:
+		return &OpenAIProvider{config: config}, nil
+	case ProviderTypeAnthropic:
+		return &AnthropicProvider{config: config}, nil
+	case ProviderTypeOllama:
+		return &OllamaProvider{config: config}, nil
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", config.ProviderType)
 	}
 }
 
+// configuredModel returns the model configured for the given provider type
+func configuredModel(config ProviderConfig, providerType string) string {
+	switch providerType {
+	case ProviderTypeClaude:
+		return config.ClaudeModel
+	case ProviderTypeOpenAI:
+		return config.OpenAIModel
+	case ProviderTypeAnthropic:
+		return config.AnthropicModel
+	case ProviderTypeOllama:
+		return config.OllamaModel
+	default:
+		return ""
+	}
+}
+
+// setConfiguredModel sets the model used for the given provider type
+func setConfiguredModel(config *ProviderConfig, providerType, model string) {
+	switch providerType {
+	case ProviderTypeClaude:
+		config.ClaudeModel = model
+	case ProviderTypeOpenAI:
+		config.OpenAIModel = model
+	case ProviderTypeAnthropic:
+		config.AnthropicModel = model
+	case ProviderTypeOllama:
+		config.OllamaModel = model
+	}
+}
+
+// validateModel checks that model is available for the given provider type
+func validateModel(cfg ProviderConfig, providerType, model string) error {
+	var valid []string
+	switch providerType {
+	case ProviderTypeClaude:
+		if _, ok := claudeCodePricingModels[model]; ok {
+			return nil
+		}
+		for alias := range claudeCodePricingModels {
+			valid = append(valid, alias)
+		}
+		sort.Strings(valid)
+	case ProviderTypeOpenAI, ProviderTypeAnthropic:
+		// OpenAI-compatible gateways and Azure deployments serve their own models
+		if providerType == ProviderTypeOpenAI && (cfg.OpenAIBaseURL != config.DefaultOpenAIBaseURL || usesAzureOpenAI(cfg)) {
+			return nil
+		}
+		if FindModel(providerType, model) != nil {
+			return nil
+		}
+		for _, m := range GetModelsByProvider(providerType) {
+			valid = append(valid, m.ID)
+		}
+	default:
+		// Ollama serves whatever models have been pulled locally
+		return nil
+	}
+
+	return fmt.Errorf("unknown %s model '%s'. Valid models: %s", providerType, model, strings.Join(valid, ", "))
+}
+
+// httpTimeout returns the configured timeout for HTTP API requests
+func httpTimeout(cfg ProviderConfig) time.Duration {
+	seconds := cfg.HTTPTimeoutSeconds
+	if seconds <= 0 {
+		seconds = config.DefaultHTTPTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// defaultTemperature is the sampling temperature of the API providers, low for faithful translations
+const defaultTemperature = 0.1
+
+// maxTemperature is the highest temperature accepted by --temperature
+const maxTemperature = 2.0
+
+// translationTemperature returns the sampling temperature for a translation request
+func translationTemperature(options TranslationOptions) float64 {
+	// Repair attempts trade variation for determinism
+	if options.Strict {
+		return 0
+	}
+	if options.Temperature != nil {
+		return *options.Temperature
+	}
+	return defaultTemperature
+}
+
+// validateTemperature checks that a provider supports temperature; Anthropic caps it at 1.0
+func validateTemperature(providerType string, temperature float64) error {
+	switch providerType {
+	case ProviderTypeClaude:
+		return fmt.Errorf("--temperature is not supported by the %s provider", providerType)
+	case ProviderTypeAnthropic:
+		if temperature > 1 {
+			return fmt.Errorf("the %s provider accepts temperatures from 0.0 to 1.0, got %g", providerType, temperature)
+		}
+	}
+	return nil
+}
+
+// translationSystemPrompt returns the system prompt shared by the HTTP API providers
+func translationSystemPrompt(options TranslationOptions) string {
+	if options.Transform {
+		return `You are a professional document editor. Your task is to rewrite documents as instructed while preserving their original format perfectly.
+
+CRITICAL RULES:
+1. Preserve ALL original formatting (Markdown, HTML, plain text, etc.) EXACTLY
+2. Maintain ALL syntax, tags, symbols, and document structure unless the instruction requires otherwise
+3. Do NOT change code blocks, URLs, or technical identifiers
+4. Keep the document in its original language; do NOT translate it
+5. Output ONLY the rewritten document - no explanations, prefixes, or additional text
+
+Respond with the rewritten document only.`
+	}
+
+	return `You are a professional document translator. Your task is to translate documents while preserving their original format perfectly.
+
+CRITICAL RULES:
+1. Preserve ALL original formatting (Markdown, HTML, plain text, etc.) EXACTLY
+2. Maintain ALL syntax, tags, symbols, and document structure
+3. Do NOT translate code blocks, URLs, or technical identifiers
+4. Do NOT change the document structure or format in any way
+5. Output ONLY the translated document - no explanations, prefixes, or additional text
+6. If the document is already in the target language, return it unchanged
+
+Respond with the translated document only.`
+}
+
+// translationUserPrompt builds the user prompt shared by the HTTP API providers
+func translationUserPrompt(options TranslationOptions, content string) string {
+	var prompt string
+	if options.Transform {
+		prompt = fmt.Sprintf("Apply the following instruction to the document, keeping its original language.\n\nInstruction: %s", options.CustomInstruction)
+	} else {
+		langName := languageName(options.TargetLanguage)
+		prompt = fmt.Sprintf(`Translate the following document to %s (%s).`, langName, options.TargetLanguage)
+		if options.CustomInstruction != "" {
+			prompt += fmt.Sprintf("\n\nAdditional instruction: %s", options.CustomInstruction)
+		}
+		if glossary := glossaryInstruction(options.Glossary); glossary != "" {
+			prompt += "\n\n" + glossary
+		}
+	}
+
+	if placeholders := placeholderInstruction(content); placeholders != "" {
+		prompt += "\n\n" + placeholders
+	}
+
+	if options.Transform {
+		prompt += fmt.Sprintf("\n\nDocument:\n%s", content)
+	} else {
+		prompt += fmt.Sprintf("\n\nDocument to translate:\n%s", content)
+	}
+
+	return prompt
+}
+
+// formatChatPrompt shows the system and user messages of an API request as one prompt
+func formatChatPrompt(systemPrompt, userPrompt string) string {
+	return "[system]\n" + systemPrompt + "\n\n[user]\n" + userPrompt
+}
+
 // LoadConfig loads provider configuration from config file and environment variables
 func LoadConfig() ProviderConfig {
-	return config.Load()
+	cfg := config.Load()
+	warnUnknownModels(cfg)
+	registerCustomLanguages(cfg.CustomLanguages)
+	return cfg
 }
 
 // LoadConfigFromEnv loads provider configuration from environment variables and .env file (deprecated)
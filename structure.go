@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// listItemPattern matches markdown bullet and ordered list items
+var listItemPattern = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+
+// linkPattern matches markdown links and images
+var linkPattern = regexp.MustCompile(`\]\([^)]*\)`)
+
+// documentStructure counts the structural elements that a translation must preserve
+type documentStructure struct {
+	CodeFences int
+	Links      int
+	ListItems  int
+	Headers    int
+}
+
+// analyzeStructure counts code fences, links, list items and headers in markdown content.
+// Lines inside fenced code blocks only count toward the fences themselves.
+func analyzeStructure(content string) documentStructure {
+	var s documentStructure
+	inCodeBlock := false
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			s.CodeFences++
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			s.Headers++
+		}
+		if listItemPattern.MatchString(line) {
+			s.ListItems++
+		}
+		s.Links += len(linkPattern.FindAllString(line, -1))
+	}
+
+	return s
+}
+
+// validateStructure compares the structure of a translation against its source
+// and returns a description of every mismatch
+func validateStructure(source, translated string) []string {
+	expected := analyzeStructure(source)
+	actual := analyzeStructure(translated)
+
+	var issues []string
+	check := func(name string, want, got int) {
+		if want != got {
+			issues = append(issues, fmt.Sprintf("%s: expected %d, got %d", name, want, got))
+		}
+	}
+
+	check("code fences", expected.CodeFences, actual.CodeFences)
+	check("links", expected.Links, actual.Links)
+	check("list items", expected.ListItems, actual.ListItems)
+	check("headers", expected.Headers, actual.Headers)
+
+	return issues
+}
+
+// repairInstruction builds an explicit instruction describing the structure the output must keep
+func repairInstruction(source string) string {
+	s := analyzeStructure(source)
+	return fmt.Sprintf("STRICT: A previous attempt corrupted the document structure. "+
+		"The output MUST contain exactly %d code fence lines, %d links, %d list items and %d headers, "+
+		"matching the original line for line. Copy code blocks and link targets verbatim.",
+		s.CodeFences, s.Links, s.ListItems, s.Headers)
+}
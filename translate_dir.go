@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// translateDirResult records the outcome of translating one file
+type translateDirResult struct {
+	Source string
+	Output string
+	Err    error
+}
+
+// runTranslateDir translates every markdown file in a directory into a sibling <name>.<lang>.md file,
+// or into the same relative path under the directory rendered from --out-dir-template
+func runTranslateDir(cliArgs *CLIArgs) error {
+	config := LoadConfig()
+	config.Verbose = verbose
+
+	if err := applyProviderOverrides(cliArgs, &config); err != nil {
+		return err
+	}
+
+	provider, err := NewLLMProvider(config)
+	if err != nil {
+		showProviderHelp(config.ProviderType)
+		return withExitCode(exitConfig, fmt.Errorf("failed to initialize %s provider: %w", config.ProviderType, err))
+	}
+
+	log("Using provider: %s", provider.GetProviderName())
+
+	cliArgs.TargetLanguage, err = validateLanguage(cliArgs.TargetLanguage, provider)
+	if err != nil {
+		return err
+	}
+
+	scanner := &FileScanner{
+		Directory:       cliArgs.TranslateDirDirectory,
+		Recursive:       cliArgs.TranslateDirRecursive,
+		ExcludePatterns: cliArgs.TranslateDirExcludePatterns,
+	}
+
+	outDir := ""
+	if cliArgs.TranslateDirOutDirTemplate != "" {
+		tmpl, err := parseOutDirTemplate(cliArgs.TranslateDirOutDirTemplate)
+		if err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		if outDir, err = renderOutDir(tmpl, cliArgs.TargetLanguage); err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		log("Output directory: %s", outDir)
+	}
+
+	log("Scanning directory: %s", cliArgs.TranslateDirDirectory)
+	files, err := scanner.ScanMarkdownFiles()
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	// Earlier outputs such as guide.ja.md, or anything under the output directory, are
+	// translations, not sources
+	var sources []MarkdownFile
+	for _, file := range SortMarkdownFiles(files, "filename", false) {
+		if isTranslatedFile(file.Path) || (outDir != "" && isWithinDir(file.Path, outDir)) {
+			log("Skipping translated file: %s", file.Path)
+			continue
+		}
+		sources = append(sources, file)
+	}
+
+	if len(sources) == 0 {
+		return fmt.Errorf("no markdown files found in directory: %s", cliArgs.TranslateDirDirectory)
+	}
+
+	concurrency := max(cliArgs.TranslateDirConcurrency, 1)
+	progress("Translating %d file(s) to %s with %d worker(s)", len(sources), cliArgs.TargetLanguage, concurrency)
+
+	// Parallel spinners would overwrite each other's line
+	if concurrency > 1 {
+		spinnerDisabled = true
+	}
+
+	options, err := newTranslationOptions(cliArgs, config)
+	if err != nil {
+		return err
+	}
+	maxChunkTokens := chunkTokenBudget(cliArgs, config)
+	typography := config.Typography[cliArgs.TargetLanguage]
+
+	outputs := make([]string, len(sources))
+	for i, source := range sources {
+		outputs[i] = translateDirOutput(cliArgs.TranslateDirDirectory, source.Path, outDir, cliArgs.TargetLanguage)
+		if err := createOutputDir(filepath.Dir(outputs[i])); err != nil {
+			return err
+		}
+	}
+
+	results := make([]translateDirResult, len(sources))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				source, output := sources[i].Path, outputs[i]
+				progress("Translating %s...", source)
+
+				err := translateFile(provider, source, output, options, maxChunkTokens, typography, cliArgs.Force)
+				results[i] = translateDirResult{Source: source, Output: output, Err: err}
+			}
+		}()
+	}
+
+	for i := range sources {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return summarizeTranslateDir(results)
+}
+
+// translateFile translates the markdown file at source and writes the result to output
+func translateFile(provider LLMProvider, source, output string, options TranslationOptions, maxChunkTokens int, typography, force bool) error {
+	if err := checkOutputFile(output, force); err != nil {
+		return err
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	content, err := readDocumentFrom(file, source)
+	if err != nil {
+		return err
+	}
+	content = normalizeLineEndings(content)
+
+	result, err := translateChunked(provider, content, options, 0, maxChunkTokens)
+	if err != nil {
+		return err
+	}
+
+	if typography {
+		result = applyTypography(options.TargetLanguage, result)
+	}
+
+	return writeTranslationOutput(output, result)
+}
+
+// summarizeTranslateDir prints which files succeeded and failed, returning an error if any failed
+func summarizeTranslateDir(results []translateDirResult) error {
+	var failed []translateDirResult
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "\nTranslated %d of %d file(s)\n", len(results)-len(failed), len(results))
+		for _, result := range results {
+			if result.Err == nil {
+				fmt.Fprintf(os.Stderr, "  ✓ %s -> %s\n", result.Source, result.Output)
+			}
+		}
+	}
+	for _, result := range failed {
+		fmt.Fprintf(os.Stderr, "  ✗ %s: %v\n", result.Source, result.Err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d file(s) failed to translate", len(failed), len(results))
+	}
+	return nil
+}
+
+// translatedFilePath returns the path of the translation of source, e.g. docs/guide.md -> docs/guide.ja.md
+func translatedFilePath(source, lang string) string {
+	ext := filepath.Ext(source)
+	return strings.TrimSuffix(source, ext) + "." + lang + ext
+}
+
+// outDirTemplateData is the data available to --out-dir-template
+type outDirTemplateData struct {
+	Lang string // Target language code, e.g. ja
+}
+
+// parseOutDirTemplate parses an --out-dir-template and checks that it renders a directory
+func parseOutDirTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("out-dir").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --out-dir-template: %w", err)
+	}
+	if _, err := renderOutDir(tmpl, "ja"); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderOutDir returns the output directory of the template for lang
+func renderOutDir(tmpl *template.Template, lang string) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, outDirTemplateData{Lang: lang}); err != nil {
+		return "", fmt.Errorf("invalid --out-dir-template: %w", err)
+	}
+	dir := strings.TrimSpace(sb.String())
+	if dir == "" {
+		return "", fmt.Errorf("invalid --out-dir-template: it renders an empty directory for %s", lang)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// translateDirOutput returns where the translation of source is written: next to it as
+// <name>.<lang>.md, or at its path relative to dir under outDir if one is set
+func translateDirOutput(dir, source, outDir, lang string) string {
+	if outDir == "" {
+		return translatedFilePath(source, lang)
+	}
+	rel, err := filepath.Rel(dir, source)
+	if err != nil {
+		rel = filepath.Base(source)
+	}
+	return filepath.Join(outDir, rel)
+}
+
+// createOutputDir creates dir and its parents if needed, logging the directories it creates
+func createOutputDir(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	log("Created directory: %s", dir)
+	return nil
+}
+
+// isWithinDir reports whether path is inside dir
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(absPath(dir), absPath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isTranslatedFile reports whether path looks like a translation written by translate-dir,
+// i.e. its name ends in .<language code> before the extension
+func isTranslatedFile(path string) bool {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	code := strings.TrimPrefix(filepath.Ext(stem), ".")
+	return code != "" && validateLanguageCode(code) == nil
+}
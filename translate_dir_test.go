@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranslatedFilePath(t *testing.T) {
+	tests := []struct {
+		source   string
+		lang     string
+		expected string
+	}{
+		{"docs/guide.md", "ja", "docs/guide.ja.md"},
+		{"README.markdown", "fr", "README.fr.markdown"},
+		{"v1.2/notes.md", "zh-CN", "v1.2/notes.zh-CN.md"},
+	}
+
+	for _, tt := range tests {
+		if got := translatedFilePath(tt.source, tt.lang); got != tt.expected {
+			t.Errorf("translatedFilePath(%q, %q) = %q, want %q", tt.source, tt.lang, got, tt.expected)
+		}
+	}
+}
+
+func TestOutDirTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{"i18n/{{.Lang}}", filepath.Join("i18n", "fr"), false},
+		{"site/{{.Lang}}/docs/", filepath.Join("site", "fr", "docs"), false},
+		{"translations", "translations", false},
+		{"i18n/{{.Lang", "", true},
+		{"i18n/{{.Language}}", "", true},
+		{"{{if false}}{{.Lang}}{{end}}", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			tmpl, err := parseOutDirTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOutDirTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			dir, err := renderOutDir(tmpl, "fr")
+			if err != nil || dir != tt.expected {
+				t.Errorf("renderOutDir(%q, fr) = %q, %v; want %q", tt.template, dir, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranslateDirOutput(t *testing.T) {
+	tests := []struct {
+		source   string
+		outDir   string
+		expected string
+	}{
+		{"docs/guide.md", "", "docs/guide.ja.md"},
+		{"docs/guide.md", "i18n/ja", "i18n/ja/guide.md"},
+		{"docs/api/auth.md", "i18n/ja", "i18n/ja/api/auth.md"},
+	}
+
+	for _, tt := range tests {
+		output := translateDirOutput("docs", filepath.FromSlash(tt.source), filepath.FromSlash(tt.outDir), "ja")
+		if output != filepath.FromSlash(tt.expected) {
+			t.Errorf("translateDirOutput(%q, %q) = %q, want %q", tt.source, tt.outDir, output, tt.expected)
+		}
+	}
+}
+
+func TestIsWithinDir(t *testing.T) {
+	tests := []struct {
+		path     string
+		dir      string
+		expected bool
+	}{
+		{"docs/i18n/ja/guide.md", "docs/i18n/ja", true},
+		{"docs/guide.md", "docs/i18n/ja", false},
+		{"docs/i18n/jazz.md", "docs/i18n/ja", false},
+		{"i18n/ja/guide.md", "./i18n/ja", true},
+	}
+
+	for _, tt := range tests {
+		if got := isWithinDir(filepath.FromSlash(tt.path), filepath.FromSlash(tt.dir)); got != tt.expected {
+			t.Errorf("isWithinDir(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.expected)
+		}
+	}
+}
+
+func TestCreateOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "i18n", "ja", "api")
+	if err := createOutputDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("Expected %s to be created, got %v", dir, err)
+	}
+	// An existing directory is fine
+	if err := createOutputDir(dir); err != nil {
+		t.Errorf("createOutputDir() on an existing directory error: %v", err)
+	}
+}
+
+func TestIsTranslatedFile(t *testing.T) {
+	tests := map[string]bool{
+		"docs/guide.md":    false,
+		"docs/guide.ja.md": true,
+		"README.fr.md":     true,
+		"guide.pt-BR.md":   true,
+		"release-1.2.md":   false,
+		"setup.config.md":  false,
+	}
+
+	for path, expected := range tests {
+		if got := isTranslatedFile(path); got != expected {
+			t.Errorf("isTranslatedFile(%q) = %v, want %v", path, got, expected)
+		}
+	}
+}
+
+func TestTranslateFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "guide.md")
+	if err := os.WriteFile(source, []byte("# Guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := translatedFilePath(source, "ja")
+
+	originalStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = originalStderr }()
+
+	provider := &fakeProvider{responses: []string{"# ガイド"}}
+	options := TranslationOptions{TargetLanguage: "ja"}
+
+	if err := translateFile(provider, source, output, options, 1000, false, false); err != nil {
+		t.Fatalf("translateFile() error: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# ガイド" {
+		t.Errorf("Output = %q, want %q", data, "# ガイド")
+	}
+
+	// An existing translation is not overwritten without force
+	err = translateFile(provider, source, output, options, 1000, false, false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected an already-exists error, got %v", err)
+	}
+	if err := translateFile(provider, source, output, options, 1000, false, true); err != nil {
+		t.Errorf("translateFile() with force error: %v", err)
+	}
+}
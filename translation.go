@@ -1,71 +1,467 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"unicode/utf8"
 )
 
-// readDocument reads the document from stdin with validation
-func readDocument() (string, error) {
+// documentSeparator joins input files read with --files
+const documentSeparator = "\n\n---\n\n"
+
+// readTranslationInput reads the document to translate: the --files inputs joined in argument
+// order, or a single document from --file or stdin
+func readTranslationInput(cliArgs *CLIArgs) (string, error) {
+	content, err := readTranslationDocuments(cliArgs)
+	if err != nil || cliArgs.KeepCRLF {
+		return content, err
+	}
+	return normalizeLineEndings(content), nil
+}
+
+// readTranslationDocuments reads the documents named by cliArgs as they are, joining --files
+func readTranslationDocuments(cliArgs *CLIArgs) (string, error) {
+	if len(cliArgs.InputFiles) == 0 {
+		return readDocument(cliArgs.InputFile)
+	}
+
+	if isStdinPiped() {
+		fmt.Fprintf(os.Stderr, "Warning: reading from %s; piped stdin is ignored\n", strings.Join(cliArgs.InputFiles, ", "))
+	}
+
+	parts := make([]string, 0, len(cliArgs.InputFiles))
+	for _, path := range cliArgs.InputFiles {
+		content, err := readDocumentFile(path)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, content)
+	}
+	log("Joined %d input files", len(parts))
+
+	return strings.Join(parts, documentSeparator), nil
+}
+
+// readDocument reads the document from inputFile if set, otherwise from stdin.
+// An explicit input file always wins over piped stdin.
+func readDocument(inputFile string) (string, error) {
+	if inputFile != "" {
+		if isStdinPiped() {
+			fmt.Fprintf(os.Stderr, "Warning: reading from %s; piped stdin is ignored\n", inputFile)
+		}
+		return readDocumentFile(inputFile)
+	}
+
 	log("Checking if stdin is available...")
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) != 0 {
-		return "", fmt.Errorf("no document provided via stdin")
+	if !isStdinPiped() {
+		return "", withExitCode(exitEmptyInput, fmt.Errorf("no document provided via stdin"))
 	}
 	log("Stdin is available")
 
 	progress("Reading document...")
 	log("Reading from stdin...")
 
-	var lines []string
-	scanner := bufio.NewScanner(os.Stdin)
+	return readDocumentFrom(os.Stdin, "stdin")
+}
 
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// readDocumentFile reads the document from a file
+func readDocumentFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("input file not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to access input file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("input file is a directory: %s", path)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("failed to read from stdin: %w", err)
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	progress("Reading document...")
+	log("Reading from file: %s", path)
+
+	return readDocumentFrom(file, path)
+}
+
+// readDocumentFrom reads the whole document from r and validates it is not empty.
+// Lines of any length are supported; the final newline is dropped. Line endings are kept as read,
+// so callers normalize them with normalizeLineEndings unless --keep-crlf is set.
+func readDocumentFrom(r io.Reader, source string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from %s: %w", source, err)
 	}
 
-	content := strings.Join(lines, "\n")
-	log("Read %d characters from stdin", len(content))
+	content := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	log("Read %d characters from %s", len(content), source)
 
 	if strings.TrimSpace(content) == "" {
-		return "", fmt.Errorf("empty document provided")
+		return "", withExitCode(exitEmptyInput, fmt.Errorf("empty document provided"))
 	}
 
 	return content, nil
 }
 
+// normalizeLineEndings converts CRLF line endings to LF, so that no stray \r characters reach
+// the prompt, the translation or a merged document
+func normalizeLineEndings(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
+// isStdinPiped reports whether stdin is connected to a pipe or file rather than a terminal
+func isStdinPiped() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) == 0
+}
+
+// checkOutputFile returns an error if the output file already exists and force is not set
+func checkOutputFile(path string, force bool) error {
+	if path == "" || force {
+		return nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("output file already exists: %s (use --force to overwrite)", path)
+	}
+
+	return nil
+}
+
+// writeTranslationOutput writes the translated content to path, or to stdout if path is empty
+func writeTranslationOutput(path, content string) error {
+	if path == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %d bytes to %s\n", len(content), path)
+	return nil
+}
+
+// jsonTranslationResult is the result of a translation as printed by --json
+type jsonTranslationResult struct {
+	TranslationResponse
+	TargetLanguage string `json:"target_language,omitempty"` // Empty for transform
+	Provider       string `json:"provider,omitempty"`
+	Model          string `json:"model,omitempty"`
+	OutputFile     string `json:"output_file,omitempty"`
+	DurationMS     int64  `json:"duration_ms"`
+}
+
+// writeJSONResult prints result to stdout as a single line of JSON
+func writeJSONResult(result jsonTranslationResult) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false) // Keep markdown and HTML content readable
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("failed to write JSON output: %w", err)
+	}
+	return nil
+}
+
 // performTranslation performs the translation using the specified provider
-func performTranslation(provider LLMProvider, content, targetLang, customInstruction string) (string, error) {
-	options := TranslationOptions{
-		TargetLanguage:    targetLang,
-		CustomInstruction: customInstruction,
-		PreserveFormat:    true,
-		Verbose:           verbose,
+func performTranslation(provider LLMProvider, content string, options TranslationOptions) (string, error) {
+	if cached, ok := cachedTranslation(content, options); ok {
+		return cached, nil
+	}
+
+	// Keep code and URLs out of the model's reach; they are put back verbatim afterwards
+	text, segments := content, []protectedSegment(nil)
+	if options.Protect {
+		text, segments = protectSegments(content)
+		if len(segments) > 0 {
+			log("Protected %d code blocks, code spans and URLs with placeholders", len(segments))
+		}
 	}
 
 	providerName := provider.GetProviderName()
-	spinner := NewSpinner(fmt.Sprintf("Translating with %s...", providerName))
+	message, completed := fmt.Sprintf("Translating with %s...", providerName), "Translation completed"
+	if options.Progress != nil {
+		message = fmt.Sprintf("Translating with %s... (%s)", providerName, options.Progress.status())
+		completed = fmt.Sprintf("Chunk %d/%d completed", options.Progress.current, options.Progress.total)
+	}
+	spinner := NewSpinner(message)
 	spinner.Start()
 
 	ctx := context.Background()
-	response, err := provider.Translate(ctx, content, options)
+	response, err := provider.Translate(ctx, text, options)
 	if err != nil {
 		spinner.Stop("Translation failed")
 		return "", fmt.Errorf("%s translation failed: %w", providerName, err)
 	}
 
-	spinner.Stop("Translation completed")
+	spinner.Stop(completed)
 
 	if response.Status != "success" {
 		return "", fmt.Errorf("translation failed: %s (status: %s)", response.Message, response.Status)
 	}
 
-	return response.Content, nil
+	if verbose && response.Usage != nil {
+		log("%s", formatUsage(*response.Usage))
+	}
+
+	translation := response.Content
+	if len(segments) > 0 {
+		var missing int
+		translation, missing = restoreSegments(translation, segments)
+		if missing > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: the translation dropped %d of %d code/URL placeholders\n", missing, len(segments))
+		}
+	}
+
+	storeTranslation(content, options, translation)
+	return translation, nil
+}
+
+// writePromptPreview writes the prompts that translating content would send to w without calling
+// the provider: one per chunk, after the same placeholder protection as a translation
+func writePromptPreview(w io.Writer, provider LLMProvider, content string, options TranslationOptions, maxChunkTokens int) error {
+	chunks, _ := splitMarkdownChunks(content, maxChunkTokens)
+
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		if options.Protect {
+			chunk, _ = protectSegments(chunk)
+		}
+		if len(chunks) > 1 {
+			fmt.Fprintf(&sb, "===== Chunk %d/%d =====\n", i+1, len(chunks))
+		}
+		sb.WriteString(provider.BuildPrompt(chunk, options))
+		sb.WriteString("\n")
+		if i < len(chunks)-1 {
+			sb.WriteString("\n")
+		}
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write prompt: %w", err)
+	}
+	return nil
+}
+
+// cachedTranslation returns the cached translation of content when caching is enabled. Repair
+// attempts always reach the provider, since the cache could only return the attempt being repaired.
+func cachedTranslation(content string, options TranslationOptions) (string, bool) {
+	if options.Cache == nil || options.Strict {
+		return "", false
+	}
+
+	cached, ok := options.Cache.Get(content, options)
+	if ok {
+		progress("Using cached translation (%d characters)", len(cached))
+	}
+	return cached, ok
+}
+
+// storeTranslation caches a translation; failures only cost a future cache miss, so they are logged.
+// Repair attempts and translations that fail structure validation are not cached, so a broken
+// translation is not served to every rerun; translateWithRepair caches a successful repair itself.
+func storeTranslation(content string, options TranslationOptions, translation string) {
+	if options.Cache == nil || options.Strict {
+		return
+	}
+	if issues := validateStructure(content, translation); len(issues) > 0 {
+		log("Not caching the translation: %s", strings.Join(issues, "; "))
+		return
+	}
+
+	if err := options.Cache.Put(content, options, translation); err != nil {
+		log("Warning: failed to cache translation: %v", err)
+	}
+}
+
+// performStreamingTranslation translates content with a streaming provider, writing text to w as it
+// arrives instead of showing a spinner
+func performStreamingTranslation(provider StreamingProvider, content string, options TranslationOptions, w io.Writer) error {
+	if cached, ok := cachedTranslation(content, options); ok {
+		if _, err := io.WriteString(w, cached); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	providerName := provider.GetProviderName()
+	progress("Streaming translation from %s...", providerName)
+
+	ctx := context.Background()
+	response, err := provider.TranslateStream(ctx, content, options, w)
+	if err != nil {
+		return fmt.Errorf("%s translation failed: %w", providerName, err)
+	}
+
+	if response.Status != "success" {
+		return fmt.Errorf("translation failed: %s (status: %s)", response.Message, response.Status)
+	}
+
+	if verbose && response.Usage != nil {
+		log("%s", formatUsage(*response.Usage))
+	}
+
+	storeTranslation(content, options, response.Content)
+	return nil
+}
+
+// translateStreaming streams the translation of content to w chunk by chunk, writing the original
+// separators between chunks. Streamed text cannot be validated and repaired after it is printed.
+func translateStreaming(provider StreamingProvider, content string, options TranslationOptions, maxChunkTokens int, w io.Writer) error {
+	chunks, separators := splitMarkdownChunks(content, maxChunkTokens)
+	if len(chunks) > 1 {
+		progress("Document split into %d chunks of up to ~%d tokens", len(chunks), maxChunkTokens)
+	}
+
+	for i, chunk := range chunks {
+		if len(chunks) > 1 {
+			progress("Translating chunk %d/%d...", i+1, len(chunks))
+		}
+
+		if err := performStreamingTranslation(provider, chunk, options, &newlineTrimWriter{w: w}); err != nil {
+			if len(chunks) > 1 {
+				return fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+			}
+			return err
+		}
+
+		if i < len(separators) {
+			if _, err := io.WriteString(w, separators[i]); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// newlineTrimWriter drops leading newlines and holds back trailing newlines until more text follows,
+// so streamed chunks join their separators the same way as strings.Trim(translated, "\n")
+type newlineTrimWriter struct {
+	w       io.Writer
+	started bool
+	pending int // Newlines held back from previous writes
+}
+
+func (t *newlineTrimWriter) Write(p []byte) (int, error) {
+	text := string(p)
+	if !t.started {
+		text = strings.TrimLeft(text, "\n")
+		if text == "" {
+			return len(p), nil
+		}
+		t.started = true
+	}
+
+	trimmed := strings.TrimRight(text, "\n")
+	if trimmed == "" {
+		t.pending += len(text)
+		return len(p), nil
+	}
+
+	out := strings.Repeat("\n", t.pending) + trimmed
+	t.pending = len(text) - len(trimmed)
+	if _, err := io.WriteString(t.w, out); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// formatUsage describes the token usage of a request with its estimated cost
+func formatUsage(usage TokenUsage) string {
+	kind := "Token usage"
+	if usage.Estimated {
+		kind = "Estimated token usage"
+	}
+	msg := fmt.Sprintf("%s: %d input + %d output tokens", kind, usage.InputTokens, usage.OutputTokens)
+
+	model := pricingModel(usage.Provider, usage.Model)
+	if model == nil {
+		return msg + fmt.Sprintf(" (no pricing data for %s)", usage.Model)
+	}
+
+	cost := TokenCost(*model, usage.InputTokens, usage.OutputTokens)
+	return msg + fmt.Sprintf(", ~$%.4f with %s pricing", cost, model.ID)
+}
+
+// writeCostEstimate writes the size, approximate token count and estimated cost of translating
+// content with a provider's model. The translation is assumed to be about as long as the input.
+func writeCostEstimate(w io.Writer, provider, modelID, content string) {
+	chars := utf8.RuneCountInString(content)
+	tokens := estimateTokens(content)
+
+	fmt.Fprintf(w, "Input: %d characters (%d bytes)\n", chars, len(content))
+	fmt.Fprintf(w, "Approximate tokens: ~%d input + ~%d output\n", tokens, tokens)
+	fmt.Fprintf(w, "Model: %s (%s)\n", modelID, provider)
+
+	// Claude Code is billed through the Claude subscription rather than per token
+	var model *Model
+	if provider != ProviderTypeClaude {
+		model = FindModel(provider, modelID)
+	}
+	if model == nil {
+		fmt.Fprintf(w, "Estimated cost: cost estimate unavailable\n")
+		return
+	}
+
+	cost := EstimateCost(*model, chars, chars)
+	fmt.Fprintf(w, "Estimated cost: ~$%.4f ($%.2f/1M input, $%.2f/1M output tokens)\n",
+		cost, model.InputCostPer1M, model.OutputCostPer1M)
+}
+
+// translateWithRepair translates content and, when maxRepairs > 0, re-runs the translation with
+// a stricter prompt while the output fails structure validation, keeping the best result
+func translateWithRepair(provider LLMProvider, content string, options TranslationOptions, maxRepairs int) (string, error) {
+	result, err := performTranslation(provider, content, options)
+	if err != nil || maxRepairs == 0 {
+		return result, err
+	}
+
+	issues := validateStructure(content, result)
+	if len(issues) == 0 {
+		return result, nil
+	}
+
+	strict := options
+	strict.Strict = true
+	strict.CustomInstruction = strings.TrimSpace(options.CustomInstruction + "\n\n" + repairInstruction(content))
+
+	attempts := 0
+	for attempts < maxRepairs && len(issues) > 0 {
+		attempts++
+		progress("Structure check failed (%s); repair attempt %d/%d", strings.Join(issues, "; "), attempts, maxRepairs)
+
+		repaired, err := performTranslation(provider, content, strict)
+		if err != nil {
+			log("Repair attempt %d failed: %v", attempts, err)
+			continue
+		}
+
+		if repairedIssues := validateStructure(content, repaired); len(repairedIssues) < len(issues) {
+			result, issues = repaired, repairedIssues
+		}
+	}
+
+	if len(issues) > 0 {
+		progress("Auto-repair made %d attempt(s); %d structure issue(s) remain: %s", attempts, len(issues), strings.Join(issues, "; "))
+	} else {
+		progress("Auto-repair succeeded after %d attempt(s)", attempts)
+		// Cache the repair under the original options, so reruns get it without repairing again
+		storeTranslation(content, options, result)
+	}
+
+	return result, nil
 }
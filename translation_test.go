@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDocumentFilePrecedenceOverStdin(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "input.md")
+	if err := os.WriteFile(inputPath, []byte("# From file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pipe content into stdin
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdinWriter.WriteString("# From stdin\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = stdinWriter.Close()
+
+	// Capture stderr
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalStdin, originalStderr := os.Stdin, os.Stderr
+	os.Stdin, os.Stderr = stdinReader, stderrWriter
+	defer func() { os.Stdin, os.Stderr = originalStdin, originalStderr }()
+
+	content, err := readDocument(inputPath)
+
+	_ = stderrWriter.Close()
+	os.Stdin, os.Stderr = originalStdin, originalStderr
+	stderrOutput, _ := io.ReadAll(stderrReader)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content != "# From file" {
+		t.Errorf("readDocument() = %q, want file content %q", content, "# From file")
+	}
+	if !strings.Contains(string(stderrOutput), "piped stdin is ignored") {
+		t.Errorf("Expected warning about ignored stdin, got %q", string(stderrOutput))
+	}
+}
+
+func TestReadTranslationInputFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	// Files are joined in argument order, not sorted
+	var paths []string
+	for _, name := range []string{"b", "a"} {
+		path := filepath.Join(tempDir, name+".md")
+		if err := os.WriteFile(path, []byte("# "+strings.ToUpper(name)+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	content, err := readTranslationInput(&CLIArgs{InputFiles: paths})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "# B" + documentSeparator + "# A"; content != expected {
+		t.Errorf("readTranslationInput() = %q, want %q", content, expected)
+	}
+
+	_, err = readTranslationInput(&CLIArgs{InputFiles: []string{paths[0], filepath.Join(tempDir, "missing.md")}})
+	if err == nil || !strings.Contains(err.Error(), "missing.md") {
+		t.Errorf("Expected an error naming the missing file, got %v", err)
+	}
+}
+
+func TestReadTranslationInputCRLF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("# Notes\r\n\r\nFirst line\r\nSecond line\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := readTranslationInput(&CLIArgs{InputFile: path})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "# Notes\n\nFirst line\nSecond line"; content != expected {
+		t.Errorf("readTranslationInput() = %q, want %q", content, expected)
+	}
+
+	// --keep-crlf passes the line endings through, dropping only the final one
+	content, err = readTranslationInput(&CLIArgs{InputFile: path, KeepCRLF: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "# Notes\r\n\r\nFirst line\r\nSecond line"; content != expected {
+		t.Errorf("readTranslationInput() with KeepCRLF = %q, want %q", content, expected)
+	}
+}
+
+func TestReadDocumentLongLine(t *testing.T) {
+	// A single 200KB line exceeds bufio.Scanner's default 64KB token limit
+	longLine := strings.Repeat("data:image/png;base64,iVBORw0KGgo", 200*1024/33+1)[:200*1024]
+
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_, _ = stdinWriter.WriteString(longLine + "\n")
+		_ = stdinWriter.Close()
+	}()
+
+	originalStdin := os.Stdin
+	os.Stdin = stdinReader
+	defer func() { os.Stdin = originalStdin }()
+
+	content, err := readDocument("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content != longLine {
+		t.Errorf("readDocument() returned %d bytes, want %d bytes intact", len(content), len(longLine))
+	}
+}
+
+func TestReadDocumentFileValidation(t *testing.T) {
+	dir := t.TempDir()
+	emptyPath := filepath.Join(dir, "empty.md")
+	if err := os.WriteFile(emptyPath, []byte("  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"missing file", filepath.Join(dir, "missing.md"), "input file not found"},
+		{"directory", dir, "input file is a directory"},
+		{"empty file", emptyPath, "empty document provided"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readDocumentFile(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("readDocumentFile(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteTranslationOutput(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "README.ja.md")
+
+	if err := checkOutputFile(outputPath, false); err != nil {
+		t.Fatalf("checkOutputFile() on missing file: %v", err)
+	}
+
+	// Silence the confirmation written to stderr
+	originalStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = originalStderr }()
+
+	if err := writeTranslationOutput(outputPath, "# タイトル\n"); err != nil {
+		t.Fatalf("writeTranslationOutput() error: %v", err)
+	}
+
+	written, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != "# タイトル\n" {
+		t.Errorf("Output file content = %q, want %q", written, "# タイトル\n")
+	}
+
+	if err := checkOutputFile(outputPath, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("checkOutputFile() on existing file = %v, want error mentioning --force", err)
+	}
+	if err := checkOutputFile(outputPath, true); err != nil {
+		t.Errorf("checkOutputFile() with force = %v, want nil", err)
+	}
+}
+
+// fakeProvider returns canned responses in order, recording the options it receives
+type fakeProvider struct {
+	responses []string
+	calls     []TranslationOptions
+}
+
+func (p *fakeProvider) Translate(ctx context.Context, content string, options TranslationOptions) (*TranslationResponse, error) {
+	p.calls = append(p.calls, options)
+	response := p.responses[min(len(p.calls)-1, len(p.responses)-1)]
+	return &TranslationResponse{Content: response, Status: "success"}, nil
+}
+
+func (p *fakeProvider) ValidateConfig() error                    { return nil }
+func (p *fakeProvider) GetProviderName() string                  { return "Fake" }
+func (p *fakeProvider) GetSupportedLanguages() map[string]string { return supportedLanguages }
+func (p *fakeProvider) BuildPrompt(content string, options TranslationOptions) string {
+	return content
+}
+
+func TestTranslateWithRepair(t *testing.T) {
+	source := "# Title\n\n- item\n\n```\ncode\n```\n"
+	broken := "# Titre\n\n- élément\n\n```\ncode\n"
+	fixed := "# Titre\n\n- élément\n\n```\ncode\n```\n"
+
+	provider := &fakeProvider{responses: []string{broken, fixed}}
+	result, err := translateWithRepair(provider, source, TranslationOptions{TargetLanguage: "fr"}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != fixed {
+		t.Errorf("translateWithRepair() = %q, want %q", result, fixed)
+	}
+	if len(provider.calls) != 2 {
+		t.Fatalf("Expected 2 provider calls, got %d", len(provider.calls))
+	}
+	if !provider.calls[1].Strict {
+		t.Error("Expected repair attempt to use strict options")
+	}
+}
+
+func TestTransformPrompts(t *testing.T) {
+	options := TranslationOptions{CustomInstruction: "convert to a formal tone", Transform: true}
+
+	claude := &ClaudeCodeProvider{}
+	prompts := map[string]string{
+		"translationSystemPrompt": translationSystemPrompt(options) + "\n" + translationUserPrompt(options, "Hello"),
+		"generatePrompt":          claude.generatePrompt(options, "Hello"),
+	}
+	for name, prompt := range prompts {
+		if strings.Contains(prompt, "Translate the following") {
+			t.Errorf("%s asks for a translation:\n%s", name, prompt)
+		}
+		for _, want := range []string{"Instruction: convert to a formal tone", "original language", "Preserve", "Hello"} {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("%s is missing %q:\n%s", name, want, prompt)
+			}
+		}
+	}
+}
+
+func TestWritePromptPreview(t *testing.T) {
+	provider := &fakeProvider{}
+	content := "# One\n\nRun `make` first.\n\n# Two\n\nSee https://example.com for more.\n"
+
+	var sb strings.Builder
+	if err := writePromptPreview(&sb, provider, content, TranslationOptions{TargetLanguage: "ja", Protect: true}, 8); err != nil {
+		t.Fatalf("writePromptPreview() error: %v", err)
+	}
+	preview := sb.String()
+
+	for _, want := range []string{"===== Chunk 1/2 =====", "===== Chunk 2/2 =====", "⟦CODE_0⟧", "⟦URL_0⟧"} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("Preview is missing %q:\n%s", want, preview)
+		}
+	}
+	if len(provider.calls) != 0 {
+		t.Errorf("Expected no provider calls, got %d", len(provider.calls))
+	}
+
+	sb.Reset()
+	if err := writePromptPreview(&sb, provider, "Hello\n", TranslationOptions{TargetLanguage: "ja"}, 1000); err != nil {
+		t.Fatalf("writePromptPreview() error: %v", err)
+	}
+	if strings.Contains(sb.String(), "Chunk") {
+		t.Errorf("Expected no chunk headers for a single chunk, got %q", sb.String())
+	}
+}
+
+func TestNewlineTrimWriter(t *testing.T) {
+	var out strings.Builder
+	w := &newlineTrimWriter{w: &out}
+	for _, piece := range []string{"\n\n", "\nHello", "\n", "\nworld\n", "\n"} {
+		_, _ = w.Write([]byte(piece))
+	}
+
+	if out.String() != "Hello\n\nworld" {
+		t.Errorf("Output = %q, want %q", out.String(), "Hello\n\nworld")
+	}
+}
+
+func TestWriteJSONResult(t *testing.T) {
+	stdoutFile, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStdout := os.Stdout
+	os.Stdout = stdoutFile
+	err = writeJSONResult(jsonTranslationResult{
+		TranslationResponse: TranslationResponse{Content: "<b>こんにちは</b>", Status: "success"},
+		TargetLanguage:      "ja",
+		Provider:            ProviderTypeOpenAI,
+		Model:               "gpt-4o-mini",
+		DurationMS:          1200,
+	})
+	os.Stdout = originalStdout
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(stdoutFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+
+	expected := map[string]any{
+		"content":         "<b>こんにちは</b>",
+		"status":          "success",
+		"message":         "",
+		"target_language": "ja",
+		"provider":        "openai",
+		"model":           "gpt-4o-mini",
+		"duration_ms":     float64(1200),
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("writeJSONResult() wrote %v, want %v", decoded, expected)
+	}
+	if !strings.Contains(string(output), "<b>") {
+		t.Errorf("Expected HTML in content to stay unescaped, got %s", output)
+	}
+}
+
+func TestWriteCostEstimate(t *testing.T) {
+	content := strings.Repeat("a", 4000)
+
+	tests := []struct {
+		name     string
+		provider string
+		model    string
+		costLine string
+	}{
+		{"Catalog model", ProviderTypeOpenAI, "gpt-4o", "Estimated cost: ~$0.0125"},
+		{"Claude Code", ProviderTypeClaude, "sonnet", "Estimated cost: cost estimate unavailable"},
+		{"Unknown model", ProviderTypeOllama, "llama3.2", "Estimated cost: cost estimate unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			writeCostEstimate(&sb, tt.provider, tt.model, content)
+			output := sb.String()
+
+			if !strings.Contains(output, "Approximate tokens: ~1000 input + ~1000 output") {
+				t.Errorf("Expected a token count, got:\n%s", output)
+			}
+			if !strings.Contains(output, tt.costLine) {
+				t.Errorf("Expected %q, got:\n%s", tt.costLine, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// protectedSegmentPattern matches inline code, link targets, URLs and HTML tags,
+// which are never touched by typographic post-processing
+var protectedSegmentPattern = regexp.MustCompile("`[^`]*`|!\\[|\\]\\([^)]*\\)|https?://\\S+|<[^>]+>")
+
+// typographyProcessors maps language codes to their typographic post-processors
+var typographyProcessors = map[string]func(string) string{
+	"ja": fixJapaneseTypography,
+	"zh": fixChineseTypography,
+	"fr": fixFrenchTypography,
+}
+
+// applyTypography normalizes language-specific typography in translated output.
+// Fenced code blocks, inline code, URLs and HTML tags are left untouched.
+func applyTypography(lang, content string) string {
+	process, ok := typographyProcessors[lang]
+	if !ok {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	inCodeBlock := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		lines[i] = processUnprotected(line, process)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// processUnprotected applies process to the parts of line outside protected segments
+func processUnprotected(line string, process func(string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range protectedSegmentPattern.FindAllStringIndex(line, -1) {
+		sb.WriteString(process(line[last:loc[0]]))
+		sb.WriteString(line[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(process(line[last:]))
+	return sb.String()
+}
+
+// isCJK reports whether r is a Han, Hiragana or Katakana character
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// replaceAfterCJK replaces ASCII punctuation that directly follows a CJK character and ends
+// a clause, dropping the space after it since full-width punctuation carries its own spacing.
+// Punctuation followed by other text (e.g. "設定.json") is left alone.
+func replaceAfterCJK(text string, replacements map[rune]rune) string {
+	runes := []rune(text)
+	var out []rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		full, ok := replacements[r]
+		endsClause := i+1 == len(runes) || unicode.IsSpace(runes[i+1]) || isCJK(runes[i+1])
+		if ok && i > 0 && isCJK(runes[i-1]) && endsClause {
+			out = append(out, full)
+			if i+1 < len(runes) && runes[i+1] == ' ' {
+				i++
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// fixJapaneseTypography converts half-width punctuation after Japanese text to full-width
+func fixJapaneseTypography(text string) string {
+	return replaceAfterCJK(text, map[rune]rune{
+		',': '、',
+		'.': '。',
+		'!': '！',
+		'?': '？',
+		':': '：',
+	})
+}
+
+// fixChineseTypography converts half-width punctuation after Chinese text to full-width
+// and adds a space between Chinese and Latin letters or digits
+func fixChineseTypography(text string) string {
+	text = replaceAfterCJK(text, map[rune]rune{
+		',': '，',
+		'.': '。',
+		'!': '！',
+		'?': '？',
+		':': '：',
+		';': '；',
+	})
+
+	runes := []rune(text)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 {
+			prev := runes[i-1]
+			if (isCJK(prev) && isLatinOrDigit(r)) || (isLatinOrDigit(prev) && isCJK(r)) {
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// isLatinOrDigit reports whether r is an ASCII letter or digit
+func isLatinOrDigit(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// frenchQuotePattern matches text in straight double quotes
+var frenchQuotePattern = regexp.MustCompile(`"([^"\n]+)"`)
+
+// frenchPunctuationPattern matches high punctuation directly after a word or closing quote
+var frenchPunctuationPattern = regexp.MustCompile(`([\p{L}»)]) ?([;:!?])`)
+
+// fixFrenchTypography uses guillemets for quotes and a narrow no-break space before ; : ! ?
+func fixFrenchTypography(text string) string {
+	text = frenchQuotePattern.ReplaceAllString(text, "«\u202f$1\u202f»")
+	return frenchPunctuationPattern.ReplaceAllString(text, "$1\u202f$2")
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestApplyTypography(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		input    string
+		expected string
+	}{
+		{"Japanese punctuation", "ja", "これはテストです. 次へ進みます!", "これはテストです。次へ進みます！"},
+		{"Japanese file name untouched", "ja", "設定.json を編集", "設定.json を編集"},
+		{"Chinese spacing", "zh", "使用Go语言", "使用 Go 语言"},
+		{"French punctuation", "fr", "Attention: voir \"ici\"!", "Attention\u202f: voir «\u202fici\u202f»\u202f!"},
+		{"French link target untouched", "fr", "Voir [doc](http://a.b/c?d=1)", "Voir [doc](http://a.b/c?d=1)"},
+		{"Inline code untouched", "ja", "実行 `a.b.` です.", "実行 `a.b.` です。"},
+		{"Code block untouched", "ja", "```\nです.\n```", "```\nです.\n```"},
+		{"Unsupported language unchanged", "de", "Hallo: Welt!", "Hallo: Welt!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyTypography(tt.lang, tt.input)
+			if result != tt.expected {
+				t.Errorf("applyTypography(%q, %q) = %q, want %q", tt.lang, tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 	"time"
@@ -12,6 +13,13 @@ import (
 
 var verbose bool
 
+// quiet silences progress messages and spinners; errors, warnings and --verbose debug logs still print
+var quiet bool
+
+// spinnerDisabled replaces spinner animations with plain progress lines, e.g. while several
+// translations run in parallel and would overwrite each other's spinner line
+var spinnerDisabled bool
+
 // log outputs debug messages when verbose mode is enabled
 func log(format string, args ...interface{}) {
 	if verbose {
@@ -19,13 +27,17 @@ func log(format string, args ...interface{}) {
 	}
 }
 
-// progress outputs informational messages
+// progress outputs informational messages unless quiet mode is enabled
 func progress(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	fmt.Fprintf(os.Stderr, "[INFO] %s\n", fmt.Sprintf(format, args...))
 }
 
 // Spinner represents a loading spinner with elapsed time display
 type Spinner struct {
+	mu        sync.Mutex // Guards message, which Update changes while the animation runs
 	message   string
 	frames    []string
 	interval  time.Duration
@@ -45,7 +57,10 @@ func NewSpinner(message string) *Spinner {
 
 // Start begins the spinner animation
 func (s *Spinner) Start() {
-	if !isTerminal() {
+	if quiet {
+		return
+	}
+	if spinnerDisabled || !isTerminal() {
 		fmt.Fprintf(os.Stderr, "[INFO] %s\n", s.message)
 		return
 	}
@@ -55,20 +70,38 @@ func (s *Spinner) Start() {
 	s.startTime = time.Now()
 
 	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		frame := 0
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(s.interval):
-				elapsed := time.Since(s.startTime)
-				fmt.Fprintf(os.Stderr, "\r%s %s (%s)", s.frames[frame], s.message, formatDuration(elapsed))
-				frame = (frame + 1) % len(s.frames)
-			}
+	go s.animate(ctx, os.Stderr)
+}
+
+// animate redraws the spinner line on w until ctx is canceled
+func (s *Spinner) animate(ctx context.Context, w io.Writer) {
+	defer s.wg.Done()
+	frame := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.interval):
+			elapsed := time.Since(s.startTime)
+			// Clear the rest of the line in case the message got shorter
+			fmt.Fprintf(w, "\r%s %s (%s)\033[K", s.frames[frame], s.currentMessage(), formatDuration(elapsed))
+			frame = (frame + 1) % len(s.frames)
 		}
-	}()
+	}
+}
+
+// Update replaces the spinner message in place without restarting the animation or elapsed time
+func (s *Spinner) Update(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.message = message
+}
+
+// currentMessage returns the message to display
+func (s *Spinner) currentMessage() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.message
 }
 
 // Stop ends the spinner animation and displays a final message
@@ -82,7 +115,7 @@ func (s *Spinner) Stop(finalMessage string) {
 
 	if isTerminal() {
 		elapsed := time.Since(s.startTime)
-		fmt.Fprintf(os.Stderr, "\r✓ %s (%s)\n", finalMessage, formatDuration(elapsed))
+		fmt.Fprintf(os.Stderr, "\r✓ %s (%s)\033[K\n", finalMessage, formatDuration(elapsed))
 	} else {
 		fmt.Fprintf(os.Stderr, "[INFO] %s\n", finalMessage)
 	}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -35,6 +40,9 @@ func TestMaskAPIKey(t *testing.T) {
 	}{
 		{"Empty key", "", "(not set)"},
 		{"Short key", "abc", "***"},
+		{"Six-character key", "x7Qz9K", "***"},
+		{"Key that four and four characters would reveal", "12345678", "***"},
+		{"Key just under the revealed length", "sk-123456789abc", "***"},
 		{"Normal key", "sk-1234567890abcdef", "sk-1...cdef"},
 		{"Long key", "sk-proj-1234567890abcdefghijklmnopqrstuvwxyz", "sk-p...wxyz"},
 	}
@@ -48,3 +56,52 @@ func TestMaskAPIKey(t *testing.T) {
 		})
 	}
 }
+
+func TestSpinnerUpdate(t *testing.T) {
+	spinner := NewSpinner("Merging files... (0/2)")
+	spinner.interval = time.Millisecond
+	spinner.startTime = time.Now()
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	spinner.wg.Add(1)
+	go spinner.animate(ctx, &buf)
+
+	// Updates race with the animation goroutine unless the message is guarded
+	for i := 1; i <= 2; i++ {
+		spinner.Update(fmt.Sprintf("Processing files... (%d/2)", i))
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	spinner.wg.Wait()
+
+	if !strings.Contains(buf.String(), "Processing files... (2/2)") {
+		t.Errorf("Expected the updated message to be drawn, got %q", buf.String())
+	}
+}
+
+func TestQuietSuppressesProgress(t *testing.T) {
+	stderrFile, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStderr := os.Stderr
+	os.Stderr = stderrFile
+	defer func() { os.Stderr = originalStderr }()
+
+	quiet = true
+	defer func() { quiet = false }()
+
+	progress("Reading document...")
+	spinner := NewSpinner("Translating...")
+	spinner.Start()
+	spinner.Stop("Translation completed")
+
+	output, err := os.ReadFile(stderrFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output) != 0 {
+		t.Errorf("Expected no output in quiet mode, got %q", output)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, injected by GoReleaser via -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// versionString describes the build for --version and bug reports
+func versionString() string {
+	v, c, d := version, commit, date
+
+	// Builds without ldflags (go install, go build) still record VCS details in the binary
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if c == "none" {
+					c = setting.Value
+				}
+			case "vcs.time":
+				if d == "unknown" {
+					d = setting.Value
+				}
+			}
+		}
+	}
+
+	return fmt.Sprintf("doc version %s\ncommit: %s\nbuilt: %s\ngo: %s %s/%s",
+		v, c, d, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}